@@ -1,13 +1,30 @@
 package main
 
 import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"net/http"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/bbengfort/otterdb/pkg"
 	"github.com/bbengfort/otterdb/pkg/config"
 	"github.com/bbengfort/otterdb/pkg/otter"
+	"github.com/bbengfort/otterdb/pkg/replica"
+	"github.com/bbengfort/otterdb/pkg/server/api/v1"
+	"github.com/bbengfort/otterdb/pkg/server/auth"
 	"github.com/joho/godotenv"
 	"github.com/urfave/cli/v2"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 )
 
 func main() {
@@ -25,11 +42,139 @@ func main() {
 			Action:   serve,
 			Category: "server",
 		},
+		{
+			Name:     "init",
+			Usage:    "initialize the replica configured from the environment as a member of a cluster",
+			Action:   initialize,
+			Category: "cluster",
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:    "cluster",
+					Aliases: []string{"c"},
+					Usage:   "the id of the cluster to join (default is to bootstrap a new cluster)",
+					EnvVars: []string{"OTTER_CLUSTER_ID"},
+				},
+			},
+		},
+		{
+			Name:     "transfer",
+			Usage:    "transfer leadership from the leader to another replica, e.g. for maintenance",
+			Action:   transfer,
+			Category: "cluster",
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:    "url",
+					Aliases: []string{"u"},
+					Usage:   "the url of the web ui of the leader",
+					Value:   "http://localhost:2208",
+					EnvVars: []string{"OTTER_WEB_URL"},
+				},
+				&cli.StringFlag{
+					Name:    "target",
+					Aliases: []string{"t"},
+					Usage:   "the name of the replica to transfer to (default is the peer with the lowest pid)",
+				},
+				&cli.DurationFlag{
+					Name:  "timeout",
+					Usage: "the maximum amount of time to wait for the transfer to complete",
+					Value: 30 * time.Second,
+				},
+				apiKeyFlag,
+				tokenFlag,
+			},
+		},
+		{
+			Name:      "add-peer",
+			Usage:     "add a replica to the quorum, e.g. to replace a replica that has failed",
+			ArgsUsage: "name",
+			Action:    addPeer,
+			Category:  "cluster",
+			Flags: append([]cli.Flag{
+				&cli.StringFlag{
+					Name:     "peer-addr",
+					Aliases:  []string{"p"},
+					Usage:    "the address that the other replicas dial to replicate to the replica",
+					Required: true,
+				},
+				&cli.StringFlag{
+					Name:  "client-addr",
+					Usage: "the address of the database server of the replica",
+				},
+				&cli.UintFlag{
+					Name:  "pid",
+					Usage: "the precedence id of the replica",
+				},
+				&cli.StringFlag{
+					Name:  "region",
+					Usage: "the region that the replica is located in",
+				},
+				&cli.BoolFlag{
+					Name:  "learner",
+					Usage: "add the replica as a learner that does not vote until it is promoted",
+				},
+			}, adminFlags...),
+		},
+		{
+			Name:      "remove-peer",
+			Usage:     "remove a replica from the quorum",
+			ArgsUsage: "name",
+			Action:    removePeer,
+			Category:  "cluster",
+			Flags:     adminFlags,
+		},
+		{
+			Name:      "promote-peer",
+			Usage:     "promote a learner to a voting member of the quorum once it has caught up",
+			ArgsUsage: "name",
+			Action:    promotePeer,
+			Category:  "cluster",
+			Flags:     adminFlags,
+		},
 	}
 
 	app.Run(os.Args)
 }
 
+// The credentials of an admin, which are the credentials of the database server.
+var (
+	apiKeyFlag = &cli.StringFlag{
+		Name:    "api-key",
+		Aliases: []string{"k"},
+		Usage:   "the api key to authenticate with",
+		EnvVars: []string{"OTTER_API_KEY"},
+	}
+
+	tokenFlag = &cli.StringFlag{
+		Name:    "token",
+		Usage:   "the bearer token to authenticate with",
+		EnvVars: []string{"OTTER_TOKEN"},
+	}
+)
+
+// Flags to connect to the database server of the leader with the credentials of an
+// admin to manage the cluster.
+var adminFlags = []cli.Flag{
+	&cli.StringFlag{
+		Name:    "addr",
+		Aliases: []string{"a"},
+		Usage:   "the address of the database server of the leader",
+		Value:   "localhost:2202",
+		EnvVars: []string{"OTTER_ADDR"},
+	},
+	apiKeyFlag,
+	tokenFlag,
+	&cli.StringFlag{
+		Name:    "ca-cert",
+		Usage:   "path to the PEM encoded certificate authority of the database server (default is plaintext)",
+		EnvVars: []string{"OTTER_CA_CERT"},
+	},
+	&cli.DurationFlag{
+		Name:  "timeout",
+		Usage: "the maximum amount of time to wait for the change to be committed",
+		Value: 30 * time.Second,
+	},
+}
+
 //===========================================================================
 // Server Commands
 //===========================================================================
@@ -50,3 +195,167 @@ func serve(c *cli.Context) (err error) {
 	}
 	return nil
 }
+
+//===========================================================================
+// Cluster Commands
+//===========================================================================
+
+func initialize(c *cli.Context) (err error) {
+	var conf config.Config
+	if conf, err = config.New(); err != nil {
+		return cli.Exit(err, 1)
+	}
+
+	var clusterID string
+	if clusterID, err = replica.Bootstrap(conf.Replica, c.String("cluster")); err != nil {
+		return cli.Exit(err, 1)
+	}
+
+	fmt.Printf("replica %s initialized in cluster %s\n", conf.Replica.Name, clusterID)
+	if c.String("cluster") == "" {
+		fmt.Println("initialize the other replicas with: otterdb init --cluster " + clusterID)
+	}
+	return nil
+}
+
+func transfer(c *cli.Context) (err error) {
+	var body []byte
+	if body, err = json.Marshal(map[string]string{"target": c.String("target")}); err != nil {
+		return cli.Exit(err, 1)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), c.Duration("timeout"))
+	defer cancel()
+
+	var req *http.Request
+	if req, err = http.NewRequestWithContext(ctx, http.MethodPost, c.String("url")+"/v1/leader/transfer", bytes.NewReader(body)); err != nil {
+		return cli.Exit(err, 1)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	switch {
+	case c.String("api-key") != "":
+		req.Header.Set(auth.APIKeyHeader, c.String("api-key"))
+	case c.String("token") != "":
+		req.Header.Set(auth.AuthorizationHeader, "Bearer "+c.String("token"))
+	}
+
+	var rep *http.Response
+	if rep, err = http.DefaultClient.Do(req); err != nil {
+		return cli.Exit(err, 1)
+	}
+	defer rep.Body.Close()
+
+	out := make(map[string]interface{})
+	if err = json.NewDecoder(rep.Body).Decode(&out); err != nil {
+		return cli.Exit(fmt.Errorf("could not parse response (status %d): %w", rep.StatusCode, err), 1)
+	}
+
+	if rep.StatusCode != http.StatusOK {
+		if leader, ok := out["leader"].(string); ok && leader != "" {
+			return cli.Exit(fmt.Errorf("%s (the leader is %s)", out["error"], leader), 1)
+		}
+		return cli.Exit(out["error"], 1)
+	}
+
+	fmt.Println("leadership transferred")
+	return nil
+}
+
+func addPeer(c *cli.Context) (err error) {
+	if c.NArg() != 1 {
+		return cli.Exit("specify the name of the replica to add", 1)
+	}
+
+	peer := &api.Peer{
+		Pid:        uint32(c.Uint("pid")),
+		Name:       c.Args().First(),
+		Addr:       c.String("peer-addr"),
+		ClientAddr: c.String("client-addr"),
+		Region:     c.String("region"),
+		Learner:    c.Bool("learner"),
+	}
+
+	return changeMembership(c, func(ctx context.Context, client api.OtterClient) (*api.MembershipResult, error) {
+		return client.AddPeer(ctx, peer)
+	})
+}
+
+func removePeer(c *cli.Context) (err error) {
+	if c.NArg() != 1 {
+		return cli.Exit("specify the name of the replica to remove", 1)
+	}
+
+	in := &api.PeerRequest{Name: c.Args().First()}
+	return changeMembership(c, func(ctx context.Context, client api.OtterClient) (*api.MembershipResult, error) {
+		return client.RemovePeer(ctx, in)
+	})
+}
+
+func promotePeer(c *cli.Context) (err error) {
+	if c.NArg() != 1 {
+		return cli.Exit("specify the name of the learner to promote", 1)
+	}
+
+	in := &api.PeerRequest{Name: c.Args().First()}
+	return changeMembership(c, func(ctx context.Context, client api.OtterClient) (*api.MembershipResult, error) {
+		return client.PromotePeer(ctx, in)
+	})
+}
+
+// Connect to the database server and make the membership change with the credentials
+// of the admin; if the server is not the leader the address of the leader is reported.
+func changeMembership(c *cli.Context, change func(context.Context, api.OtterClient) (*api.MembershipResult, error)) (err error) {
+	var creds credentials.TransportCredentials
+	if creds, err = transportCredentials(c.String("ca-cert")); err != nil {
+		return cli.Exit(err, 1)
+	}
+
+	var cc *grpc.ClientConn
+	if cc, err = grpc.NewClient(c.String("addr"), grpc.WithTransportCredentials(creds)); err != nil {
+		return cli.Exit(err, 1)
+	}
+	defer cc.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), c.Duration("timeout"))
+	defer cancel()
+
+	switch {
+	case c.String("api-key") != "":
+		ctx = metadata.AppendToOutgoingContext(ctx, auth.APIKeyHeader, c.String("api-key"))
+	case c.String("token") != "":
+		ctx = metadata.AppendToOutgoingContext(ctx, auth.AuthorizationHeader, "Bearer "+c.String("token"))
+	}
+
+	var out *api.MembershipResult
+	if out, err = change(ctx, api.NewOtterClient(cc)); err != nil {
+		for _, detail := range status.Convert(err).Details() {
+			if redirect, ok := detail.(*api.Redirect); ok {
+				return cli.Exit(fmt.Errorf("%s (the leader is %s at %s)", status.Convert(err).Message(), redirect.Leader, redirect.Addr), 1)
+			}
+		}
+		return cli.Exit(status.Convert(err).Message(), 1)
+	}
+
+	fmt.Println("voters: " + strings.Join(out.Voters, ", "))
+	return nil
+}
+
+// Returns TLS credentials that trust the certificate authority, or plaintext
+// credentials if no certificate authority is specified.
+func transportCredentials(caPath string) (_ credentials.TransportCredentials, err error) {
+	if caPath == "" {
+		return insecure.NewCredentials(), nil
+	}
+
+	var data []byte
+	if data, err = os.ReadFile(caPath); err != nil {
+		return nil, fmt.Errorf("could not read certificate authority: %w", err)
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("no certificates found in %s", caPath)
+	}
+	return credentials.NewTLS(&tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}), nil
+}
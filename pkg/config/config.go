@@ -3,6 +3,7 @@ package config
 import (
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/rotationalio/confire"
@@ -24,6 +25,7 @@ type Config struct {
 	Maintenance bool                `default:"false" desc:"if true, the node will start in maintenance mode"`
 	LogLevel    logger.LevelDecoder `split_words:"true" default:"info" desc:"specify the verbosity of logging (trace, debug, info, warn, error, fatal panic)"`
 	ConsoleLog  bool                `split_words:"true" default:"false" desc:"if true logs colorized human readable output instead of json"`
+	DataDir     string              `split_words:"true" desc:"the directory where the node durably stores its data"`
 	Server      ServerConfig
 	Replica     ReplicaConfig
 	Web         WebConfig
@@ -34,13 +36,63 @@ type ServerConfig struct {
 	Maintenance bool   `env:"OTTER_MAINTENANCE" desc:"if true sets the server to maintenance mode; inherited from parent"`
 	Enabled     bool   `default:"true" desc:"if false, the client facing server will not be started, e.g. to uses this as a backup replica only"`
 	BindAddr    string `default:":2202" split_words:"true" desc:"the ip address and port to bind the database server on"`
+	TLS         ServerTLSConfig
+	Auth        AuthConfig
+}
+
+// ServerTLSConfig specifies the certificate and private key that the database server
+// uses to secure connections from clients. The server is plaintext if neither is set.
+type ServerTLSConfig struct {
+	CertPath string `split_words:"true" desc:"path to the PEM encoded certificate of the database server"`
+	KeyPath  string `split_words:"true" desc:"path to the PEM encoded private key of the certificate of the database server"`
+}
+
+// AuthConfig specifies how clients of the database server are authenticated; clients
+// may present a static api key or a JWT bearer token signed by a key in the key set. If
+// neither the keys nor the key set are specified then clients are not authenticated. If
+// roles are specified, the statements of clients are authorized by the roles assigned
+// to their api key or in the roles claim of their token.
+type AuthConfig struct {
+	KeysPath  string `split_words:"true" desc:"path to a JSON file of the static api keys that clients can authenticate with"`
+	JWKSPath  string `split_words:"true" desc:"path to the JSON web key set used to verify the signatures of bearer tokens"`
+	Issuer    string `desc:"if set, bearer tokens must be issued by this issuer"`
+	Audience  string `desc:"if set, bearer tokens must be intended for this audience"`
+	RolesPath string `split_words:"true" desc:"path to a JSON file of the roles that authorize the statements of clients; if not set, clients may execute any statement"`
 }
 
 type ReplicaConfig struct {
-	Maintenance bool   `env:"OTTER_MAINTENANCE" desc:"if true sets the replica to maintenance mode; inherited from parent"`
-	Enabled     bool   `default:"false" desc:"if false, the replica service will not be started, e.g. run as a single node cluster"`
-	BindAddr    string `default:":2204" split_words:"true" desc:"the ip address and port to bind the replica server on"`
-	Aggregate   bool   `default:"true" desc:"if true the replica will aggregate append entries messages into a single consensus ballot"`
+	Maintenance      bool          `env:"OTTER_MAINTENANCE" desc:"if true sets the replica to maintenance mode; inherited from parent"`
+	Enabled          bool          `default:"false" desc:"if false, the replica service will not be started, e.g. run as a single node cluster"`
+	BindAddr         string        `default:":2204" split_words:"true" desc:"the ip address and port to bind the replica server on"`
+	Aggregate        bool          `default:"true" desc:"if true the replica will aggregate append entries messages into a single consensus ballot"`
+	Name             string        `desc:"the unique name of the replica, must match the name of a peer in the peers file"`
+	Peers            string        `desc:"path to the peers.json file that describes the replicas in the quorum"`
+	DataDir          string        `env:"OTTER_DATA_DIR" desc:"the directory where the replica stores its write-ahead log; inherited from parent"`
+	Tick             time.Duration `default:"250ms" desc:"the heartbeat interval of the leader; election timeouts are a jittered multiple of the tick"`
+	Timeout          time.Duration `default:"500ms" desc:"the amount of time to wait for a remote peer to respond to an rpc"`
+	PreVote          bool          `split_words:"true" default:"true" desc:"if true candidates check that they can win an election before starting one so partitioned replicas do not disrupt the quorum"`
+	CheckQuorum      bool          `split_words:"true" default:"true" desc:"if true the leader steps down if a majority of the quorum has not responded within an election timeout"`
+	Priority         bool          `default:"true" desc:"if true replicas with lower pids have shorter election timeouts and reclaim leadership from replicas with higher pids once caught up"`
+	MaxInflight      int           `split_words:"true" default:"16" desc:"the maximum number of append entries requests the leader pipelines to a follower without waiting for replies"`
+	MaxInflightBytes int           `split_words:"true" default:"8388608" desc:"the maximum number of bytes of entries the leader has in flight to a follower; zero is unlimited"`
+	Stream           bool          `default:"true" desc:"if true the leader replicates entries to each follower on a long-lived stream rather than with an rpc per request"`
+	TLS              TLSConfig
+	Snapshot         SnapshotConfig
+}
+
+// TLSConfig specifies the certificate and private key that identify the replica and the
+// certificate authorities that sign the certificates of its peers. Connections between
+// replicas are secured with mutual TLS if any of the paths are specified.
+type TLSConfig struct {
+	CertPath string `split_words:"true" desc:"path to the PEM encoded certificate of the replica; must be valid for the name of the replica"`
+	KeyPath  string `split_words:"true" desc:"path to the PEM encoded private key of the certificate of the replica"`
+	CAPath   string `split_words:"true" desc:"path to the PEM encoded certificate authorities that sign the certificates of peers"`
+}
+
+type SnapshotConfig struct {
+	Threshold uint64 `default:"8192" desc:"the number of entries applied since the last snapshot that triggers a new snapshot; zero disables snapshots"`
+	Retain    int    `default:"3" desc:"the number of snapshots that are kept on disk"`
+	Trailing  uint64 `default:"1024" desc:"the number of entries kept in the log behind a snapshot so that slow followers can catch up"`
 }
 
 type WebConfig struct {
@@ -92,12 +144,124 @@ func (c Config) GetLogLevel() zerolog.Level {
 	return zerolog.Level(c.LogLevel)
 }
 
-func (c ServerConfig) Validate() error {
-	return nil
+func (c ServerConfig) Validate() (err error) {
+	if terr := c.TLS.Validate(); terr != nil {
+		err = errors.Join(err, fmt.Errorf("invalid server configuration: %w", terr))
+	}
+
+	if aerr := c.Auth.Validate(); aerr != nil {
+		err = errors.Join(err, fmt.Errorf("invalid server configuration: %w", aerr))
+	}
+
+	return err
+}
+
+// Enabled returns true if TLS is configured.
+func (c ServerTLSConfig) Enabled() bool {
+	return c.CertPath != "" || c.KeyPath != ""
+}
+
+func (c ServerTLSConfig) Validate() (err error) {
+	if !c.Enabled() {
+		return nil
+	}
+
+	if c.CertPath == "" {
+		err = errors.Join(err, errors.New("tls certificate path is required"))
+	}
+
+	if c.KeyPath == "" {
+		err = errors.Join(err, errors.New("tls key path is required"))
+	}
+
+	return err
+}
+
+// Enabled returns true if clients must authenticate with the database server.
+func (c AuthConfig) Enabled() bool {
+	return c.KeysPath != "" || c.JWKSPath != ""
 }
 
-func (c ReplicaConfig) Validate() error {
-	return nil
+func (c AuthConfig) Validate() (err error) {
+	if c.JWKSPath == "" && (c.Issuer != "" || c.Audience != "") {
+		err = errors.Join(err, errors.New("jwks path is required to verify the issuer or audience of bearer tokens"))
+	}
+
+	if c.RolesPath != "" && !c.Enabled() {
+		err = errors.Join(err, errors.New("keys or jwks path is required to authenticate clients before they are authorized"))
+	}
+
+	return err
+}
+
+func (c ReplicaConfig) Validate() (err error) {
+	// If the replica is not enabled then none of the consensus configuration is used.
+	if !c.Enabled {
+		return nil
+	}
+
+	if c.Name == "" {
+		err = errors.Join(err, errors.New("invalid replica configuration: name is required"))
+	}
+
+	if c.Peers == "" {
+		err = errors.Join(err, errors.New("invalid replica configuration: path to peers is required"))
+	}
+
+	if c.DataDir == "" {
+		err = errors.Join(err, errors.New("invalid replica configuration: data directory is required"))
+	}
+
+	if c.Tick <= 0 {
+		err = errors.Join(err, errors.New("invalid replica configuration: tick must be greater than zero"))
+	}
+
+	if c.Timeout <= 0 {
+		err = errors.Join(err, errors.New("invalid replica configuration: timeout must be greater than zero"))
+	}
+
+	if c.MaxInflight < 0 {
+		err = errors.Join(err, errors.New("invalid replica configuration: max inflight cannot be negative"))
+	}
+
+	if c.MaxInflightBytes < 0 {
+		err = errors.Join(err, errors.New("invalid replica configuration: max inflight bytes cannot be negative"))
+	}
+
+	if c.Snapshot.Threshold > 0 && c.Snapshot.Retain < 1 {
+		err = errors.Join(err, errors.New("invalid replica configuration: at least one snapshot must be retained"))
+	}
+
+	if terr := c.TLS.Validate(); terr != nil {
+		err = errors.Join(err, fmt.Errorf("invalid replica configuration: %w", terr))
+	}
+
+	return err
+}
+
+// Enabled returns true if TLS is configured.
+func (c TLSConfig) Enabled() bool {
+	return c.CertPath != "" || c.KeyPath != "" || c.CAPath != ""
+}
+
+func (c TLSConfig) Validate() (err error) {
+	if !c.Enabled() {
+		return nil
+	}
+
+	if c.CertPath == "" {
+		err = errors.Join(err, errors.New("tls certificate path is required"))
+	}
+
+	if c.KeyPath == "" {
+		err = errors.Join(err, errors.New("tls key path is required"))
+	}
+
+	if c.CAPath == "" {
+		err = errors.Join(err, errors.New("tls certificate authority path is required"))
+	}
+
+	return err
 }
 
 func (c WebConfig) Validate() (err error) {
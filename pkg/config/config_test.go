@@ -3,6 +3,7 @@ package config_test
 import (
 	"os"
 	"testing"
+	"time"
 
 	"github.com/bbengfort/otterdb/pkg/config"
 
@@ -11,18 +12,42 @@ import (
 )
 
 var testEnv = map[string]string{
-	"OTTER_MAINTENANCE":       "true",
-	"OTTER_LOG_LEVEL":         "debug",
-	"OTTER_CONSOLE_LOG":       "true",
-	"OTTER_SERVER_ENABLED":    "false",
-	"OTTER_SERVER_BIND_ADDR":  ":3303",
-	"OTTER_REPLICA_ENABLED":   "true",
-	"OTTER_REPLICA_BIND_ADDR": ":3304",
-	"OTTER_REPLICA_AGGREGATE": "false",
-	"OTTER_WEB_ENABLED":       "true",
-	"OTTER_WEB_MODE":          "test",
-	"OTTER_WEB_BIND_ADDR":     ":3305",
-	"OTTER_WEB_ORIGIN":        "https://example.com",
+	"OTTER_MAINTENANCE":                "true",
+	"OTTER_LOG_LEVEL":                  "debug",
+	"OTTER_CONSOLE_LOG":                "true",
+	"OTTER_DATA_DIR":                   "/data",
+	"OTTER_SERVER_ENABLED":             "false",
+	"OTTER_SERVER_BIND_ADDR":           ":3303",
+	"OTTER_SERVER_TLS_CERT_PATH":       "/etc/otterdb/server.crt",
+	"OTTER_SERVER_TLS_KEY_PATH":        "/etc/otterdb/server.key",
+	"OTTER_SERVER_AUTH_KEYS_PATH":      "/etc/otterdb/keys.json",
+	"OTTER_SERVER_AUTH_JWKS_PATH":      "/etc/otterdb/jwks.json",
+	"OTTER_SERVER_AUTH_ISSUER":         "https://auth.example.com",
+	"OTTER_SERVER_AUTH_AUDIENCE":       "otterdb",
+	"OTTER_SERVER_AUTH_ROLES_PATH":     "/etc/otterdb/roles.json",
+	"OTTER_REPLICA_ENABLED":            "true",
+	"OTTER_REPLICA_BIND_ADDR":          ":3304",
+	"OTTER_REPLICA_AGGREGATE":          "false",
+	"OTTER_REPLICA_NAME":               "jade",
+	"OTTER_REPLICA_PEERS":              "/etc/otterdb/peers.json",
+	"OTTER_REPLICA_TICK":               "100ms",
+	"OTTER_REPLICA_TIMEOUT":            "350ms",
+	"OTTER_REPLICA_PRE_VOTE":           "false",
+	"OTTER_REPLICA_CHECK_QUORUM":       "false",
+	"OTTER_REPLICA_PRIORITY":           "false",
+	"OTTER_REPLICA_MAX_INFLIGHT":       "4",
+	"OTTER_REPLICA_MAX_INFLIGHT_BYTES": "1048576",
+	"OTTER_REPLICA_STREAM":             "false",
+	"OTTER_REPLICA_TLS_CERT_PATH":      "/etc/otterdb/jade.crt",
+	"OTTER_REPLICA_TLS_KEY_PATH":       "/etc/otterdb/jade.key",
+	"OTTER_REPLICA_TLS_CA_PATH":        "/etc/otterdb/ca.crt",
+	"OTTER_REPLICA_SNAPSHOT_THRESHOLD": "4096",
+	"OTTER_REPLICA_SNAPSHOT_RETAIN":    "2",
+	"OTTER_REPLICA_SNAPSHOT_TRAILING":  "128",
+	"OTTER_WEB_ENABLED":                "true",
+	"OTTER_WEB_MODE":                   "test",
+	"OTTER_WEB_BIND_ADDR":              ":3305",
+	"OTTER_WEB_ORIGIN":                 "https://example.com",
 }
 
 func TestConfig(t *testing.T) {
@@ -41,17 +66,84 @@ func TestConfig(t *testing.T) {
 	require.True(t, conf.Web.Maintenance)
 	require.Equal(t, zerolog.DebugLevel, conf.GetLogLevel())
 	require.True(t, conf.ConsoleLog)
+	require.Equal(t, testEnv["OTTER_DATA_DIR"], conf.DataDir)
+	require.Equal(t, testEnv["OTTER_DATA_DIR"], conf.Replica.DataDir)
 	require.False(t, conf.Server.Enabled)
 	require.Equal(t, testEnv["OTTER_SERVER_BIND_ADDR"], conf.Server.BindAddr)
+	require.True(t, conf.Server.TLS.Enabled())
+	require.Equal(t, testEnv["OTTER_SERVER_TLS_CERT_PATH"], conf.Server.TLS.CertPath)
+	require.Equal(t, testEnv["OTTER_SERVER_TLS_KEY_PATH"], conf.Server.TLS.KeyPath)
+	require.True(t, conf.Server.Auth.Enabled())
+	require.Equal(t, testEnv["OTTER_SERVER_AUTH_KEYS_PATH"], conf.Server.Auth.KeysPath)
+	require.Equal(t, testEnv["OTTER_SERVER_AUTH_JWKS_PATH"], conf.Server.Auth.JWKSPath)
+	require.Equal(t, testEnv["OTTER_SERVER_AUTH_ISSUER"], conf.Server.Auth.Issuer)
+	require.Equal(t, testEnv["OTTER_SERVER_AUTH_AUDIENCE"], conf.Server.Auth.Audience)
+	require.Equal(t, testEnv["OTTER_SERVER_AUTH_ROLES_PATH"], conf.Server.Auth.RolesPath)
 	require.True(t, conf.Replica.Enabled)
 	require.Equal(t, testEnv["OTTER_REPLICA_BIND_ADDR"], conf.Replica.BindAddr)
 	require.False(t, conf.Replica.Aggregate)
+	require.Equal(t, testEnv["OTTER_REPLICA_NAME"], conf.Replica.Name)
+	require.Equal(t, testEnv["OTTER_REPLICA_PEERS"], conf.Replica.Peers)
+	require.Equal(t, 100*time.Millisecond, conf.Replica.Tick)
+	require.Equal(t, 350*time.Millisecond, conf.Replica.Timeout)
+	require.False(t, conf.Replica.PreVote)
+	require.False(t, conf.Replica.CheckQuorum)
+	require.False(t, conf.Replica.Priority)
+	require.Equal(t, 4, conf.Replica.MaxInflight)
+	require.Equal(t, 1048576, conf.Replica.MaxInflightBytes)
+	require.False(t, conf.Replica.Stream)
+	require.True(t, conf.Replica.TLS.Enabled())
+	require.Equal(t, testEnv["OTTER_REPLICA_TLS_CERT_PATH"], conf.Replica.TLS.CertPath)
+	require.Equal(t, testEnv["OTTER_REPLICA_TLS_KEY_PATH"], conf.Replica.TLS.KeyPath)
+	require.Equal(t, testEnv["OTTER_REPLICA_TLS_CA_PATH"], conf.Replica.TLS.CAPath)
+	require.Equal(t, uint64(4096), conf.Replica.Snapshot.Threshold)
+	require.Equal(t, 2, conf.Replica.Snapshot.Retain)
+	require.Equal(t, uint64(128), conf.Replica.Snapshot.Trailing)
 	require.True(t, conf.Web.Enabled)
 	require.Equal(t, testEnv["OTTER_WEB_MODE"], conf.Web.Mode)
 	require.Equal(t, testEnv["OTTER_WEB_BIND_ADDR"], conf.Web.BindAddr)
 	require.Equal(t, testEnv["OTTER_WEB_ORIGIN"], conf.Web.Origin)
 }
 
+func TestServerConfigValidation(t *testing.T) {
+	conf := config.ServerConfig{Enabled: true, BindAddr: ":2202"}
+	require.NoError(t, conf.Validate())
+
+	conf = config.ServerConfig{Enabled: true, TLS: config.ServerTLSConfig{KeyPath: "server.key"}, Auth: config.AuthConfig{Audience: "otterdb", RolesPath: "roles.json"}}
+	err := conf.Validate()
+	require.ErrorContains(t, err, "tls certificate path is required")
+	require.ErrorContains(t, err, "jwks path is required")
+	require.ErrorContains(t, err, "keys or jwks path is required")
+
+	conf = config.ServerConfig{Enabled: true, TLS: config.ServerTLSConfig{CertPath: "server.crt", KeyPath: "server.key"}, Auth: config.AuthConfig{JWKSPath: "jwks.json", Audience: "otterdb"}}
+	require.NoError(t, conf.Validate())
+}
+
+func TestReplicaConfigValidation(t *testing.T) {
+	// If the replica is disabled then no validation is required
+	conf := config.ReplicaConfig{Enabled: false}
+	require.NoError(t, conf.Validate())
+
+	conf = config.ReplicaConfig{Enabled: true, MaxInflight: -1, MaxInflightBytes: -1, Snapshot: config.SnapshotConfig{Threshold: 10}}
+	err := conf.Validate()
+	require.ErrorContains(t, err, "name is required")
+	require.ErrorContains(t, err, "path to peers is required")
+	require.ErrorContains(t, err, "data directory is required")
+	require.ErrorContains(t, err, "tick must be greater than zero")
+	require.ErrorContains(t, err, "timeout must be greater than zero")
+	require.ErrorContains(t, err, "max inflight cannot be negative")
+	require.ErrorContains(t, err, "max inflight bytes cannot be negative")
+	require.ErrorContains(t, err, "at least one snapshot must be retained")
+
+	conf = config.ReplicaConfig{Enabled: true, Name: "jade", Peers: "peers.json", DataDir: "data", Tick: time.Second, Timeout: time.Second, TLS: config.TLSConfig{CertPath: "jade.crt"}}
+	err = conf.Validate()
+	require.ErrorContains(t, err, "tls key path is required")
+	require.ErrorContains(t, err, "tls certificate authority path is required")
+
+	conf = config.ReplicaConfig{Enabled: true, Name: "jade", Peers: "peers.json", DataDir: "data", Tick: time.Second, Timeout: time.Second}
+	require.NoError(t, conf.Validate())
+}
+
 // Returns the current environment for the specified keys, or if no keys are specified
 // then it returns the current environment for all keys in the testEnv variable.
 func curEnv(keys ...string) map[string]string {
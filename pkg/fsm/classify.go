@@ -0,0 +1,280 @@
+package fsm
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+// Operation is the kind of access to the database that a SQL statement requires.
+type Operation uint8
+
+// Operations are ordered so that the operation of a statement is the greatest operation
+// of the actions that it performs, e.g. an INSERT that selects from a table is a write.
+const (
+	OpUnknown Operation = iota
+	OpRead              // Statements that only read from the database, e.g. SELECT
+	OpWrite             // Statements that modify rows, e.g. INSERT, UPDATE, DELETE
+	OpDDL               // Statements that modify the schema or the storage of the database
+	OpPragma            // PRAGMA statements that query or modify the database settings
+)
+
+var operationNames = map[Operation]string{
+	OpUnknown: "unknown",
+	OpRead:    "read",
+	OpWrite:   "write",
+	OpDDL:     "ddl",
+	OpPragma:  "pragma",
+}
+
+func (o Operation) String() string {
+	if name, ok := operationNames[o]; ok {
+		return name
+	}
+	return operationNames[OpUnknown]
+}
+
+// ParseOperation returns the operation with the specified name.
+func ParseOperation(name string) (Operation, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	for op, opName := range operationNames {
+		if op != OpUnknown && opName == name {
+			return op, nil
+		}
+	}
+	return OpUnknown, fmt.Errorf("%w: %q", ErrUnknownOperation, name)
+}
+
+// The operations of the actions reported by the SQLite authorizer; actions that are not
+// listed, e.g. FUNCTION and RECURSIVE, do not require any access to the database.
+var actions = map[int]Operation{
+	sqlite3.SQLITE_SELECT:              OpRead,
+	sqlite3.SQLITE_READ:                OpRead,
+	sqlite3.SQLITE_INSERT:              OpWrite,
+	sqlite3.SQLITE_UPDATE:              OpWrite,
+	sqlite3.SQLITE_DELETE:              OpWrite,
+	sqlite3.SQLITE_CREATE_TABLE:        OpDDL,
+	sqlite3.SQLITE_CREATE_TEMP_TABLE:   OpDDL,
+	sqlite3.SQLITE_CREATE_VIEW:         OpDDL,
+	sqlite3.SQLITE_CREATE_TEMP_VIEW:    OpDDL,
+	sqlite3.SQLITE_CREATE_VTABLE:       OpDDL,
+	sqlite3.SQLITE_DROP_TABLE:          OpDDL,
+	sqlite3.SQLITE_DROP_TEMP_TABLE:     OpDDL,
+	sqlite3.SQLITE_DROP_VIEW:           OpDDL,
+	sqlite3.SQLITE_DROP_TEMP_VIEW:      OpDDL,
+	sqlite3.SQLITE_DROP_VTABLE:         OpDDL,
+	sqlite3.SQLITE_CREATE_INDEX:        OpDDL,
+	sqlite3.SQLITE_CREATE_TEMP_INDEX:   OpDDL,
+	sqlite3.SQLITE_CREATE_TRIGGER:      OpDDL,
+	sqlite3.SQLITE_CREATE_TEMP_TRIGGER: OpDDL,
+	sqlite3.SQLITE_DROP_INDEX:          OpDDL,
+	sqlite3.SQLITE_DROP_TEMP_INDEX:     OpDDL,
+	sqlite3.SQLITE_DROP_TRIGGER:        OpDDL,
+	sqlite3.SQLITE_DROP_TEMP_TRIGGER:   OpDDL,
+	sqlite3.SQLITE_ALTER_TABLE:         OpDDL,
+	sqlite3.SQLITE_ANALYZE:             OpDDL,
+	sqlite3.SQLITE_REINDEX:             OpDDL,
+	sqlite3.SQLITE_ATTACH:              OpDDL,
+	sqlite3.SQLITE_DETACH:              OpDDL,
+	sqlite3.SQLITE_PRAGMA:              OpPragma,
+}
+
+// Actions whose table is the second argument of the authorizer, e.g. the table of an
+// index or trigger; the first argument of these actions is the name of the index,
+// trigger, or database.
+var secondArg = map[int]struct{}{
+	sqlite3.SQLITE_CREATE_INDEX:        {},
+	sqlite3.SQLITE_CREATE_TEMP_INDEX:   {},
+	sqlite3.SQLITE_CREATE_TRIGGER:      {},
+	sqlite3.SQLITE_CREATE_TEMP_TRIGGER: {},
+	sqlite3.SQLITE_DROP_INDEX:          {},
+	sqlite3.SQLITE_DROP_TEMP_INDEX:     {},
+	sqlite3.SQLITE_DROP_TRIGGER:        {},
+	sqlite3.SQLITE_DROP_TEMP_TRIGGER:   {},
+	sqlite3.SQLITE_ALTER_TABLE:         {},
+}
+
+// Actions that affect objects other than a single table, e.g. every table of the
+// database or the database settings.
+var unscoped = map[int]struct{}{
+	sqlite3.SQLITE_ANALYZE: {},
+	sqlite3.SQLITE_REINDEX: {},
+	sqlite3.SQLITE_ATTACH:  {},
+	sqlite3.SQLITE_DETACH:  {},
+	sqlite3.SQLITE_PRAGMA:  {},
+}
+
+// The schema tables are modified by every DDL statement and read while it is prepared.
+var schemaTables = map[string]struct{}{"sqlite_master": {}, "sqlite_temp_master": {}}
+
+// Classification describes the access that a single SQL statement requires.
+type Classification struct {
+	Operation Operation
+	Targets   []string // The tables and views that the statement modifies or defines
+	Reads     []string // The tables and views that the statement reads from
+	Unscoped  bool     // True if the objects the statement affects cannot be determined
+}
+
+// Classify the statement by the operation it requires and the tables it accesses so
+// that clients can be authorized before the statement is replicated. The statement is
+// prepared but not executed by a query connection and the actions that SQLite reports
+// to its authorizer are collected, so the tables accessed by subqueries, views, and the
+// triggers the statement fires are included. Table names are lower case and include the
+// schema unless it is the main or temp schema. Table-valued PRAGMA functions and
+// statements that affect objects other than their tables, e.g. PRAGMA, REINDEX, or
+// VACUUM, are unscoped. Since statements are prepared against the local schema, the
+// tables that a statement accesses must exist on the local replica.
+func (f *FSM) Classify(ctx context.Context, query string) (_ *Classification, err error) {
+	if _, err = statement(query); err != nil {
+		return nil, err
+	}
+
+	c := &classifier{class: &Classification{}}
+	if err = f.prepare(ctx, query, c.authorize); err != nil {
+		if c.err != nil {
+			return nil, c.err
+		}
+		return nil, fmt.Errorf("%w: %s", ErrInvalidStatement, err)
+	}
+	return c.classification(), nil
+}
+
+// Prepare the query on a connection from the query pool with the authorizer; the
+// authorizer of the pool is restored once the statement is prepared.
+func (f *FSM) prepare(ctx context.Context, query string, authorizer func(int, string, string, string) int) (err error) {
+	conn, err := f.reader.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	return conn.Raw(func(driverConn interface{}) (err error) {
+		sc, ok := driverConn.(*sqlite3.SQLiteConn)
+		if !ok {
+			return fmt.Errorf("unexpected driver connection %T", driverConn)
+		}
+
+		sc.RegisterAuthorizer(authorizer)
+		defer sc.RegisterAuthorizer(authorizeQuery)
+
+		stmt, err := sc.Prepare(query)
+		if err != nil {
+			return err
+		}
+		return stmt.Close()
+	})
+}
+
+// Collects the actions of a statement as it is prepared.
+type classifier struct {
+	class   *Classification
+	actions int
+	indexes []string // The indexes created by the statement, which are then built
+	err     error
+}
+
+func (c *classifier) authorize(action int, arg1, arg2, db string) int {
+	c.actions++
+	switch action {
+	case sqlite3.SQLITE_TRANSACTION, sqlite3.SQLITE_SAVEPOINT:
+		c.err = ErrTransactionControl
+		return sqlite3.SQLITE_DENY
+	}
+
+	op, ok := actions[action]
+	if !ok {
+		return sqlite3.SQLITE_OK
+	}
+
+	switch action {
+	case sqlite3.SQLITE_CREATE_INDEX, sqlite3.SQLITE_CREATE_TEMP_INDEX:
+		c.indexes = append(c.indexes, strings.ToLower(arg1))
+	case sqlite3.SQLITE_REINDEX:
+		if slices.Contains(c.indexes, strings.ToLower(arg1)) {
+			return sqlite3.SQLITE_OK
+		}
+	}
+
+	table := arg1
+	if _, ok := secondArg[action]; ok {
+		table = arg2
+	}
+
+	// PRAGMA functions are table-valued functions whose arguments name other tables.
+	if action == sqlite3.SQLITE_READ && strings.HasPrefix(strings.ToLower(table), "pragma_") {
+		op, action = OpPragma, sqlite3.SQLITE_PRAGMA
+	}
+
+	if op > c.class.Operation {
+		c.class.Operation = op
+	}
+
+	// SELECT does not access a table; its tables are read by the actions that follow.
+	if action == sqlite3.SQLITE_SELECT {
+		return sqlite3.SQLITE_OK
+	}
+
+	if _, ok := unscoped[action]; ok || table == "" {
+		c.class.Unscoped = true
+		return sqlite3.SQLITE_OK
+	}
+
+	table = strings.ToLower(table)
+	if db = strings.ToLower(db); db != "" && db != "main" && db != "temp" {
+		table = db + "." + table
+	}
+
+	if op == OpRead {
+		c.class.Reads = appendUnique(c.class.Reads, table)
+	} else {
+		c.class.Targets = appendUnique(c.class.Targets, table)
+	}
+	return sqlite3.SQLITE_OK
+}
+
+// Returns the classification of the prepared statement; the tables are sorted by name
+// since the order that SQLite reports them in depends on the query plan. Statements that do not report
+// any actions, e.g. VACUUM, are unscoped DDL. The schema tables are excluded from DDL
+// statements, which always modify them, and are never targets since they can only be
+// modified by DDL statements.
+func (c *classifier) classification() *Classification {
+	if c.actions == 0 {
+		return &Classification{Operation: OpDDL, Unscoped: true}
+	}
+
+	c.class.Targets = excludeSchema(c.class.Targets)
+	if c.class.Operation == OpDDL {
+		c.class.Reads = excludeSchema(c.class.Reads)
+	}
+
+	sort.Strings(c.class.Targets)
+	sort.Strings(c.class.Reads)
+	return c.class
+}
+
+func excludeSchema(names []string) []string {
+	out := names[:0]
+	for _, name := range names {
+		if _, ok := schemaTables[name]; !ok {
+			out = append(out, name)
+		}
+	}
+
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
+
+func appendUnique(names []string, name string) []string {
+	for _, existing := range names {
+		if existing == name {
+			return names
+		}
+	}
+	return append(names, name)
+}
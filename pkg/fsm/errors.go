@@ -0,0 +1,17 @@
+package fsm
+
+import "errors"
+
+var (
+	ErrOutOfOrder         = errors.New("entries must be applied to the state machine in index order")
+	ErrUnknownCommand     = errors.New("unknown state machine command")
+	ErrInvalidStatement   = errors.New("could not parse statement")
+	ErrEmptyStatement     = errors.New("statement does not contain any sql")
+	ErrEmptyTransaction   = errors.New("transaction does not contain any statements")
+	ErrTransactionControl = errors.New("transaction control statements cannot be executed directly")
+	ErrMultipleStatements = errors.New("sql must contain exactly one statement")
+	ErrNondeterministic   = errors.New("statement is not deterministic and cannot be replicated")
+	ErrMissingValue       = errors.New("parameter does not have a value")
+	ErrUnknownOperation   = errors.New("unknown sql operation")
+	ErrUnhandledType      = errors.New("cannot convert database value")
+)
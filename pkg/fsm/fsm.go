@@ -0,0 +1,320 @@
+/*
+Package fsm implements the replicated state machine of otterdb: a local SQLite database
+that committed log entries are applied to in index order. The index of the last applied
+entry is stored in the database in the same transaction as the entry so that entries are
+applied exactly once, even if the replica crashes and the log is replayed on restart.
+*/
+package fsm
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/bbengfort/otterdb/pkg/replica/raft/v1"
+	"github.com/bbengfort/otterdb/pkg/server/api/v1"
+
+	"github.com/mattn/go-sqlite3"
+	"google.golang.org/protobuf/proto"
+)
+
+// Names of the log entries that are applied by the state machine.
+const (
+	NoOp        = "noop"        // Appended by leaders when elected, no statement is executed
+	Exec        = "exec"        // A SQL statement that modifies the database
+	Transaction = "transaction" // An ordered list of SQL statements applied atomically
+	Config      = "config"      // Changes the members of the quorum, no statement is executed
+)
+
+const (
+	driverName   = "sqlite3"
+	dsnParams    = "_journal_mode=WAL&_synchronous=FULL&_foreign_keys=on&_busy_timeout=5000&_txlock=immediate"
+	readerParams = "mode=ro&_busy_timeout=5000"
+)
+
+// Stores the index of the last applied entry; the table is created with the database.
+const createMeta = `CREATE TABLE IF NOT EXISTS _otterdb_meta (
+	id INTEGER PRIMARY KEY CHECK (id = 1),
+	last_applied INTEGER NOT NULL
+);
+INSERT OR IGNORE INTO _otterdb_meta (id, last_applied) VALUES (1, 0);`
+
+// FSM applies committed log entries to a local SQLite database. Apply must be called
+// with entries in index order; entries that have already been applied are ignored.
+type FSM struct {
+	sync.RWMutex
+	db          *sql.DB // single read-write connection used to apply entries
+	reader      *sql.DB // read-only connection pool used to serve local queries
+	lastApplied uint64
+	guarded     bool // true while a client statement is applied, guarded by the lock
+}
+
+// Result is returned for every applied entry. If the statement could not be executed,
+// e.g. because of a syntax error or a constraint violation, Err is set and none of the
+// changes of the statement are applied; the entry is still considered applied. The
+// results of the statements of a transaction are returned in Statements.
+type Result struct {
+	Index        uint64
+	LastInsertID int64
+	RowsAffected int64
+	Statements   []*Result
+	Err          error
+}
+
+// The serialized form of a result that is sent from the leader to a follower that
+// forwarded the entry; errors are sent as their message.
+type encodedResult struct {
+	Index        uint64           `json:"index"`
+	LastInsertID int64            `json:"last_insert_id,omitempty"`
+	RowsAffected int64            `json:"rows_affected,omitempty"`
+	Statements   []*encodedResult `json:"statements,omitempty"`
+	Err          string           `json:"error,omitempty"`
+}
+
+// Open the SQLite database at the specified path, creating it if it does not exist, and
+// load the index of the last applied entry.
+func Open(path string) (f *FSM, err error) {
+	f = &FSM{}
+	f.db = sql.OpenDB(&connector{
+		dsn: fmt.Sprintf("file:%s?%s", path, dsnParams),
+		driver: &sqlite3.SQLiteDriver{
+			ConnectHook: func(conn *sqlite3.SQLiteConn) error {
+				conn.RegisterAuthorizer(f.authorize)
+				return nil
+			},
+		},
+	})
+
+	// Entries must be applied serially so only a single connection is used for writes.
+	f.db.SetMaxOpenConns(1)
+
+	if _, err = f.db.Exec(createMeta); err != nil {
+		f.db.Close()
+		return nil, fmt.Errorf("could not initialize database: %w", err)
+	}
+
+	if err = f.db.QueryRow("SELECT last_applied FROM _otterdb_meta WHERE id=1").Scan(&f.lastApplied); err != nil {
+		f.db.Close()
+		return nil, fmt.Errorf("could not read last applied index: %w", err)
+	}
+
+	// Queries are read-only so that they cannot modify the database without consensus;
+	// the authorizer also prevents queries from attaching other databases to the pool.
+	// Statements are classified by the query connections, which never execute writes, so
+	// count_changes is enabled to disable the transfer optimization of INSERT INTO ...
+	// SELECT * FROM statements, which reads the source table without authorizing it.
+	f.reader = sql.OpenDB(&connector{
+		dsn: fmt.Sprintf("file:%s?%s", path, readerParams),
+		driver: &sqlite3.SQLiteDriver{
+			ConnectHook: func(conn *sqlite3.SQLiteConn) (err error) {
+				if _, err = conn.Exec("PRAGMA count_changes = ON", nil); err != nil {
+					return err
+				}
+				conn.RegisterAuthorizer(authorizeQuery)
+				return nil
+			},
+		},
+	})
+	return f, nil
+}
+
+// LastApplied returns the index of the last entry applied to the database.
+func (f *FSM) LastApplied() uint64 {
+	f.RLock()
+	defer f.RUnlock()
+	return f.lastApplied
+}
+
+// Close the database.
+func (f *FSM) Close() (err error) {
+	if rerr := f.reader.Close(); rerr != nil {
+		err = errors.Join(err, rerr)
+	}
+
+	if werr := f.db.Close(); werr != nil {
+		err = errors.Join(err, werr)
+	}
+	return err
+}
+
+// Apply the committed entry to the database and return a *Result. The statement in the
+// entry and the new last applied index are written in a single transaction. An error is
+// only returned if the database itself fails, in which case the state machine cannot
+// make progress; errors executing the statement are returned in the result.
+func (f *FSM) Apply(entry *raft.LogEntry) (_ interface{}, err error) {
+	f.Lock()
+	defer f.Unlock()
+
+	// Entries that were applied before a restart are ignored.
+	if entry.Index <= f.lastApplied {
+		return &Result{Index: entry.Index}, nil
+	}
+
+	if entry.Index != f.lastApplied+1 {
+		return nil, fmt.Errorf("cannot apply entry %d after %d: %w", entry.Index, f.lastApplied, ErrOutOfOrder)
+	}
+
+	var tx *sql.Tx
+	if tx, err = f.db.Begin(); err != nil {
+		return nil, fmt.Errorf("could not begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result := &Result{Index: entry.Index}
+	switch entry.Name {
+	case NoOp, Config:
+	case Exec:
+		result.Err = f.exec(tx, entry.Value, result)
+	case Transaction:
+		result.Err = f.transaction(tx, entry.Value, result)
+	default:
+		result.Err = fmt.Errorf("%w: %q", ErrUnknownCommand, entry.Name)
+	}
+
+	if _, err = tx.Exec("UPDATE _otterdb_meta SET last_applied=? WHERE id=1", entry.Index); err != nil {
+		return nil, fmt.Errorf("could not update last applied index: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("could not commit transaction: %w", err)
+	}
+
+	f.lastApplied = entry.Index
+	return result, nil
+}
+
+// EncodeResult serializes a *Result so that it can be returned to a follower that
+// forwarded the entry to the leader.
+func (f *FSM) EncodeResult(result interface{}) (_ []byte, err error) {
+	res, ok := result.(*Result)
+	if !ok {
+		return nil, fmt.Errorf("cannot encode result of type %T", result)
+	}
+	return json.Marshal(encode(res))
+}
+
+// DecodeResult deserializes a *Result that was encoded by the leader.
+func (f *FSM) DecodeResult(data []byte) (_ interface{}, err error) {
+	enc := &encodedResult{}
+	if err = json.Unmarshal(data, enc); err != nil {
+		return nil, err
+	}
+	return decode(enc), nil
+}
+
+func encode(res *Result) *encodedResult {
+	enc := &encodedResult{Index: res.Index, LastInsertID: res.LastInsertID, RowsAffected: res.RowsAffected}
+	if res.Err != nil {
+		enc.Err = res.Err.Error()
+	}
+
+	for _, stmt := range res.Statements {
+		enc.Statements = append(enc.Statements, encode(stmt))
+	}
+	return enc
+}
+
+func decode(enc *encodedResult) *Result {
+	res := &Result{Index: enc.Index, LastInsertID: enc.LastInsertID, RowsAffected: enc.RowsAffected}
+	if enc.Err != "" {
+		res.Err = errors.New(enc.Err)
+	}
+
+	for _, stmt := range enc.Statements {
+		res.Statements = append(res.Statements, decode(stmt))
+	}
+	return res
+}
+
+// Execute the statement in a savepoint so that if the statement fails its changes are
+// rolled back without aborting the transaction that records the applied index.
+func (f *FSM) exec(tx *sql.Tx, value []byte, result *Result) (err error) {
+	stmt := &api.Statement{}
+	if err = proto.Unmarshal(value, stmt); err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalidStatement, err)
+	}
+
+	return savepoint(tx, func() error {
+		return f.execStatement(tx, stmt, result)
+	})
+}
+
+// Execute all of the statements of the transaction in a single savepoint so that if any
+// statement fails, the changes of all of the statements are rolled back. The results of
+// the statements are only returned if every statement succeeds.
+func (f *FSM) transaction(tx *sql.Tx, value []byte, result *Result) (err error) {
+	txn := &api.TransactionRequest{}
+	if err = proto.Unmarshal(value, txn); err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalidStatement, err)
+	}
+
+	if len(txn.Statements) == 0 {
+		return ErrEmptyTransaction
+	}
+
+	results := make([]*Result, 0, len(txn.Statements))
+	err = savepoint(tx, func() error {
+		for i, stmt := range txn.Statements {
+			res := &Result{Index: result.Index}
+			if err := f.execStatement(tx, stmt, res); err != nil {
+				return fmt.Errorf("statement %d: %w", i, err)
+			}
+			results = append(results, res)
+		}
+		return nil
+	})
+
+	if err != nil {
+		return err
+	}
+
+	result.Statements = results
+	return nil
+}
+
+// Run the function in a savepoint, rolling back to the savepoint if it returns an error.
+func savepoint(tx *sql.Tx, fn func() error) (err error) {
+	if _, err = tx.Exec("SAVEPOINT apply"); err != nil {
+		return err
+	}
+
+	if err = fn(); err != nil {
+		if _, rerr := tx.Exec("ROLLBACK TO apply"); rerr != nil {
+			return errors.Join(err, rerr)
+		}
+		return err
+	}
+
+	_, err = tx.Exec("RELEASE apply")
+	return err
+}
+
+// Validate and execute a single statement, recording its effects in the result. Only
+// the first statement of the SQL is prepared so that statements that were not validated
+// before they were replicated cannot execute trailing SQL.
+func (f *FSM) execStatement(tx *sql.Tx, stmt *api.Statement, result *Result) (err error) {
+	var args []interface{}
+	if args, err = validate(stmt); err != nil {
+		return err
+	}
+
+	f.guarded = true
+	defer func() { f.guarded = false }()
+
+	var prepared *sql.Stmt
+	if prepared, err = tx.Prepare(stmt.Sql); err != nil {
+		return err
+	}
+	defer prepared.Close()
+
+	var res sql.Result
+	if res, err = prepared.Exec(args...); err != nil {
+		return err
+	}
+
+	result.LastInsertID, _ = res.LastInsertId()
+	result.RowsAffected, _ = res.RowsAffected()
+	return nil
+}
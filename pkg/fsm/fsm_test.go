@@ -0,0 +1,468 @@
+package fsm_test
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/bbengfort/otterdb/pkg/fsm"
+	"github.com/bbengfort/otterdb/pkg/replica/raft/v1"
+	"github.com/bbengfort/otterdb/pkg/server/api/v1"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestApply(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "otter.db")
+	db, err := fsm.Open(path)
+	require.NoError(t, err, "could not open database")
+	require.Equal(t, uint64(0), db.LastApplied())
+
+	entries := []*raft.LogEntry{
+		{Index: 1, Term: 1, Name: fsm.NoOp},
+		exec(t, 2, "CREATE TABLE otters (id INTEGER PRIMARY KEY, name TEXT NOT NULL UNIQUE, age INTEGER, weight REAL, photo BLOB)"),
+		exec(t, 3, "INSERT INTO otters (name, age, weight, photo) VALUES (?, ?, ?, ?)", text("jade"), integer(4), double(9.8), blob([]byte{0xca, 0xfe})),
+		exec(t, 4, "INSERT INTO otters (name, age) VALUES (:name, @age)", named("name", text("kira")), named(":age", null())),
+		exec(t, 5, "INSERT INTO otters (name) VALUES (?)", text("jade")),
+		exec(t, 6, "UPDATE otters SET age=age+1 WHERE age IS NOT NULL"),
+		{Index: 7, Term: 1, Name: "unknown"},
+		exec(t, 8, "BEGIN TRANSACTION"),
+		exec(t, 9, "INSERT INTO otters (name) VALUES (?)"),
+	}
+
+	results := make([]*fsm.Result, 0, len(entries))
+	for _, entry := range entries {
+		out, err := db.Apply(entry)
+		require.NoError(t, err, "could not apply entry %d", entry.Index)
+		result, ok := out.(*fsm.Result)
+		require.True(t, ok, "expected a result from the state machine")
+		require.Equal(t, entry.Index, result.Index)
+		results = append(results, result)
+	}
+
+	require.NoError(t, results[0].Err)
+	require.NoError(t, results[1].Err)
+	require.NoError(t, results[2].Err)
+	require.Equal(t, int64(1), results[2].LastInsertID)
+	require.Equal(t, int64(1), results[2].RowsAffected)
+	require.NoError(t, results[3].Err)
+	require.Equal(t, int64(2), results[3].LastInsertID)
+	require.ErrorContains(t, results[4].Err, "UNIQUE constraint failed")
+	require.NoError(t, results[5].Err)
+	require.Equal(t, int64(1), results[5].RowsAffected)
+	require.ErrorIs(t, results[6].Err, fsm.ErrUnknownCommand)
+	require.ErrorIs(t, results[7].Err, fsm.ErrTransactionControl)
+	require.Error(t, results[8].Err)
+
+	// Entries must be applied in order
+	_, err = db.Apply(exec(t, 11, "DELETE FROM otters"))
+	require.ErrorIs(t, err, fsm.ErrOutOfOrder)
+	require.Equal(t, uint64(9), db.LastApplied())
+	require.NoError(t, db.Close())
+
+	// Reopening the database should not reapply entries
+	db, err = fsm.Open(path)
+	require.NoError(t, err, "could not reopen database")
+	defer db.Close()
+	require.Equal(t, uint64(9), db.LastApplied())
+
+	for _, entry := range entries {
+		_, err := db.Apply(entry)
+		require.NoError(t, err, "could not apply entry %d", entry.Index)
+	}
+
+	out, err := db.Apply(exec(t, 10, "DELETE FROM otters WHERE age > 4"))
+	require.NoError(t, err)
+	require.NoError(t, out.(*fsm.Result).Err)
+	require.Equal(t, int64(1), out.(*fsm.Result).RowsAffected, "expected only one row to have been updated")
+	require.Equal(t, uint64(10), db.LastApplied())
+}
+
+func TestTransaction(t *testing.T) {
+	db, err := fsm.Open(filepath.Join(t.TempDir(), "otter.db"))
+	require.NoError(t, err, "could not open database")
+	defer db.Close()
+
+	entries := []*raft.LogEntry{
+		exec(t, 1, "CREATE TABLE accounts (name TEXT PRIMARY KEY, balance INTEGER NOT NULL CHECK (balance >= 0))"),
+		transaction(t, 2,
+			&api.Statement{Sql: "INSERT INTO accounts VALUES ('jade', 100)"},
+			&api.Statement{Sql: "INSERT INTO accounts VALUES ('kira', 0)"},
+		),
+		transaction(t, 3,
+			&api.Statement{Sql: "UPDATE accounts SET balance=balance-? WHERE name=?", Params: []*api.Parameter{integer(40), text("jade")}},
+			&api.Statement{Sql: "UPDATE accounts SET balance=balance+? WHERE name=?", Params: []*api.Parameter{integer(40), text("kira")}},
+		),
+		transaction(t, 4,
+			&api.Statement{Sql: "UPDATE accounts SET balance=balance+? WHERE name=?", Params: []*api.Parameter{integer(80), text("kira")}},
+			&api.Statement{Sql: "UPDATE accounts SET balance=balance-? WHERE name=?", Params: []*api.Parameter{integer(80), text("jade")}},
+		),
+		transaction(t, 5,
+			&api.Statement{Sql: "UPDATE accounts SET balance=0"},
+			&api.Statement{Sql: "COMMIT"},
+		),
+		transaction(t, 6),
+	}
+
+	results := make([]*fsm.Result, 0, len(entries))
+	for _, entry := range entries {
+		out, err := db.Apply(entry)
+		require.NoError(t, err, "could not apply entry %d", entry.Index)
+		results = append(results, out.(*fsm.Result))
+	}
+
+	require.NoError(t, results[1].Err)
+	require.Len(t, results[1].Statements, 2)
+	require.Equal(t, int64(2), results[1].Statements[1].LastInsertID)
+
+	require.NoError(t, results[2].Err)
+	require.Len(t, results[2].Statements, 2)
+	for _, stmt := range results[2].Statements {
+		require.Equal(t, int64(1), stmt.RowsAffected)
+		require.Equal(t, uint64(3), stmt.Index)
+	}
+
+	// The second statement violates the check constraint so the first is rolled back
+	require.ErrorContains(t, results[3].Err, "statement 1: CHECK constraint failed")
+	require.Empty(t, results[3].Statements)
+
+	require.ErrorIs(t, results[4].Err, fsm.ErrTransactionControl)
+	require.ErrorIs(t, results[5].Err, fsm.ErrEmptyTransaction)
+	require.Equal(t, uint64(6), db.LastApplied())
+
+	out, err := db.Query(context.Background(), &api.Statement{Sql: "SELECT name, balance FROM accounts ORDER BY name"})
+	require.NoError(t, err, "could not query accounts")
+	require.Len(t, out.Rows, 2)
+	require.Equal(t, int64(60), out.Rows[0].Values[1].GetInteger())
+	require.Equal(t, int64(40), out.Rows[1].Values[1].GetInteger())
+}
+
+func TestApplyUnsafeStatements(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "otter.db")
+	db, err := fsm.Open(path)
+	require.NoError(t, err, "could not open database")
+
+	_, err = db.Apply(exec(t, 1, "CREATE TABLE otters (id INTEGER PRIMARY KEY, name TEXT)"))
+	require.NoError(t, err, "could not create table")
+
+	tests := []struct {
+		entry *raft.LogEntry
+		err   error
+	}{
+		{exec(t, 2, "INSERT INTO otters (name) VALUES ('jade'); COMMIT"), fsm.ErrMultipleStatements},
+		{exec(t, 3, "INSERT INTO otters (name) VALUES ('jade'); BEGIN; INSERT INTO otters (name) VALUES ('kira')"), fsm.ErrMultipleStatements},
+		{exec(t, 4, "commit;"), fsm.ErrTransactionControl},
+		{exec(t, 5, "INSERT INTO otters (name) VALUES (random())"), fsm.ErrNondeterministic},
+		{exec(t, 6, "INSERT INTO otters (name) VALUES (datetime('now'))"), fsm.ErrNondeterministic},
+		{exec(t, 7, "INSERT INTO otters (name) VALUES (datetime(?))", text(" NOW ")), fsm.ErrNondeterministic},
+		{exec(t, 8, "INSERT INTO otters (name) VALUES (date())"), fsm.ErrNondeterministic},
+		{exec(t, 9, "INSERT INTO otters (name) VALUES (strftime('%s'))"), fsm.ErrNondeterministic},
+		{exec(t, 10, "INSERT INTO otters (name) VALUES (CURRENT_TIMESTAMP)"), fsm.ErrNondeterministic},
+		{exec(t, 11, "UPDATE otters SET name=changes()"), fsm.ErrNondeterministic},
+		{exec(t, 12, "INSERT INTO otters (name) VALUES (datetime('2024-06-01', 'localtime'))"), fsm.ErrNondeterministic},
+		{transaction(t, 13,
+			&api.Statement{Sql: "INSERT INTO otters (name) VALUES ('jade')"},
+			&api.Statement{Sql: "INSERT INTO otters (name) VALUES ('kira'); ROLLBACK"},
+		), fsm.ErrMultipleStatements},
+	}
+
+	for _, tc := range tests {
+		out, err := db.Apply(tc.entry)
+		require.NoError(t, err, "statement errors must not fail entry %d", tc.entry.Index)
+		require.ErrorIs(t, out.(*fsm.Result).Err, tc.err, "unexpected result for entry %d", tc.entry.Index)
+	}
+
+	// Statements that pass validation are still denied by the authorizer
+	for i, query := range []string{
+		"UPDATE _otterdb_meta SET last_applied=100",
+		"DROP TABLE _otterdb_meta",
+		"ATTACH DATABASE ':memory:' AS other",
+	} {
+		out, err := db.Apply(exec(t, uint64(14+i), query))
+		require.NoError(t, err, "statement errors must not fail entry %d", 14+i)
+		require.ErrorContains(t, out.(*fsm.Result).Err, "not authorized", "expected %q to be denied", query)
+	}
+
+	// Triggers are authorized when they are fired by a statement
+	out, err := db.Apply(exec(t, 17, "CREATE TRIGGER otters_meta AFTER INSERT ON otters BEGIN DELETE FROM _otterdb_meta; END"))
+	require.NoError(t, err)
+	require.NoError(t, out.(*fsm.Result).Err)
+
+	out, err = db.Apply(exec(t, 18, "INSERT INTO otters (name) VALUES ('jade')"))
+	require.NoError(t, err)
+	require.ErrorContains(t, out.(*fsm.Result).Err, "not authorized")
+
+	out, err = db.Apply(exec(t, 19, "DROP TRIGGER otters_meta"))
+	require.NoError(t, err)
+	require.NoError(t, out.(*fsm.Result).Err)
+
+	out, err = db.Apply(exec(t, 20, "INSERT INTO otters (name) VALUES (datetime('2024-06-01', '+1 day'))"))
+	require.NoError(t, err)
+	require.NoError(t, out.(*fsm.Result).Err, "deterministic date functions should be allowed")
+	require.Equal(t, uint64(20), db.LastApplied())
+	require.NoError(t, db.Close())
+
+	// The applied index on disk must match the index in memory
+	db, err = fsm.Open(path)
+	require.NoError(t, err, "could not reopen database")
+	defer db.Close()
+	require.Equal(t, uint64(20), db.LastApplied())
+
+	out, err = db.Apply(exec(t, 21, "INSERT INTO otters (name) VALUES ('kira')"))
+	require.NoError(t, err)
+	require.NoError(t, out.(*fsm.Result).Err)
+
+	rows, err := db.Query(context.Background(), &api.Statement{Sql: "SELECT name FROM otters ORDER BY id"})
+	require.NoError(t, err)
+	require.Len(t, rows.Rows, 2)
+	require.Equal(t, "2024-06-02 00:00:00", rows.Rows[0].Values[0].GetText())
+	require.Equal(t, "kira", rows.Rows[1].Values[0].GetText())
+}
+
+func TestQueryUnsafeStatements(t *testing.T) {
+	dir := t.TempDir()
+	db, err := fsm.Open(filepath.Join(dir, "otter.db"))
+	require.NoError(t, err, "could not open database")
+	defer db.Close()
+
+	_, err = db.Apply(exec(t, 1, "CREATE TABLE otters (id INTEGER PRIMARY KEY, name TEXT)"))
+	require.NoError(t, err, "could not create table")
+
+	other, err := fsm.Open(filepath.Join(dir, "other.db"))
+	require.NoError(t, err, "could not open other database")
+	_, err = other.Apply(exec(t, 1, "CREATE TABLE secrets (value TEXT)"))
+	require.NoError(t, err, "could not create table")
+	require.NoError(t, other.Close())
+
+	query := func(sql string) error {
+		_, err := db.Query(context.Background(), &api.Statement{Sql: sql})
+		return err
+	}
+
+	require.ErrorIs(t, query("SELECT 1; SELECT 2"), fsm.ErrMultipleStatements)
+	require.ErrorIs(t, query(" ; "), fsm.ErrEmptyStatement)
+	require.ErrorContains(t, query(fmt.Sprintf("ATTACH '%s' AS other", filepath.Join(dir, "other.db"))), "not authorized")
+	require.ErrorContains(t, query("SELECT * FROM other.secrets"), "no such table")
+	require.ErrorContains(t, query("DETACH other"), "not authorized")
+	require.ErrorContains(t, query("PRAGMA table_info(otters)"), "not authorized")
+	require.ErrorContains(t, query("PRAGMA journal_mode=DELETE"), "not authorized")
+	require.ErrorContains(t, query("INSERT INTO otters (name) VALUES ('jade')"), "not authorized")
+	require.ErrorContains(t, query("CREATE TABLE beavers (id INTEGER)"), "not authorized")
+	require.NoError(t, query("SELECT count(*), random() FROM otters"))
+}
+
+func TestSnapshot(t *testing.T) {
+	dir := t.TempDir()
+	db, err := fsm.Open(filepath.Join(dir, "otter.db"))
+	require.NoError(t, err, "could not open database")
+	defer db.Close()
+
+	entries := []*raft.LogEntry{
+		exec(t, 1, "CREATE TABLE otters (name TEXT PRIMARY KEY)"),
+		exec(t, 2, "INSERT INTO otters VALUES ('jade')"),
+		exec(t, 3, "INSERT INTO otters VALUES ('kira')"),
+	}
+
+	for _, entry := range entries {
+		_, err := db.Apply(entry)
+		require.NoError(t, err, "could not apply entry %d", entry.Index)
+	}
+
+	path := filepath.Join(dir, "otter.snap")
+	lastApplied, err := db.Snapshot(path)
+	require.NoError(t, err, "could not snapshot database")
+	require.Equal(t, uint64(3), lastApplied)
+
+	// Changes after the snapshot are not in the snapshot
+	_, err = db.Apply(exec(t, 4, "DELETE FROM otters"))
+	require.NoError(t, err)
+
+	// Restore the snapshot into a database that is behind the snapshot
+	other, err := fsm.Open(filepath.Join(dir, "other.db"))
+	require.NoError(t, err, "could not open database")
+	defer other.Close()
+
+	require.NoError(t, other.Restore(path), "could not restore snapshot")
+	require.Equal(t, uint64(3), other.LastApplied())
+
+	out, err := other.Query(context.Background(), &api.Statement{Sql: "SELECT count(*) FROM otters"})
+	require.NoError(t, err, "could not query restored database")
+	require.Equal(t, int64(2), out.Rows[0].Values[0].GetInteger())
+
+	// Entries after the snapshot can be applied to the restored database
+	_, err = other.Apply(exec(t, 4, "DELETE FROM otters"))
+	require.NoError(t, err)
+	require.Equal(t, uint64(4), other.LastApplied())
+
+	// Restoring a database that is ahead of the snapshot rolls it back
+	require.NoError(t, db.Restore(path), "could not restore snapshot")
+	require.Equal(t, uint64(3), db.LastApplied())
+}
+
+func TestResultCodec(t *testing.T) {
+	db, err := fsm.Open(filepath.Join(t.TempDir(), "otter.db"))
+	require.NoError(t, err, "could not open database")
+	defer db.Close()
+
+	results := []*fsm.Result{
+		{Index: 1},
+		{Index: 2, LastInsertID: 42, RowsAffected: 1},
+		{Index: 3, Err: fsm.ErrTransactionControl},
+		{Index: 4, Statements: []*fsm.Result{{Index: 4, RowsAffected: 2}, {Index: 4, LastInsertID: 7, RowsAffected: 1}}},
+	}
+
+	for _, result := range results {
+		data, err := db.EncodeResult(result)
+		require.NoError(t, err, "could not encode result")
+
+		out, err := db.DecodeResult(data)
+		require.NoError(t, err, "could not decode result")
+
+		decoded, ok := out.(*fsm.Result)
+		require.True(t, ok, "expected a result to be decoded")
+		require.Equal(t, result.Index, decoded.Index)
+		require.Equal(t, result.LastInsertID, decoded.LastInsertID)
+		require.Equal(t, result.RowsAffected, decoded.RowsAffected)
+		require.Equal(t, result.Statements, decoded.Statements)
+
+		if result.Err != nil {
+			require.EqualError(t, decoded.Err, result.Err.Error())
+		} else {
+			require.NoError(t, decoded.Err)
+		}
+	}
+
+	_, err = db.EncodeResult(uint64(1))
+	require.Error(t, err, "only results can be encoded")
+}
+
+func exec(t *testing.T, index uint64, query string, params ...*api.Parameter) *raft.LogEntry {
+	value, err := proto.Marshal(&api.Statement{Sql: query, Params: params})
+	require.NoError(t, err, "could not marshal statement")
+	return &raft.LogEntry{Index: index, Term: 1, Name: fsm.Exec, Value: value}
+}
+
+func transaction(t *testing.T, index uint64, stmts ...*api.Statement) *raft.LogEntry {
+	value, err := proto.Marshal(&api.TransactionRequest{Statements: stmts})
+	require.NoError(t, err, "could not marshal transaction")
+	return &raft.LogEntry{Index: index, Term: 1, Name: fsm.Transaction, Value: value}
+}
+
+func named(name string, param *api.Parameter) *api.Parameter {
+	param.Name = name
+	return param
+}
+
+func integer(v int64) *api.Parameter {
+	return &api.Parameter{Value: &api.Value{Value: &api.Value_Integer{Integer: v}}}
+}
+
+func double(v float64) *api.Parameter {
+	return &api.Parameter{Value: &api.Value{Value: &api.Value_Real{Real: v}}}
+}
+
+func text(v string) *api.Parameter {
+	return &api.Parameter{Value: &api.Value{Value: &api.Value_Text{Text: v}}}
+}
+
+func blob(v []byte) *api.Parameter {
+	return &api.Parameter{Value: &api.Value{Value: &api.Value_Blob{Blob: v}}}
+}
+
+func null() *api.Parameter {
+	return &api.Parameter{Value: &api.Value{Value: &api.Value_Null{Null: true}}}
+}
+
+func TestClassify(t *testing.T) {
+	db, err := fsm.Open(filepath.Join(t.TempDir(), "otter.db"))
+	require.NoError(t, err, "could not open database")
+	defer db.Close()
+
+	schema := []string{
+		"CREATE TABLE owners (id INTEGER PRIMARY KEY, name TEXT)",
+		"CREATE TABLE otters (id INTEGER PRIMARY KEY, name TEXT, age INTEGER, owner INTEGER REFERENCES owners(id))",
+		"CREATE TABLE ages (id INTEGER PRIMARY KEY, age INTEGER)",
+		"CREATE TABLE secret (value TEXT)",
+		"CREATE TABLE log (value TEXT)",
+		"CREATE VIEW old AS SELECT * FROM secret",
+		"CREATE INDEX idx ON otters (name)",
+		"CREATE TRIGGER audit AFTER INSERT ON owners BEGIN INSERT INTO log VALUES (new.name); END",
+	}
+
+	for i, query := range schema {
+		out, err := db.Apply(exec(t, uint64(i+1), query))
+		require.NoError(t, err)
+		require.NoError(t, out.(*fsm.Result).Err, query)
+	}
+
+	testCases := []struct {
+		sql      string
+		expected *fsm.Classification
+	}{
+		{"SELECT 1", &fsm.Classification{Operation: fsm.OpRead}},
+		{"select o.name from Otters o join main.owners w on o.owner = w.id where o.age in (select age from ages)", &fsm.Classification{Operation: fsm.OpRead, Reads: []string{"ages", "otters", "owners"}}},
+		{"SELECT count(*) FROM [otters]", &fsm.Classification{Operation: fsm.OpRead, Reads: []string{"otters"}}},
+		{"SELECT * FROM (secret)", &fsm.Classification{Operation: fsm.OpRead, Reads: []string{"secret"}}},
+		{"SELECT * FROM otters, (secret)", &fsm.Classification{Operation: fsm.OpRead, Reads: []string{"otters", "secret"}}},
+		{"SELECT * FROM otters NATURAL JOIN (secret)", &fsm.Classification{Operation: fsm.OpRead, Reads: []string{"otters", "secret"}}},
+		{"SELECT * FROM 'secret'", &fsm.Classification{Operation: fsm.OpRead, Reads: []string{"secret"}}},
+		{"SELECT * FROM old", &fsm.Classification{Operation: fsm.OpRead, Reads: []string{"old", "secret"}}},
+		{"SELECT value FROM json_each('[1, 2]')", &fsm.Classification{Operation: fsm.OpRead, Reads: []string{"json_each"}}},
+		{"SELECT * FROM pragma_table_info('secret')", &fsm.Classification{Operation: fsm.OpPragma, Unscoped: true}},
+		{"WITH RECURSIVE n(x) AS (SELECT 1 UNION ALL SELECT x+1 FROM n WHERE x < 10) SELECT x FROM n", &fsm.Classification{Operation: fsm.OpRead}},
+		{"WITH s AS (SELECT * FROM secret) SELECT * FROM s", &fsm.Classification{Operation: fsm.OpRead, Reads: []string{"secret"}}},
+		{"INSERT INTO log SELECT * FROM secret", &fsm.Classification{Operation: fsm.OpWrite, Targets: []string{"log"}, Reads: []string{"secret"}}},
+		{"INSERT INTO otters (name) SELECT * FROM (secret)", &fsm.Classification{Operation: fsm.OpWrite, Targets: []string{"otters"}, Reads: []string{"secret"}}},
+		{"INSERT INTO otters VALUES (1, 'jade', 2, NULL) ON CONFLICT (id) DO UPDATE SET age = excluded.age", &fsm.Classification{Operation: fsm.OpWrite, Targets: []string{"otters"}, Reads: []string{"otters"}}},
+		{"REPLACE INTO ages VALUES (1, 2)", &fsm.Classification{Operation: fsm.OpWrite, Targets: []string{"ages"}}},
+		{"UPDATE OR IGNORE otters SET age = a.age FROM ages a WHERE a.id = otters.id", &fsm.Classification{Operation: fsm.OpWrite, Targets: []string{"otters"}, Reads: []string{"ages", "otters"}}},
+		{"DELETE FROM secret", &fsm.Classification{Operation: fsm.OpWrite, Targets: []string{"secret"}}},
+		{"INSERT INTO owners (name) VALUES ('jade')", &fsm.Classification{Operation: fsm.OpWrite, Targets: []string{"log", "owners"}, Reads: []string{"owners"}}},
+		{"EXPLAIN QUERY PLAN DELETE FROM secret", &fsm.Classification{Operation: fsm.OpWrite, Targets: []string{"secret"}}},
+		{"CREATE TABLE IF NOT EXISTS beavers (id INTEGER PRIMARY KEY)", &fsm.Classification{Operation: fsm.OpDDL, Targets: []string{"beavers"}}},
+		{"CREATE TEMP VIEW recent AS SELECT * FROM otters", &fsm.Classification{Operation: fsm.OpDDL, Targets: []string{"recent"}}},
+		{"CREATE UNIQUE INDEX names ON owners (name)", &fsm.Classification{Operation: fsm.OpDDL, Targets: []string{"owners"}, Reads: []string{"owners"}}},
+		{"DROP INDEX idx", &fsm.Classification{Operation: fsm.OpDDL, Targets: []string{"otters"}}},
+		{"DROP TABLE secret", &fsm.Classification{Operation: fsm.OpDDL, Targets: []string{"secret"}}},
+		{"ALTER TABLE otters ADD COLUMN color TEXT", &fsm.Classification{Operation: fsm.OpDDL, Targets: []string{"otters"}}},
+		{"PRAGMA table_info(otters)", &fsm.Classification{Operation: fsm.OpPragma, Unscoped: true}},
+		{"ANALYZE", &fsm.Classification{Operation: fsm.OpDDL, Targets: []string{"sqlite_stat1"}, Unscoped: true}},
+		{"VACUUM", &fsm.Classification{Operation: fsm.OpDDL, Unscoped: true}},
+		{"ATTACH DATABASE 'other.db' AS other", &fsm.Classification{Operation: fsm.OpDDL, Unscoped: true}},
+	}
+
+	for _, tc := range testCases {
+		actual, err := db.Classify(context.Background(), tc.sql)
+		require.NoError(t, err, tc.sql)
+		require.Equal(t, tc.expected, actual, tc.sql)
+	}
+
+	_, err = db.Classify(context.Background(), " ; -- nothing")
+	require.ErrorIs(t, err, fsm.ErrEmptyStatement)
+
+	_, err = db.Classify(context.Background(), "BEGIN TRANSACTION")
+	require.ErrorIs(t, err, fsm.ErrTransactionControl)
+
+	_, err = db.Classify(context.Background(), "SELECT 1; SELECT * FROM secret")
+	require.ErrorIs(t, err, fsm.ErrMultipleStatements)
+
+	_, err = db.Classify(context.Background(), "SELECT * FROM beavers")
+	require.ErrorIs(t, err, fsm.ErrInvalidStatement)
+
+	_, err = db.Classify(context.Background(), "SELECT 'unterminated")
+	require.ErrorIs(t, err, fsm.ErrInvalidStatement)
+
+	// Classifying a statement does not change the authorizer of the query connections.
+	_, err = db.Query(context.Background(), &api.Statement{Sql: "PRAGMA table_info(otters)"})
+	require.ErrorContains(t, err, "not authorized")
+
+	op, err := fsm.ParseOperation(" DDL ")
+	require.NoError(t, err)
+	require.Equal(t, fsm.OpDDL, op)
+	require.Equal(t, "ddl", op.String())
+
+	_, err = fsm.ParseOperation("admin")
+	require.ErrorIs(t, err, fsm.ErrUnknownOperation)
+}
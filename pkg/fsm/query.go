@@ -0,0 +1,68 @@
+package fsm
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/bbengfort/otterdb/pkg/server/api/v1"
+)
+
+// Query executes a read-only statement against the local database and returns all of
+// the rows in the result. The SQL must contain exactly one statement that only reads
+// from the database; statements that attempt to modify the database, attach other
+// databases, or execute PRAGMAs fail since modifications must be applied through the
+// replicated log.
+func (f *FSM) Query(ctx context.Context, stmt *api.Statement) (out *api.QueryResult, err error) {
+	if _, err = statement(stmt.GetSql()); err != nil {
+		return nil, err
+	}
+
+	var args []interface{}
+	if args, err = Bind(stmt.Params); err != nil {
+		return nil, err
+	}
+
+	var rows *sql.Rows
+	if rows, err = f.reader.QueryContext(ctx, stmt.Sql, args...); err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var types []*sql.ColumnType
+	if types, err = rows.ColumnTypes(); err != nil {
+		return nil, err
+	}
+
+	out = &api.QueryResult{Columns: make([]*api.Column, 0, len(types))}
+	for _, ct := range types {
+		out.Columns = append(out.Columns, &api.Column{Name: ct.Name(), Type: ct.DatabaseTypeName()})
+	}
+
+	for rows.Next() {
+		values := make([]interface{}, len(types))
+		dest := make([]interface{}, len(types))
+		for i := range values {
+			dest[i] = &values[i]
+		}
+
+		if err = rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+
+		row := &api.Row{Values: make([]*api.Value, 0, len(values))}
+		for i, value := range values {
+			var v *api.Value
+			if v, err = Wrap(value); err != nil {
+				return nil, fmt.Errorf("could not convert column %q: %w", out.Columns[i].Name, err)
+			}
+			row.Values = append(row.Values, v)
+		}
+		out.Rows = append(out.Rows, row)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
@@ -0,0 +1,99 @@
+package fsm
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+// Snapshot writes a consistent copy of the database to the specified path using the
+// online backup API and returns the index of the last entry applied to the copy. The
+// copy is made from a read-only connection so entries continue to be applied while the
+// snapshot is taken; the applied index is read from the copy itself.
+func (f *FSM) Snapshot(path string) (lastApplied uint64, err error) {
+	var dst *sql.DB
+	if dst, err = sql.Open(driverName, fmt.Sprintf("file:%s", path)); err != nil {
+		return 0, fmt.Errorf("could not create snapshot: %w", err)
+	}
+	defer dst.Close()
+
+	if err = backup(dst, f.reader); err != nil {
+		return 0, fmt.Errorf("could not copy database to snapshot: %w", err)
+	}
+
+	if err = dst.QueryRow("SELECT last_applied FROM _otterdb_meta WHERE id=1").Scan(&lastApplied); err != nil {
+		return 0, fmt.Errorf("could not read last applied index of snapshot: %w", err)
+	}
+	return lastApplied, nil
+}
+
+// Restore replaces the contents of the database with the snapshot at the specified
+// path using the online backup API, e.g. when the entries that the database is missing
+// have been removed from the log. Entries cannot be applied while the database is
+// being restored.
+func (f *FSM) Restore(path string) (err error) {
+	f.Lock()
+	defer f.Unlock()
+
+	var src *sql.DB
+	if src, err = sql.Open(driverName, fmt.Sprintf("file:%s?%s", path, readerParams)); err != nil {
+		return fmt.Errorf("could not open snapshot: %w", err)
+	}
+	defer src.Close()
+
+	if err = backup(f.db, src); err != nil {
+		return fmt.Errorf("could not restore database from snapshot: %w", err)
+	}
+
+	if err = f.db.QueryRow("SELECT last_applied FROM _otterdb_meta WHERE id=1").Scan(&f.lastApplied); err != nil {
+		return fmt.Errorf("could not read last applied index: %w", err)
+	}
+	return nil
+}
+
+// Copy all of the pages of the source database to the destination database in a single
+// step so that the copy is a consistent view of the source.
+func backup(dst, src *sql.DB) (err error) {
+	ctx := context.Background()
+
+	var dconn, sconn *sql.Conn
+	if dconn, err = dst.Conn(ctx); err != nil {
+		return err
+	}
+	defer dconn.Close()
+
+	if sconn, err = src.Conn(ctx); err != nil {
+		return err
+	}
+	defer sconn.Close()
+
+	return dconn.Raw(func(dc interface{}) error {
+		return sconn.Raw(func(sc interface{}) (err error) {
+			var (
+				dlite, slite *sqlite3.SQLiteConn
+				ok           bool
+			)
+
+			if dlite, ok = dc.(*sqlite3.SQLiteConn); !ok {
+				return fmt.Errorf("unexpected driver connection %T", dc)
+			}
+
+			if slite, ok = sc.(*sqlite3.SQLiteConn); !ok {
+				return fmt.Errorf("unexpected driver connection %T", sc)
+			}
+
+			var bk *sqlite3.SQLiteBackup
+			if bk, err = dlite.Backup("main", slite, "main"); err != nil {
+				return err
+			}
+
+			if _, err = bk.Step(-1); err != nil {
+				bk.Finish()
+				return err
+			}
+			return bk.Finish()
+		})
+	})
+}
@@ -0,0 +1,406 @@
+package fsm
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"strings"
+
+	"github.com/bbengfort/otterdb/pkg/server/api/v1"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+// The table that stores the index of the last applied entry; statements cannot modify it.
+const metaTable = "_otterdb_meta"
+
+// Statements that would end or modify the transaction that applies the entry.
+var transactionKeywords = map[string]struct{}{
+	"BEGIN": {}, "COMMIT": {}, "END": {}, "ROLLBACK": {}, "SAVEPOINT": {}, "RELEASE": {},
+}
+
+// Functions whose results differ between replicas that apply the same statement. The
+// CURRENT_DATE, CURRENT_TIME, and CURRENT_TIMESTAMP keywords are evaluated as functions.
+var nondeterministic = map[string]struct{}{
+	"random":            {},
+	"randomblob":        {},
+	"changes":           {},
+	"total_changes":     {},
+	"last_insert_rowid": {},
+	"current_date":      {},
+	"current_time":      {},
+	"current_timestamp": {},
+}
+
+// Date and time functions use the current time if they are not passed a time value; the
+// value is the number of arguments that precede the time value.
+var timeFunctions = map[string]int{
+	"date": 0, "time": 0, "datetime": 0, "julianday": 0, "unixepoch": 0, "strftime": 1,
+}
+
+// Time values and modifiers that depend on the clock or the time zone of the replica.
+var localTimes = map[string]struct{}{"now": {}, "localtime": {}, "utc": {}}
+
+// Validate returns an error if the statement cannot be applied by the state machine. The
+// SQL must contain exactly one statement that does not begin or end a transaction and
+// whose effects do not depend on the replica that applies it, e.g. by calling random()
+// or datetime('now'). Statements are validated before they are replicated and again when
+// they are applied.
+func Validate(stmt *api.Statement) (err error) {
+	_, err = validate(stmt)
+	return err
+}
+
+// Validate the statement and return its bound parameters.
+func validate(stmt *api.Statement) (args []interface{}, err error) {
+	var tokens []token
+	if tokens, err = statement(stmt.GetSql()); err != nil {
+		return nil, err
+	}
+
+	if tokens[0].kind == tkWord {
+		if _, ok := transactionKeywords[tokens[0].text]; ok {
+			return nil, ErrTransactionControl
+		}
+	}
+
+	if err = deterministic(tokens, stmt.Params); err != nil {
+		return nil, err
+	}
+	return Bind(stmt.Params)
+}
+
+// Returns the tokens of the SQL or an error if it does not contain exactly one statement.
+func statement(query string) (_ []token, err error) {
+	if strings.TrimSpace(query) == "" {
+		return nil, ErrEmptyStatement
+	}
+
+	var tokens []token
+	if tokens, err = tokenize(query); err != nil {
+		return nil, err
+	}
+
+	stmts := split(tokens)
+	switch {
+	case len(stmts) == 0:
+		return nil, ErrEmptyStatement
+	case len(stmts) > 1:
+		return nil, ErrMultipleStatements
+	}
+	return stmts[0], nil
+}
+
+// Returns an error if the statement calls a function whose result differs between
+// replicas or uses the local clock or time zone in a date and time function.
+func deterministic(tokens []token, params []*api.Parameter) error {
+	clock := false
+	for i, tok := range tokens {
+		if tok.kind != tkWord {
+			continue
+		}
+
+		if _, ok := nondeterministic[tok.name]; ok {
+			if strings.HasPrefix(tok.name, "current_") || isPunct(tokens, i+1, "(") {
+				return fmt.Errorf("%w: %s", ErrNondeterministic, tok.name)
+			}
+		}
+
+		if n, ok := timeFunctions[tok.name]; ok && isPunct(tokens, i+1, "(") {
+			if arguments(tokens, i+1) <= n {
+				return fmt.Errorf("%w: %s without a time value", ErrNondeterministic, tok.name)
+			}
+			clock = true
+		}
+	}
+
+	if !clock {
+		return nil
+	}
+
+	for _, tok := range tokens {
+		if tok.kind == tkString && isLocalTime(tok.text) {
+			return fmt.Errorf("%w: %q time value", ErrNondeterministic, tok.text)
+		}
+	}
+
+	for _, param := range params {
+		if text, ok := param.GetValue().GetValue().(*api.Value_Text); ok && isLocalTime(text.Text) {
+			return fmt.Errorf("%w: %q time value", ErrNondeterministic, text.Text)
+		}
+	}
+	return nil
+}
+
+func isLocalTime(value string) bool {
+	_, ok := localTimes[strings.ToLower(strings.TrimSpace(value))]
+	return ok
+}
+
+// Returns the number of arguments of the function call whose parenthesis is at the index.
+func arguments(tokens []token, i int) int {
+	if isPunct(tokens, i+1, ")") {
+		return 0
+	}
+
+	n, depth := 1, 0
+	for ; i < len(tokens); i++ {
+		if tokens[i].kind != tkPunct {
+			continue
+		}
+
+		switch tokens[i].text {
+		case "(":
+			depth++
+		case ")":
+			if depth--; depth == 0 {
+				return n
+			}
+		case ",":
+			if depth == 1 {
+				n++
+			}
+		}
+	}
+	return n
+}
+
+// Authorizes the actions of the statements that are applied to the database. The checks
+// are only made while a client statement is prepared and executed so that the state
+// machine can manage the transaction and the applied index; regardless of the SQL, a
+// statement cannot end the transaction, attach other databases, modify the applied
+// index, or call functions whose results differ between replicas.
+func (f *FSM) authorize(action int, arg1, arg2, _ string) int {
+	if !f.guarded {
+		return sqlite3.SQLITE_OK
+	}
+
+	switch action {
+	case sqlite3.SQLITE_TRANSACTION, sqlite3.SQLITE_SAVEPOINT, sqlite3.SQLITE_ATTACH, sqlite3.SQLITE_DETACH:
+		return sqlite3.SQLITE_DENY
+	case sqlite3.SQLITE_FUNCTION:
+		if _, ok := nondeterministic[strings.ToLower(arg2)]; ok {
+			return sqlite3.SQLITE_DENY
+		}
+	case sqlite3.SQLITE_READ, sqlite3.SQLITE_SELECT:
+	default:
+		if strings.EqualFold(arg1, metaTable) || strings.EqualFold(arg2, metaTable) {
+			return sqlite3.SQLITE_DENY
+		}
+	}
+	return sqlite3.SQLITE_OK
+}
+
+// Authorizes the statements of local queries, which may only read tables and call
+// functions; all other actions, including ATTACH, DETACH, and PRAGMA, are denied so that
+// queries cannot modify the database or the connections of the reader pool.
+func authorizeQuery(action int, _, _, _ string) int {
+	switch action {
+	case sqlite3.SQLITE_READ, sqlite3.SQLITE_SELECT, sqlite3.SQLITE_FUNCTION:
+		return sqlite3.SQLITE_OK
+	default:
+		return sqlite3.SQLITE_DENY
+	}
+}
+
+// Opens database connections with the authorizer of the state machine or of queries.
+type connector struct {
+	dsn    string
+	driver *sqlite3.SQLiteDriver
+}
+
+func (c *connector) Connect(context.Context) (driver.Conn, error) {
+	return c.driver.Open(c.dsn)
+}
+
+func (c *connector) Driver() driver.Driver {
+	return c.driver
+}
+
+// Split the tokens into statements separated by semicolons; the semicolons that
+// separate the statements in the body of a trigger do not end the statement.
+func split(tokens []token) [][]token {
+	var (
+		stmts   [][]token
+		start   int
+		trigger bool
+		body    bool
+		cases   int
+	)
+
+	for i, tok := range tokens {
+		switch {
+		case tok.kind == tkWord:
+			switch tok.text {
+			case "TRIGGER":
+				trigger = isCreateTrigger(tokens[start:i])
+			case "BEGIN":
+				body = body || trigger
+			case "CASE":
+				if body {
+					cases++
+				}
+			case "END":
+				if body {
+					if cases > 0 {
+						cases--
+					} else {
+						body, trigger = false, false
+					}
+				}
+			}
+		case tok.kind == tkPunct && tok.text == ";" && !body:
+			if i > start {
+				stmts = append(stmts, tokens[start:i])
+			}
+			start = i + 1
+		}
+	}
+
+	if start < len(tokens) {
+		stmts = append(stmts, tokens[start:])
+	}
+	return stmts
+}
+
+// Returns true if the tokens are CREATE [TEMP|TEMPORARY], i.e. precede TRIGGER.
+func isCreateTrigger(tokens []token) bool {
+	if len(tokens) == 0 || len(tokens) > 2 || !isWord(tokens, 0, "CREATE") {
+		return false
+	}
+	return len(tokens) == 1 || isWord(tokens, 1, "TEMP") || isWord(tokens, 1, "TEMPORARY")
+}
+
+//===========================================================================
+// SQL Tokenizer
+//===========================================================================
+
+type tokenKind uint8
+
+const (
+	tkWord   tokenKind = iota // Keywords and unquoted identifiers
+	tkQuoted                  // Quoted identifiers
+	tkString                  // String literals
+	tkNumber                  // Numeric literals
+	tkParam                   // Bound parameters
+	tkPunct                   // Operators and punctuation
+)
+
+type token struct {
+	kind tokenKind
+	text string // The upper case text of words, the value of quoted identifiers, or the punctuation
+	name string // The lower case name of words and quoted identifiers
+}
+
+func isWord(tokens []token, i int, word string) bool {
+	return i < len(tokens) && tokens[i].kind == tkWord && tokens[i].text == word
+}
+
+func isPunct(tokens []token, i int, punct string) bool {
+	return i < len(tokens) && tokens[i].kind == tkPunct && tokens[i].text == punct
+}
+
+// Split the SQL into tokens, skipping whitespace and comments.
+func tokenize(query string) (tokens []token, err error) {
+	for i := 0; i < len(query); {
+		c := query[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f' || c == '\v':
+			i++
+		case strings.HasPrefix(query[i:], "--"):
+			if end := strings.IndexByte(query[i:], '\n'); end >= 0 {
+				i += end + 1
+			} else {
+				i = len(query)
+			}
+		case strings.HasPrefix(query[i:], "/*"):
+			// An unterminated block comment extends to the end of the SQL.
+			if end := strings.Index(query[i+2:], "*/"); end >= 0 {
+				i += end + 4
+			} else {
+				i = len(query)
+			}
+		case c == '\'':
+			var value string
+			var n int
+			if value, n, err = unquote(query[i:], '\''); err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, token{kind: tkString, text: value})
+			i += n
+		case c == '"' || c == '`' || c == '[':
+			var value string
+			var n int
+			if value, n, err = unquote(query[i:], closing(c)); err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, token{kind: tkQuoted, text: value, name: strings.ToLower(value)})
+			i += n
+		case c == '?' || c == ':' || c == '@' || c == '$':
+			n := 1
+			for i+n < len(query) && isWordChar(query[i+n]) {
+				n++
+			}
+			tokens = append(tokens, token{kind: tkParam, text: query[i : i+n]})
+			i += n
+		case isDigit(c) || (c == '.' && i+1 < len(query) && isDigit(query[i+1])):
+			n := 1
+			for i+n < len(query) && (isWordChar(query[i+n]) || query[i+n] == '.') {
+				n++
+			}
+			tokens = append(tokens, token{kind: tkNumber, text: query[i : i+n]})
+			i += n
+		case isWordStart(c):
+			n := 1
+			for i+n < len(query) && isWordChar(query[i+n]) {
+				n++
+			}
+			word := query[i : i+n]
+			tokens = append(tokens, token{kind: tkWord, text: strings.ToUpper(word), name: strings.ToLower(word)})
+			i += n
+		default:
+			tokens = append(tokens, token{kind: tkPunct, text: query[i : i+1]})
+			i++
+		}
+	}
+	return tokens, nil
+}
+
+func closing(c byte) byte {
+	if c == '[' {
+		return ']'
+	}
+	return c
+}
+
+// Returns the value of the quoted string at the start of s and the length of the quoted
+// string. A doubled quote within the string is an escaped quote, except for brackets.
+func unquote(s string, quote byte) (string, int, error) {
+	var sb strings.Builder
+	for i := 1; i < len(s); i++ {
+		if s[i] != quote {
+			sb.WriteByte(s[i])
+			continue
+		}
+
+		if quote != ']' && i+1 < len(s) && s[i+1] == quote {
+			sb.WriteByte(quote)
+			i++
+			continue
+		}
+		return sb.String(), i + 1, nil
+	}
+	return "", 0, fmt.Errorf("%w: unterminated quoted string", ErrInvalidStatement)
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isWordStart(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c == '_' || c >= 0x80
+}
+
+func isWordChar(c byte) bool {
+	return isWordStart(c) || isDigit(c) || c == '$'
+}
@@ -0,0 +1,82 @@
+package fsm
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bbengfort/otterdb/pkg/server/api/v1"
+)
+
+// Timestamps are returned in the format SQLite uses for its date and time functions.
+const timeFormat = "2006-01-02 15:04:05.999999999-07:00"
+
+// Bind converts the parameters of a statement into arguments for database/sql.
+// Parameters with a name are bound as named arguments (the :, @, or $ prefix is
+// optional), all other parameters are bound by position.
+func Bind(params []*api.Parameter) (args []interface{}, err error) {
+	args = make([]interface{}, 0, len(params))
+	for i, param := range params {
+		var value interface{}
+		if value, err = Convert(param.Value); err != nil {
+			return nil, fmt.Errorf("could not bind parameter %d: %w", i+1, err)
+		}
+
+		if name := strings.TrimLeft(param.Name, ":@$"); name != "" {
+			args = append(args, sql.Named(name, value))
+			continue
+		}
+		args = append(args, value)
+	}
+	return args, nil
+}
+
+// Convert a protocol buffer value into a value that can be bound to a statement.
+func Convert(value *api.Value) (interface{}, error) {
+	if value == nil {
+		return nil, ErrMissingValue
+	}
+
+	switch v := value.Value.(type) {
+	case *api.Value_Integer:
+		return v.Integer, nil
+	case *api.Value_Real:
+		return v.Real, nil
+	case *api.Value_Text:
+		return v.Text, nil
+	case *api.Value_Blob:
+		return v.Blob, nil
+	case *api.Value_Null:
+		return nil, nil
+	default:
+		return nil, ErrMissingValue
+	}
+}
+
+// Wrap a value scanned from the database in a protocol buffer value. The SQLite driver
+// converts columns declared as timestamps into time.Time and booleans into bool, these
+// are returned as text and integer values respectively, the SQLite storage classes.
+func Wrap(value interface{}) (*api.Value, error) {
+	switch v := value.(type) {
+	case nil:
+		return &api.Value{Value: &api.Value_Null{Null: true}}, nil
+	case int64:
+		return &api.Value{Value: &api.Value_Integer{Integer: v}}, nil
+	case float64:
+		return &api.Value{Value: &api.Value_Real{Real: v}}, nil
+	case string:
+		return &api.Value{Value: &api.Value_Text{Text: v}}, nil
+	case []byte:
+		return &api.Value{Value: &api.Value_Blob{Blob: v}}, nil
+	case bool:
+		if v {
+			return &api.Value{Value: &api.Value_Integer{Integer: 1}}, nil
+		}
+		return &api.Value{Value: &api.Value_Integer{Integer: 0}}, nil
+	case time.Time:
+		return &api.Value{Value: &api.Value_Text{Text: v.Format(timeFormat)}}, nil
+	default:
+		return nil, fmt.Errorf("%w: %T", ErrUnhandledType, value)
+	}
+}
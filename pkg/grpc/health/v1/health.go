@@ -133,6 +133,16 @@ func (h *ProbeServer) Watch(in *HealthCheckRequest, stream Health_WatchServer) (
 	watcher := h.AddWatcher(id, in.Service)
 	ctx := stream.Context()
 
+	// Remove the watcher when the stream ends; the watcher is drained until it is
+	// deleted so that a concurrent status change does not block on the watcher.
+	defer func() {
+		go func() {
+			for range watcher {
+			}
+		}()
+		h.DelWatcher(id)
+	}()
+
 	// Send the first health check message
 	if err = stream.Send(&HealthCheckResponse{Status: h.ServiceStatus(in.Service, true)}); err != nil {
 		if errors.Is(err, io.EOF) {
@@ -5,12 +5,15 @@ package otter
 
 import (
 	"errors"
+	"fmt"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"syscall"
 	"time"
 
 	"github.com/bbengfort/otterdb/pkg/config"
+	"github.com/bbengfort/otterdb/pkg/fsm"
 	"github.com/bbengfort/otterdb/pkg/logger"
 	"github.com/bbengfort/otterdb/pkg/replica"
 	"github.com/bbengfort/otterdb/pkg/server"
@@ -31,8 +34,12 @@ func init() {
 	log.Logger = zerolog.New(os.Stdout).Hook(gcpHook).With().Timestamp().Logger()
 }
 
+// The name of the SQLite database file in the data directory.
+const dbFile = "otter.db"
+
 type OtterDB struct {
 	conf    config.Config
+	fsm     *fsm.FSM
 	replica *replica.Replica
 	server  *server.Server
 	web     *web.Server
@@ -56,18 +63,31 @@ func New(conf config.Config) (svc *OtterDB, err error) {
 	// Create the otterdb service
 	svc = &OtterDB{conf: conf, errc: make(chan error, 1)}
 
+	// Open the local database that committed entries are applied to
+	opts := make([]replica.Option, 0, 1)
+	if conf.DataDir != "" {
+		if err = os.MkdirAll(conf.DataDir, 0755); err != nil {
+			return nil, fmt.Errorf("could not create data directory: %w", err)
+		}
+
+		if svc.fsm, err = fsm.Open(filepath.Join(conf.DataDir, dbFile)); err != nil {
+			return nil, err
+		}
+		opts = append(opts, replica.WithStateMachine(svc.fsm))
+	}
+
 	// Configure the replica service
-	if svc.replica, err = replica.New(conf.Replica); err != nil {
+	if svc.replica, err = replica.New(conf.Replica, opts...); err != nil {
 		return nil, err
 	}
 
 	// Configure the database service
-	if svc.server, err = server.New(conf.Server); err != nil {
+	if svc.server, err = server.New(conf.Server, svc.replica, svc.fsm); err != nil {
 		return nil, err
 	}
 
 	// Configure the web user interface service
-	if svc.web, err = web.New(conf.Web); err != nil {
+	if svc.web, err = web.New(conf.Web, svc.replica, conf.Server.Auth); err != nil {
 		return nil, err
 	}
 
@@ -123,6 +143,13 @@ func (o *OtterDB) Shutdown() (err error) {
 		err = errors.Join(err, serr)
 	}
 
+	// Close the database after the replica has stopped applying entries
+	if o.fsm != nil {
+		if serr := o.fsm.Close(); serr != nil {
+			err = errors.Join(err, serr)
+		}
+	}
+
 	log.Debug().Msg("all otterdb services have shutdown")
 	return err
 }
@@ -0,0 +1,90 @@
+package replica
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+
+	"github.com/bbengfort/otterdb/pkg/config"
+	"github.com/bbengfort/otterdb/pkg/replica/metastore"
+	"github.com/oklog/ulid/v2"
+
+	"github.com/rs/zerolog/log"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Bootstrap initializes the replica in the data directory of the configuration as a
+// member of the cluster with the specified id; the cluster id is included in every RPC
+// so that replicas reject RPCs from replicas that belong to a different cluster. If the
+// cluster id is empty, a new cluster id is generated, e.g. for the first replica of a
+// new cluster; every other replica must be initialized with the id of that cluster.
+// Initializing a replica again with the same cluster id has no effect.
+func Bootstrap(conf config.ReplicaConfig, clusterID string) (_ string, err error) {
+	if !conf.Enabled {
+		return "", ErrNotEnabled
+	}
+
+	if err = conf.Validate(); err != nil {
+		return "", err
+	}
+
+	if clusterID == "" {
+		clusterID = ulid.Make().String()
+	}
+
+	if _, err = ulid.ParseStrict(clusterID); err != nil {
+		return "", ErrInvalidClusterID
+	}
+
+	var store *metastore.Store
+	if store, err = metastore.Open(filepath.Join(conf.DataDir, metaFile)); err != nil {
+		return "", fmt.Errorf("could not open replica metadata: %w", err)
+	}
+
+	defer func() {
+		if cerr := store.Close(); cerr != nil {
+			err = errors.Join(err, cerr)
+		}
+	}()
+
+	state := store.State()
+	switch state.ClusterID {
+	case clusterID:
+		return clusterID, nil
+	case "":
+		state.ClusterID = clusterID
+		if err = store.Save(state); err != nil {
+			return "", err
+		}
+		return clusterID, nil
+	default:
+		return "", fmt.Errorf("%w: %s", ErrInitialized, state.ClusterID)
+	}
+}
+
+// ClusterID returns the id of the cluster the replica was initialized with or an empty
+// string if the replica has not been initialized.
+func (r *Replica) ClusterID() string {
+	return r.clusterID
+}
+
+// Returns a FailedPrecondition error if the remote replica does not belong to the same
+// cluster as the local replica, e.g. if it was accidentally configured with the peers
+// of another cluster. Replicas that have not been initialized only accept RPCs from
+// other replicas that have not been initialized.
+func (r *Replica) checkCluster(remote, clusterID string) error {
+	if clusterID == r.clusterID {
+		return nil
+	}
+
+	log.Warn().Str("remote", remote).Str("remote_cluster", clusterID).Str("cluster", r.clusterID).Msg("rejected rpc from replica in another cluster")
+	return status.Error(codes.FailedPrecondition, fmt.Sprintf("%s: %s belongs to cluster %s but %s belongs to cluster %s", ErrClusterMismatch, remote, clusterName(clusterID), r.name, clusterName(r.clusterID)))
+}
+
+func clusterName(clusterID string) string {
+	if clusterID == "" {
+		return "(uninitialized)"
+	}
+	return clusterID
+}
@@ -0,0 +1,142 @@
+package replica
+
+import (
+	"context"
+
+	"github.com/bbengfort/otterdb/pkg/replica/events"
+	"github.com/bbengfort/otterdb/pkg/replica/raft/v1"
+)
+
+// NoOp is the name of the entry that a leader appends to its log when it is elected so
+// that entries from previous terms can be committed as quickly as possible.
+const NoOp = "noop"
+
+// Config is the name of the entries that change the members of the quorum; the value of
+// the entry is the marshaled configuration that replicas use as soon as it is appended.
+const Config = "config"
+
+// StateMachine applies committed entries to the replicated state, e.g. the database.
+// Entries are applied serially in index order by the event loop. Apply returns a result
+// that is sent to the client that proposed the entry; an error should only be returned
+// if the state machine cannot make progress since it stops the replica. LastApplied is
+// used when the replica starts to skip entries that were applied before a restart.
+type StateMachine interface {
+	Apply(entry *raft.LogEntry) (result interface{}, err error)
+	LastApplied() uint64
+}
+
+// Commit proposes a command to the quorum and blocks until the command has been
+// committed to the replicated log and applied to the state machine or the context is
+// canceled. Only the leader can propose commands; if the local replica is not the leader
+// ErrNotLeader is returned. If replication is disabled, the replica runs as a single node
+// and the command is applied directly to the state machine.
+func (r *Replica) Commit(ctx context.Context, name string, value []byte) (_ *Applied, err error) {
+	if !r.conf.Enabled {
+		return r.applyLocal(name, value)
+	}
+
+	reply := make(chan *proposal, 1)
+	if err = r.Dispatch(&events.Message{Type: events.WriteAhead, Source: reply, Value: &raft.LogEntry{Name: name, Value: value}}); err != nil {
+		return nil, err
+	}
+
+	select {
+	case rep := <-reply:
+		if rep.err != nil {
+			return nil, rep.err
+		}
+		return &Applied{Entry: rep.entry, Result: rep.result}, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Apply a command directly to the state machine without consensus when the replica is
+// running as a single node. The command is assigned the index that follows the last
+// applied entry of the state machine. Commands are serialized by their own mutex rather
+// than the consensus mutex so that the state, term, and leader can be read while a
+// statement is applied.
+func (r *Replica) applyLocal(name string, value []byte) (_ *Applied, err error) {
+	if r.fsm == nil {
+		return nil, ErrNoStateMachine
+	}
+
+	r.local.Lock()
+	defer r.local.Unlock()
+
+	entry := &raft.LogEntry{Index: r.fsm.LastApplied() + 1, Name: name, Value: value}
+
+	var result interface{}
+	if result, err = r.fsm.Apply(entry); err != nil {
+		return nil, err
+	}
+	return &Applied{Entry: entry, Result: result}, nil
+}
+
+// Applied is returned by Commit when the proposed entry has been committed and applied
+// to the state machine, along with the result returned by the state machine.
+type Applied struct {
+	Entry  *raft.LogEntry
+	Result interface{}
+}
+
+// A proposal is sent back to the caller of Commit when the entry has been applied or
+// if the entry could not be committed.
+type proposal struct {
+	entry  *raft.LogEntry
+	result interface{}
+	err    error
+}
+
+// A pending proposal is an entry that has been appended to the log by the leader but
+// has not been committed. The term is tracked so that if the entry is replaced by
+// another leader the proposal is not incorrectly reported as committed.
+type pending struct {
+	term  uint64
+	reply chan<- *proposal
+}
+
+//===========================================================================
+// Pending Proposal Management
+//===========================================================================
+
+// Track a proposed entry until it has been applied or dropped.
+//
+// NOTE: This method is not thread-safe and should only be called from the event loop.
+func (r *Replica) track(entry *raft.LogEntry, reply chan<- *proposal) {
+	if r.pending == nil {
+		r.pending = make(map[uint64]*pending)
+	}
+	r.pending[entry.Index] = &pending{term: entry.Term, reply: reply}
+}
+
+// Notify the pending proposal for an applied entry; if the term of the applied entry
+// does not match the term of the proposal then the proposal was dropped.
+//
+// NOTE: This method is not thread-safe and should only be called from the event loop.
+func (r *Replica) notifyApplied(entry *raft.LogEntry, result interface{}) {
+	p, ok := r.pending[entry.Index]
+	if !ok {
+		return
+	}
+
+	if entry.Term != p.term {
+		p.reply <- &proposal{err: ErrDropped}
+	} else {
+		p.reply <- &proposal{entry: entry, result: result}
+	}
+	delete(r.pending, entry.Index)
+}
+
+// Notify any pending proposals after the specified index that they have been dropped,
+// e.g. because the log has been truncated by a new leader.
+//
+// NOTE: This method is not thread-safe and should only be called from the event loop.
+func (r *Replica) notifyDropped(after uint64) {
+	for index, p := range r.pending {
+		if index > after {
+			p.reply <- &proposal{err: ErrDropped}
+			delete(r.pending, index)
+		}
+	}
+}
@@ -0,0 +1,396 @@
+package replica
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/bbengfort/otterdb/pkg/replica/events"
+	"github.com/bbengfort/otterdb/pkg/replica/peers"
+	"github.com/bbengfort/otterdb/pkg/replica/quorum"
+	"github.com/bbengfort/otterdb/pkg/replica/raft/v1"
+	"github.com/bbengfort/otterdb/pkg/replica/snapshot"
+
+	"github.com/rs/zerolog/log"
+	"google.golang.org/protobuf/proto"
+)
+
+// AddPeer adds a replica to the quorum and blocks until the change has been committed.
+// The membership is changed with joint consensus: the leader first commits a
+// configuration that requires a majority of both the old and the new members and then
+// commits the new configuration. Only one change can be made at a time; if a change is
+// in progress ErrConfigChange is returned. The new replica should be started with the
+// peers of the current quorum so that it waits to be added before it joins elections.
+// If the peer is a learner it receives entries but does not vote or count toward
+// commit, e.g. to serve reads in another region or to catch up before it is promoted.
+func (r *Replica) AddPeer(ctx context.Context, peer *peers.Peer) error {
+	return r.changeConfig(ctx, &configChange{add: peer.Member()})
+}
+
+// PromotePeer changes a learner into a voting member of the quorum and blocks until the
+// change has been committed. The learner must have caught up with the leader so that
+// adding it to the quorum does not stall commits; otherwise ErrNotCaughtUp is returned.
+func (r *Replica) PromotePeer(ctx context.Context, name string) error {
+	return r.changeConfig(ctx, &configChange{promote: name})
+}
+
+// RemovePeer removes a replica from the quorum and blocks until the change has been
+// committed, e.g. to replace a replica that has failed. If the leader is removed it
+// steps down once the change is committed.
+func (r *Replica) RemovePeer(ctx context.Context, name string) error {
+	return r.changeConfig(ctx, &configChange{remove: name})
+}
+
+// Quorum returns the configuration of the quorum as of the latest configuration in the
+// log, which may not be committed yet.
+func (r *Replica) Quorum() *quorum.Joint {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.quorum
+}
+
+// A change to the members of the quorum that is proposed to the leader.
+type configChange struct {
+	add     *raft.Member
+	remove  string
+	promote string
+}
+
+// Returns the members of the quorum after the change is applied to the members.
+func (c *configChange) apply(members []*raft.Member) (next []*raft.Member, err error) {
+	var changed, voters bool
+	next = make([]*raft.Member, 0, len(members)+1)
+	for _, member := range members {
+		switch member.Name {
+		case c.add.GetName():
+			return nil, ErrMemberExists
+		case c.remove:
+			changed = true
+			continue
+		case c.promote:
+			if !member.Learner {
+				return nil, ErrNotLearner
+			}
+
+			changed = true
+			member = proto.Clone(member).(*raft.Member)
+			member.Learner = false
+		}
+
+		voters = voters || !member.Learner
+		next = append(next, member)
+	}
+
+	if c.add != nil {
+		changed = true
+		voters = voters || !c.add.Learner
+		next = append(next, c.add)
+	}
+
+	switch {
+	case !changed:
+		return nil, ErrNotMember
+	case !voters:
+		return nil, ErrEmptyQuorum
+	}
+	return next, nil
+}
+
+// Dispatch the change to the leader and wait for the new configuration to be committed.
+func (r *Replica) changeConfig(ctx context.Context, change *configChange) (err error) {
+	reply := make(chan *proposal, 1)
+	if err = r.Dispatch(&events.Message{Type: events.ConfigChange, Source: reply, Value: change}); err != nil {
+		return err
+	}
+
+	select {
+	case rep := <-reply:
+		return rep.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Append a joint configuration of the current and changed members to the log. The
+// proposal is tracked until the final configuration that the leader appends once the
+// joint configuration is committed has been applied. The leader must have committed an
+// entry in its term before the configuration can be changed so that the change cannot
+// overlap with an uncommitted change from a previous leader.
+//
+// NOTE: This method is not thread-safe and should only be called from the event loop.
+func (r *Replica) onConfigChange(e events.Event) (err error) {
+	var (
+		change *configChange
+		reply  chan<- *proposal
+	)
+
+	if change, reply, err = configChangeEvent(e); err != nil {
+		return err
+	}
+
+	if r.state != Leader {
+		reply <- &proposal{err: ErrNotLeader}
+		return nil
+	}
+
+	if r.transfer != nil {
+		reply <- &proposal{err: ErrTransferring}
+		return nil
+	}
+
+	if len(r.config.Next) > 0 || r.configIndex > r.log.CommitIndex() || r.log.CommitIndex() < r.termStart {
+		reply <- &proposal{err: ErrConfigChange}
+		return nil
+	}
+
+	config := &raft.Configuration{Current: r.config.Current}
+	if config.Next, err = change.apply(r.config.Current); err != nil {
+		reply <- &proposal{err: err}
+		return nil
+	}
+
+	// A learner is caught up if it can be sent the remaining entries in one request.
+	if change.promote != "" && r.matchIndex[change.promote]+maxAppendEntries < r.log.CommitIndex() {
+		reply <- &proposal{err: ErrNotCaughtUp}
+		return nil
+	}
+
+	var entry *raft.LogEntry
+	if entry, err = r.appendConfig(config); err != nil {
+		return err
+	}
+
+	r.track(entry, reply)
+	return r.replicate()
+}
+
+// Once the joint configuration is committed the leader appends the final configuration
+// with only the next members, moving the pending proposal of the joint configuration
+// to the final configuration so that the change is only reported once it is complete.
+//
+// NOTE: This method is not thread-safe and should only be called from the event loop.
+func (r *Replica) leaveJoint() (err error) {
+	joint := r.configIndex
+
+	var entry *raft.LogEntry
+	if entry, err = r.appendConfig(&raft.Configuration{Current: r.config.Next}); err != nil {
+		return err
+	}
+
+	if p, ok := r.pending[joint]; ok {
+		delete(r.pending, joint)
+		r.track(entry, p.reply)
+	}
+	return nil
+}
+
+// Append a configuration entry to the log in the current term; the configuration is
+// used as soon as it is appended to the log.
+//
+// NOTE: This method is not thread-safe and should only be called from the event loop.
+func (r *Replica) appendConfig(config *raft.Configuration) (entry *raft.LogEntry, err error) {
+	entry = &raft.LogEntry{Index: r.log.LastIndex() + 1, Term: r.term, Name: Config}
+	if entry.Value, err = proto.Marshal(config); err != nil {
+		return nil, err
+	}
+
+	if err = r.log.Append(entry); err != nil {
+		return nil, err
+	}
+
+	if err = r.setConfig(config, entry.Index); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+// Use the latest configuration in the entries that were appended to the log.
+//
+// NOTE: This method is not thread-safe and should only be called from the event loop.
+func (r *Replica) appendedConfig(entries []*raft.LogEntry) (err error) {
+	for i := len(entries) - 1; i >= 0; i-- {
+		if entries[i].Name == Config {
+			config := &raft.Configuration{}
+			if err = proto.Unmarshal(entries[i].Value, config); err != nil {
+				return fmt.Errorf("could not unmarshal configuration at index %d: %w", entries[i].Index, err)
+			}
+
+			return r.setConfig(config, entries[i].Index)
+		}
+	}
+	return nil
+}
+
+// Use the latest configuration in the log, e.g. after the log has been truncated or
+// replaced by a snapshot.
+//
+// NOTE: This method is not thread-safe and should only be called from the event loop.
+func (r *Replica) reloadConfig() (err error) {
+	var (
+		config *raft.Configuration
+		index  uint64
+	)
+
+	if config, index, err = r.configAt(r.log.LastIndex()); err != nil {
+		return err
+	}
+
+	return r.setConfig(config, index)
+}
+
+// Returns the latest configuration as of the specified index and the index of the entry
+// that contains it. Configurations that have been compacted from the log are stored
+// with the snapshot; if no configuration has been replicated, the configuration that
+// was loaded from the peers file is returned with an index of zero.
+func (r *Replica) configAt(index uint64) (_ *raft.Configuration, _ uint64, err error) {
+	for ; index > 0 && index >= r.log.FirstIndex(); index-- {
+		var entry *raft.LogEntry
+		if entry, err = r.log.Get(index); err != nil {
+			return nil, 0, err
+		}
+
+		if entry.Name == Config {
+			config := &raft.Configuration{}
+			if err = proto.Unmarshal(entry.Value, config); err != nil {
+				return nil, 0, fmt.Errorf("could not unmarshal configuration at index %d: %w", index, err)
+			}
+			return config, index, nil
+		}
+	}
+
+	if r.snapshots != nil {
+		var latest *snapshot.Snapshot
+		if latest, err = r.snapshots.Latest(); err != nil && !errors.Is(err, snapshot.ErrNoSnapshot) {
+			return nil, 0, err
+		}
+
+		if latest != nil && latest.Meta.Configuration != nil && latest.Meta.LastApplied <= index {
+			return latest.Meta.Configuration, latest.Meta.LastApplied, nil
+		}
+	}
+
+	return r.initial, 0, nil
+}
+
+// Use the configuration for elections and replication: peers that joined the quorum are
+// connected and peers that left the quorum are disconnected. While the membership is
+// changing, entries are replicated to both the current and the next members. An error
+// is returned if the quorum id sequence cannot be persisted before the quorum is used.
+//
+// NOTE: This method is not thread-safe and should only be called from the event loop.
+func (r *Replica) setConfig(config *raft.Configuration, index uint64) (err error) {
+	members := make([]*raft.Member, 0, len(config.Current)+len(config.Next))
+	members = append(members, config.Current...)
+	members = append(members, config.Next...)
+
+	// Learners are not members of the quorum so they are excluded from elections.
+	names := func(members []*raft.Member) []string {
+		names := make([]string, 0, len(members))
+		for _, member := range members {
+			if !member.Learner {
+				names = append(names, member.Name)
+			}
+		}
+		return names
+	}
+
+	joint := quorum.NewJoint(quorum.New(names(config.Current)...), nil)
+	if len(config.Next) > 0 {
+		joint = quorum.NewJoint(joint.Current(), quorum.New(names(config.Next)...))
+	}
+
+	// Persist the quorum id sequence so that quorum ids are not reused after a restart.
+	if err = r.saveMeta(r.term, r.votedFor); err != nil {
+		return fmt.Errorf("could not save quorum id sequence: %w", err)
+	}
+
+	remotes := make(peers.Peers, 0, len(members))
+	for _, member := range members {
+		if member.Name == r.name {
+			continue
+		}
+
+		if _, err := remotes.Get(member.Name); err == nil {
+			continue
+		}
+
+		if peer, err := r.peers.Get(member.Name); err == nil {
+			remotes = append(remotes, peer)
+			continue
+		}
+
+		peer := peers.FromMember(member)
+		if err := r.conns.Connect(peer); err != nil {
+			log.Warn().Err(err).Str("peer", peer.Name).Msg("could not connect to peer")
+			continue
+		}
+		remotes = append(remotes, peer)
+
+		// Start replicating to the peer if the local replica is the leader.
+		if r.nextIndex != nil {
+			r.nextIndex[peer.Name] = r.log.LastIndex() + 1
+			r.matchIndex[peer.Name] = 0
+			r.progress[peer.Name] = &progress{}
+		}
+	}
+
+	for _, peer := range r.peers {
+		if _, err := remotes.Get(peer.Name); err != nil {
+			if err = r.conns.Disconnect(peer); err != nil {
+				log.Debug().Err(err).Str("peer", peer.Name).Msg("could not close connection to peer")
+			}
+
+			delete(r.nextIndex, peer.Name)
+			delete(r.matchIndex, peer.Name)
+			delete(r.progress, peer.Name)
+			delete(r.acks, peer.Name)
+			delete(r.installing, peer.Name)
+		}
+	}
+
+	r.config, r.configIndex = config, index
+
+	// Restart the election timeout if the precedence of the local replica has changed.
+	if rank := rank(config, r.name); rank != r.rank {
+		r.rank = rank
+		if r.election != nil {
+			r.stopElectionTimeout()
+			r.resetElectionTimeout()
+		}
+	}
+
+	r.mu.Lock()
+	r.quorum = joint
+	r.peers = remotes
+	r.mu.Unlock()
+
+	log.Debug().Uint64("index", index).Strs("members", joint.Hosts()).Bool("joint", joint.IsJoint()).Msg("configuration changed")
+
+	// A learner that is promoted to a voter follows the leader as a voter.
+	if r.state == Follower || r.state == Learner {
+		if state := r.followerState(); state != r.state {
+			return r.setState(state)
+		}
+	}
+	return nil
+}
+
+func configChangeEvent(e events.Event) (change *configChange, reply chan<- *proposal, err error) {
+	var (
+		msg *events.Message
+		ok  bool
+	)
+
+	if msg, ok = e.(*events.Message); !ok {
+		return nil, nil, ErrEventTypeError
+	}
+
+	if change, ok = msg.Value.(*configChange); !ok {
+		return nil, nil, ErrEventTypeError
+	}
+
+	if reply, ok = msg.Source.(chan *proposal); !ok {
+		return nil, nil, ErrEventSourceError
+	}
+	return change, reply, nil
+}
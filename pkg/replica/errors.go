@@ -7,6 +7,30 @@ var (
 	ErrCommitIndex      = errors.New("commit index does not refer to an entry in the log")
 	ErrAlreadyCommitted = errors.New("commit index precedes current commit index")
 	ErrMissingCommit    = errors.New("cannot commit entry higher than found in log")
+	ErrMissingEntry     = errors.New("no entry exists in the log at the specified index")
+	ErrNotCommitted     = errors.New("cannot apply entries that have not been committed")
+	ErrNotApplied       = errors.New("cannot compact entries that have not been applied")
+	ErrNoSnapshot       = errors.New("no snapshot is available to restore the state machine from")
+	ErrOutOfOrder       = errors.New("entries must be appended to the log in order")
+	ErrNotLeader        = errors.New("replica is not the leader of the quorum")
+	ErrConfigChange     = errors.New("a configuration change is already in progress")
+	ErrMemberExists     = errors.New("replica is already a member of the quorum")
+	ErrNotMember        = errors.New("replica is not a member of the quorum")
+	ErrEmptyQuorum      = errors.New("cannot remove the last voting member of the quorum")
+	ErrNotLearner       = errors.New("replica is not a learner in the quorum")
+	ErrNotCaughtUp      = errors.New("learner has not caught up with the leader")
+	ErrNoLeader         = errors.New("the leader of the quorum is not known")
+	ErrTransferring     = errors.New("leadership is being transferred to another replica")
+	ErrTransferTimeout  = errors.New("leadership transfer did not complete before the timeout")
+	ErrNoTransferTarget = errors.New("no voting member of the quorum to transfer leadership to")
+	ErrClusterMismatch  = errors.New("remote replica belongs to a different cluster")
+	ErrInitialized      = errors.New("replica has already been initialized with a different cluster id")
+	ErrInvalidClusterID = errors.New("cluster id must be a valid ulid")
+	ErrNotEnabled       = errors.New("replication is not enabled")
+	ErrNotPeer          = errors.New("client certificate does not identify a peer of the replica")
+	ErrNoCertificates   = errors.New("no certificate authorities found in the certificate authority file")
+	ErrNoStateMachine   = errors.New("replica does not have a state machine to apply commands to")
+	ErrDropped          = errors.New("proposed entry was removed from the log before it was committed")
 	ErrNotImplemented   = errors.New("functionality not implemented yet")
 	ErrEventTypeError   = errors.New("captured event with wrong value type")
 	ErrEventSourceError = errors.New("captured event with wrong source type")
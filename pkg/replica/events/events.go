@@ -33,6 +33,14 @@ const (
 	VoteReply
 	AppendRequest
 	AppendReply
+	ReadIndex
+	Snapshot
+	InstallRequest
+	InstallReply
+	ConfigChange
+	TransferLeadership
+	TransferTimeout
+	TimeoutNow
 )
 
 // Names of event types for easier debugging
@@ -42,6 +50,10 @@ var eventTypes = [...]string{
 	"heartbeatTimeout", "electionTimeout",
 	"voteRequest", "voteReply",
 	"appendRequest", "appendReply",
+	"readIndex", "snapshot",
+	"installRequest", "installReply",
+	"configChange",
+	"transferLeadership", "transferTimeout", "timeoutNow",
 }
 
 func (t EventType) String() string {
@@ -57,3 +69,16 @@ type AggregatedWriteAheadEvents []Event
 func (a AggregatedWriteAheadEvents) Event() EventType {
 	return AggregatedWriteAhead
 }
+
+// Message is a generic event that carries a value such as an RPC request or reply. If
+// the event requires a response, the source is used to send it back to the caller,
+// e.g. a channel that an RPC handler is waiting on.
+type Message struct {
+	Type   EventType   // The type of event for the handler to dispatch on
+	Source interface{} // The origin of the event, e.g. a reply channel
+	Value  interface{} // The data associated with the event, e.g. an RPC request
+}
+
+func (m *Message) Event() EventType {
+	return m.Type
+}
@@ -22,6 +22,14 @@ func TestEvents(t *testing.T) {
 		{events.VoteReply, "voteReply"},
 		{events.AppendRequest, "appendRequest"},
 		{events.AppendReply, "appendReply"},
+		{events.ReadIndex, "readIndex"},
+		{events.Snapshot, "snapshot"},
+		{events.InstallRequest, "installRequest"},
+		{events.InstallReply, "installReply"},
+		{events.ConfigChange, "configChange"},
+		{events.TransferLeadership, "transferLeadership"},
+		{events.TransferTimeout, "transferTimeout"},
+		{events.TimeoutNow, "timeoutNow"},
 	}
 
 	for i, tc := range testCases {
@@ -0,0 +1,142 @@
+package replica
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/bbengfort/otterdb/pkg/replica/peers"
+	"github.com/bbengfort/otterdb/pkg/replica/raft/v1"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ResultCodec is implemented by state machines whose results can be sent between
+// replicas so that the result of a command that a follower forwards to the leader can
+// be returned to the follower. The leader encodes the result that its state machine
+// returned and the follower decodes it using its own state machine.
+type ResultCodec interface {
+	EncodeResult(result interface{}) ([]byte, error)
+	DecodeResult(data []byte) (interface{}, error)
+}
+
+// ForwardCommit proposes a command to the leader of the quorum on behalf of the local
+// replica and blocks until the leader has committed the command and applied it to its
+// state machine. It is used by followers to accept commands from clients that are not
+// connected to the leader. The local state machine may not yet have applied the command
+// when ForwardCommit returns. If the leader is not known, ErrNoLeader is returned.
+func (r *Replica) ForwardCommit(ctx context.Context, name string, value []byte) (_ *Applied, err error) {
+	if !r.conf.Enabled {
+		return r.applyLocal(name, value)
+	}
+
+	var leader *peers.Peer
+	if leader, err = r.leaderPeer(); err != nil {
+		return nil, err
+	}
+
+	var rep *raft.ForwardReply
+	if rep, err = leader.Forward(ctx, &raft.ForwardRequest{Remote: r.name, Name: name, Value: value, ClusterID: r.clusterID}); err != nil {
+		return nil, forwardError(err)
+	}
+
+	applied := &Applied{Entry: &raft.LogEntry{Index: rep.Index, Term: rep.Term, Name: name, Value: value}}
+	if codec, ok := r.fsm.(ResultCodec); ok && rep.Result != nil {
+		if applied.Result, err = codec.DecodeResult(rep.Result); err != nil {
+			return nil, fmt.Errorf("could not decode result from leader: %w", err)
+		}
+	}
+	return applied, nil
+}
+
+// LeaderAddr returns the name and the database server address of the leader of the
+// current term so that clients can be redirected to the leader. If the leader is not
+// known or is the local replica, ErrNoLeader is returned.
+func (r *Replica) LeaderAddr() (name, addr string, err error) {
+	var leader *peers.Peer
+	if leader, err = r.leaderPeer(); err != nil {
+		return "", "", err
+	}
+	return leader.Name, leader.ClientAddr, nil
+}
+
+// Returns the remote peer that is the leader of the current term.
+func (r *Replica) leaderPeer() (_ *peers.Peer, err error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if r.leader == "" || r.leader == r.name {
+		return nil, ErrNoLeader
+	}
+
+	var peer *peers.Peer
+	if peer, err = r.peers.Get(r.leader); err != nil {
+		return nil, ErrNoLeader
+	}
+	return peer, nil
+}
+
+// Forward is called by followers in the same cluster to propose a command on behalf of
+// a client. The command is committed as though it were proposed by the local replica,
+// which must be the leader; the index and term of the entry are returned along with the
+// encoded result of applying the entry to the state machine.
+func (r *Replica) Forward(ctx context.Context, in *raft.ForwardRequest) (out *raft.ForwardReply, err error) {
+	if err = r.checkCluster(in.Remote, in.ClusterID); err != nil {
+		return nil, err
+	}
+
+	if err = r.checkRemote(ctx, in.Remote); err != nil {
+		return nil, err
+	}
+
+	var applied *Applied
+	if applied, err = r.Commit(ctx, in.Name, in.Value); err != nil {
+		switch {
+		case errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded):
+			return nil, status.FromContextError(err).Err()
+		case errors.Is(err, ErrNotLeader):
+			return nil, status.Error(codes.FailedPrecondition, err.Error())
+		case errors.Is(err, ErrDropped):
+			return nil, status.Error(codes.Aborted, err.Error())
+		case errors.Is(err, ErrNotListening), errors.Is(err, ErrTransferring):
+			return nil, status.Error(codes.Unavailable, err.Error())
+		default:
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+	}
+
+	out = &raft.ForwardReply{Index: applied.Entry.Index, Term: applied.Entry.Term}
+	if codec, ok := r.fsm.(ResultCodec); ok {
+		if out.Result, err = codec.EncodeResult(applied.Result); err != nil {
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+	}
+	return out, nil
+}
+
+// Convert the status returned by the leader for a forwarded command back into the
+// errors returned by Commit so that callers handle both in the same way.
+func forwardError(err error) error {
+	serr, ok := status.FromError(err)
+	if !ok {
+		return err
+	}
+
+	switch serr.Code() {
+	case codes.FailedPrecondition:
+		if strings.HasPrefix(serr.Message(), ErrClusterMismatch.Error()) {
+			return fmt.Errorf("could not forward command to leader: %w", ErrClusterMismatch)
+		}
+		return ErrNotLeader
+	case codes.Aborted:
+		return ErrDropped
+	case codes.Canceled:
+		return context.Canceled
+	case codes.DeadlineExceeded:
+		return context.DeadlineExceeded
+	default:
+		return fmt.Errorf("could not forward command to leader: %w", err)
+	}
+}
@@ -1,12 +1,64 @@
 package replica
 
-import "github.com/bbengfort/otterdb/pkg/replica/events"
+import (
+	"fmt"
+	"time"
 
+	"github.com/bbengfort/otterdb/pkg/replica/events"
+	"github.com/bbengfort/otterdb/pkg/replica/peers"
+	"github.com/bbengfort/otterdb/pkg/replica/raft/v1"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Handle implements the events.Handler interface and is called by the event loop for
+// every event dispatched to the replica. All consensus state is modified by the event
+// handlers, which means that state does not have to be locked in the handlers so long
+// as only the event loop calls them. Returning an error from a handler will stop the
+// event loop, so errors should only be returned if the replica cannot continue.
 func (r *Replica) Handle(e events.Event) error {
-	return nil
+	switch e.Event() {
+	case events.WriteAhead:
+		return r.onWriteAhead(e)
+	case events.AggregatedWriteAhead:
+		return r.onAggregatedWriteAhead(e)
+	case events.HeartbeatTimeout:
+		return r.onHeartbeatTimeout()
+	case events.ElectionTimeout:
+		return r.onElectionTimeout()
+	case events.VoteRequest:
+		return r.onVoteRequest(e)
+	case events.VoteReply:
+		return r.onVoteReply(e)
+	case events.AppendRequest:
+		return r.onAppendRequest(e)
+	case events.AppendReply:
+		return r.onAppendReply(e)
+	case events.ReadIndex:
+		return r.onReadIndex(e)
+	case events.Snapshot:
+		return r.onSnapshot(e)
+	case events.InstallRequest:
+		return r.onInstallRequest(e)
+	case events.InstallReply:
+		return r.onInstallReply(e)
+	case events.ConfigChange:
+		return r.onConfigChange(e)
+	case events.TransferLeadership:
+		return r.onTransferLeadership(e)
+	case events.TransferTimeout:
+		return r.onTransferTimeout(e)
+	case events.TimeoutNow:
+		return r.onTimeoutNow(e)
+	default:
+		return fmt.Errorf("no handler identified for event %s", e.Event())
+	}
 }
 
 func (r *Replica) Dispatch(e events.Event) error {
+	r.pipe.RLock()
+	defer r.pipe.RUnlock()
+
 	if r.events == nil {
 		return ErrNotListening
 	}
@@ -14,3 +66,772 @@ func (r *Replica) Dispatch(e events.Event) error {
 	r.events <- e
 	return nil
 }
+
+//===========================================================================
+// Event Handlers
+//===========================================================================
+
+// Leaders send append entries to all followers on every heartbeat; heartbeats that
+// were already in the event queue when the replica stepped down are ignored. With
+// CheckQuorum, the leader steps down if a majority of the quorum has not acknowledged
+// its leadership within an election timeout, e.g. because it is partitioned from the
+// quorum, so that clients are not served by a leader that cannot commit entries.
+func (r *Replica) onHeartbeatTimeout() error {
+	if r.state != Leader {
+		return nil
+	}
+
+	if r.conf.CheckQuorum {
+		contact := r.quorumContact()
+		if contact.Before(r.elected) {
+			contact = r.elected
+		}
+
+		if time.Since(contact) > r.electionTimeout() {
+			log.Warn().Uint64("term", r.term).Dur("since", time.Since(contact)).Msg("leader has not heard from a majority of the quorum, stepping down")
+			return r.setState(Follower)
+		}
+	}
+
+	r.broadcastAppendEntries(true)
+	return nil
+}
+
+// If an election timeout occurs the replica has not heard from a leader or granted a
+// vote to a candidate, so it becomes a candidate and starts a new election, first
+// checking that it can win the election with a pre-vote if enabled. Replicas that are
+// not members of the quorum do not start elections, e.g. replicas that are waiting to
+// be added to the quorum or that have been removed from it.
+func (r *Replica) onElectionTimeout() error {
+	if r.state == Leader || !r.quorum.Contains(r.name) {
+		return nil
+	}
+
+	if r.conf.PreVote {
+		return r.preCampaign()
+	}
+	return r.campaign(false)
+}
+
+// Grant a vote to the candidate if the candidate's term is current, the replica has
+// not already voted for another candidate in this term, and the candidate's log is at
+// least as up to date as the local log. Replicas that are not voting members of the
+// quorum, e.g. learners, never grant votes. Pre-votes are granted without changing the
+// term or vote of the replica if the candidate could win an election in its term and the
+// replica has not recently heard from a leader.
+func (r *Replica) onVoteRequest(e events.Event) (err error) {
+	var (
+		req   *raft.VoteRequest
+		reply chan<- *raft.VoteReply
+	)
+
+	if req, reply, err = voteRequest(e); err != nil {
+		return err
+	}
+
+	out := &raft.VoteReply{Remote: r.name, Term: r.term, PreVote: req.PreVote}
+
+	// Pre-votes for a later term are ignored while the leader is alive, as are votes with
+	// CheckQuorum, unless the leader is transferring leadership to the candidate.
+	if req.Term > r.term && !req.Transfer && (req.PreVote || r.conf.CheckQuorum) && r.inLease() {
+		log.Debug().
+			Uint64("term", r.term).
+			Str("candidate", req.Candidate).
+			Str("leader", r.leader).
+			Msg("vote request ignored in leader lease")
+		reply <- out
+		return nil
+	}
+
+	if req.PreVote {
+		if req.Term > r.term && r.quorum.Contains(r.name) && r.log.AsUpToDate(req.LastLogIndex, req.LastLogTerm) {
+			out.Term = req.Term
+			out.Granted = true
+		}
+
+		log.Debug().
+			Uint64("term", req.Term).
+			Str("candidate", req.Candidate).
+			Bool("granted", out.Granted).
+			Msg("pre-vote requested")
+
+		reply <- out
+		return nil
+	}
+
+	// If the candidate is in a later term, step down and move into that term.
+	if req.Term > r.term {
+		if err = r.setTerm(req.Term); err != nil {
+			return err
+		}
+		if err = r.setState(Follower); err != nil {
+			return err
+		}
+	}
+
+	out.Term = r.term
+	if req.Term == r.term && r.quorum.Contains(r.name) && (r.votedFor == "" || r.votedFor == req.Candidate) && r.log.AsUpToDate(req.LastLogIndex, req.LastLogTerm) {
+		if err = r.setVote(req.Candidate); err != nil {
+			return err
+		}
+		out.Granted = true
+		r.resetElectionTimeout()
+	}
+
+	log.Debug().
+		Uint64("term", r.term).
+		Str("candidate", req.Candidate).
+		Bool("granted", out.Granted).
+		Msg("vote requested")
+
+	reply <- out
+	return nil
+}
+
+// Count the votes for the local replica, becoming the leader if a majority of the
+// quorum has granted their vote in the current term. Pre-candidates start an election
+// once a majority of the quorum has granted their pre-vote for the next term.
+func (r *Replica) onVoteReply(e events.Event) (err error) {
+	var reply *raft.VoteReply
+	if reply, err = voteReply(e); err != nil {
+		return err
+	}
+
+	// If a remote is in a later term, step down and move into that term; granted
+	// pre-votes are in the term that the pre-candidate requested.
+	if reply.Term > r.term && !(reply.PreVote && reply.Granted) {
+		if err = r.setTerm(reply.Term); err != nil {
+			return err
+		}
+		return r.setState(Follower)
+	}
+
+	// Ignore votes from previous elections or if no longer a (pre-)candidate.
+	if reply.PreVote {
+		if r.state != PreCandidate || reply.Term != r.term+1 || !reply.Granted {
+			return nil
+		}
+	} else if r.state != Candidate || reply.Term != r.term || !reply.Granted {
+		return nil
+	}
+
+	var passed bool
+	if passed, err = r.votes.Vote(reply.Remote); err != nil {
+		log.Debug().Err(err).Str("remote", reply.Remote).Msg("could not count vote")
+		return nil
+	}
+
+	if passed {
+		if reply.PreVote {
+			return r.campaign(false)
+		}
+		return r.setState(Leader)
+	}
+	return nil
+}
+
+// Accept the remote as the leader if it is in the current term and reset the election
+// timeout since the leader is still alive. If the local log contains the entry that
+// precedes the leader's entries, any conflicting entries are removed and the new
+// entries are appended to the log, otherwise the request is rejected so that the
+// leader can back off and resend earlier entries.
+func (r *Replica) onAppendRequest(e events.Event) (err error) {
+	var (
+		req   *raft.AppendRequest
+		reply chan<- *raft.AppendReply
+	)
+
+	if req, reply, err = appendRequest(e); err != nil {
+		return err
+	}
+
+	// If the leader is in a later term, step down and move into that term.
+	if req.Term > r.term {
+		if err = r.setTerm(req.Term); err != nil {
+			return err
+		}
+		if err = r.setState(Follower); err != nil {
+			return err
+		}
+	}
+
+	out := &raft.AppendReply{Remote: r.name, Term: r.term, Index: r.log.LastIndex(), CommitIndex: r.log.CommitIndex()}
+
+	// Reject requests from leaders of previous terms.
+	if req.Term < r.term {
+		reply <- out
+		return nil
+	}
+
+	// A candidate that hears from the leader of its term concedes the election.
+	if r.state == Candidate || r.state == PreCandidate {
+		if err = r.setState(Follower); err != nil {
+			return err
+		}
+	}
+
+	if r.leader != req.Leader {
+		r.setLeader(req.Leader)
+		log.Info().Uint64("term", r.term).Str("leader", req.Leader).Msg("following leader")
+	}
+	r.contact = time.Now()
+	r.resetElectionTimeout()
+
+	// Log consistency check: reject the request if the previous entry does not match.
+	if !r.log.Matches(req.PrevLogIndex, req.PrevLogTerm) {
+		if req.PrevLogIndex <= r.log.LastIndex() && req.PrevLogIndex > 0 {
+			// Hint to the leader that the conflicting entry must be replaced.
+			out.Index = req.PrevLogIndex - 1
+		}
+
+		log.Debug().
+			Uint64("prev_log_index", req.PrevLogIndex).
+			Uint64("prev_log_term", req.PrevLogTerm).
+			Uint64("last_index", r.log.LastIndex()).
+			Msg("rejecting append entries: log mismatch")
+		reply <- out
+		return nil
+	}
+
+	// Skip any entries that are already in the log, truncating the log at the first
+	// conflicting entry and appending all entries that follow it.
+	entries := req.Entries
+	for i, entry := range entries {
+		if entry.Index > r.log.LastIndex() {
+			entries = entries[i:]
+			break
+		}
+
+		if !r.log.Matches(entry.Index, entry.Term) {
+			if err = r.log.Truncate(entry.Index - 1); err != nil {
+				return err
+			}
+			r.notifyDropped(entry.Index - 1)
+
+			// A configuration in the truncated entries is no longer used.
+			if r.configIndex > r.log.LastIndex() {
+				if err = r.reloadConfig(); err != nil {
+					return err
+				}
+			}
+			entries = entries[i:]
+			break
+		}
+
+		if i == len(entries)-1 {
+			entries = nil
+		}
+	}
+
+	if err = r.log.Append(entries...); err != nil {
+		return err
+	}
+
+	if err = r.appendedConfig(entries); err != nil {
+		return err
+	}
+
+	// Commit entries up to the leader's commit index that are known to match.
+	matched := req.PrevLogIndex + uint64(len(req.Entries))
+	if commitIndex := min(req.LeaderCommit, matched); commitIndex > r.log.CommitIndex() {
+		if err = r.commit(commitIndex); err != nil {
+			return err
+		}
+	}
+
+	// The follower is up to date with the leader if it has applied the leader's commit
+	// index, which bounds the staleness of local reads.
+	if r.log.LastApplied() >= req.LeaderCommit {
+		r.mu.Lock()
+		r.syncedAt = time.Now()
+		r.mu.Unlock()
+	}
+
+	out.Success = true
+	out.Index = matched
+	out.CommitIndex = r.log.CommitIndex()
+	reply <- out
+	return nil
+}
+
+// Leaders step down if a follower has moved on to a later term, otherwise the progress
+// of the follower is updated, advancing the commit index if a majority of the quorum
+// has replicated the entry, and more entries are pipelined to the follower. If the
+// follower rejected the request, the leader returns the follower to probe mode, backing
+// off and retrying with earlier entries until the logs match. If the request failed, the
+// follower is probed on the next heartbeat.
+func (r *Replica) onAppendReply(e events.Event) (err error) {
+	var rep *appendResponse
+	if rep, err = appendReply(e); err != nil {
+		return err
+	}
+
+	if rep.err != nil {
+		if pr := r.progress[rep.peer]; r.state == Leader && pr != nil {
+			if pr.pipeline {
+				r.nextIndex[rep.peer] = r.matchIndex[rep.peer] + 1
+			}
+			pr.probe()
+		}
+		return nil
+	}
+
+	reply, sent := rep.reply, rep.sent
+
+	if reply.Term > r.term {
+		if err = r.setTerm(reply.Term); err != nil {
+			return err
+		}
+		return r.setState(Follower)
+	}
+
+	// Ignore replies from previous terms or if no longer the leader.
+	if r.state != Leader || reply.Term != r.term {
+		return nil
+	}
+
+	// Any reply in the current term acknowledges the leadership of the local replica.
+	r.ack(reply.Remote, sent)
+	r.serveReads()
+
+	pr := r.progress[reply.Remote]
+	if pr == nil {
+		log.Debug().Str("remote", reply.Remote).Msg("append reply from unknown peer")
+		return nil
+	}
+
+	if reply.Success {
+		if reply.Index > r.matchIndex[reply.Remote] {
+			r.matchIndex[reply.Remote] = reply.Index
+		}
+
+		// Entries in flight beyond the match index are not sent again.
+		if r.nextIndex[reply.Remote] <= r.matchIndex[reply.Remote] {
+			r.nextIndex[reply.Remote] = r.matchIndex[reply.Remote] + 1
+		}
+
+		if pr.pipeline {
+			pr.ack(reply.Index)
+		} else if !r.installing[reply.Remote] {
+			pr.replicate()
+		}
+
+		if r.transfer != nil && r.transfer.target == reply.Remote {
+			r.advanceTransfer()
+		}
+
+		if err = r.updateCommitIndex(); err != nil {
+			return err
+		}
+
+		if r.conf.Priority {
+			r.maybeYield()
+		}
+
+		// Send the entries that were waiting for room in the window of the follower.
+		if r.state == Leader && r.nextIndex[reply.Remote] <= r.log.LastIndex() {
+			if peer, err := r.peers.Get(reply.Remote); err == nil {
+				r.sendAppendEntries(peer, false)
+			}
+		}
+		return nil
+	}
+
+	// Heartbeats sent while a snapshot is installed are not retried.
+	if r.installing[reply.Remote] {
+		return nil
+	}
+
+	// Back off on log mismatch, never moving the next index behind the match index; the
+	// entries in flight will be rejected as well so the follower is probed instead.
+	if pr.pipeline {
+		r.nextIndex[reply.Remote] = r.matchIndex[reply.Remote] + 1
+		pr.probe()
+	}
+	pr.paused = false
+
+	next := r.nextIndex[reply.Remote]
+	if next > 1 {
+		next--
+	}
+
+	if reply.Index+1 < next {
+		next = reply.Index + 1
+	}
+
+	if next <= r.matchIndex[reply.Remote] {
+		next = r.matchIndex[reply.Remote] + 1
+	}
+	r.nextIndex[reply.Remote] = next
+
+	// Immediately retry the follower with the earlier entries.
+	var peer *peers.Peer
+	if peer, err = r.peers.Get(reply.Remote); err != nil {
+		log.Debug().Err(err).Msg("append reply from unknown peer")
+		return nil
+	}
+
+	r.sendAppendEntries(peer, false)
+	return nil
+}
+
+// The leader appends proposed entries to its log and replicates them to its followers.
+func (r *Replica) onWriteAhead(e events.Event) (err error) {
+	if err = r.appendProposal(e); err != nil {
+		return err
+	}
+	return r.replicate()
+}
+
+// Aggregated proposals are appended to the log together and are replicated to the
+// followers in a single append entries request.
+func (r *Replica) onAggregatedWriteAhead(e events.Event) (err error) {
+	var (
+		agg events.AggregatedWriteAheadEvents
+		ok  bool
+	)
+
+	if agg, ok = e.(events.AggregatedWriteAheadEvents); !ok {
+		return ErrEventTypeError
+	}
+
+	for _, proposal := range agg {
+		if err = r.appendProposal(proposal); err != nil {
+			return err
+		}
+	}
+	return r.replicate()
+}
+
+// Append a proposed entry to the log in the current term and track the proposal until
+// it has been committed. If the replica is not the leader the proposal is rejected.
+func (r *Replica) appendProposal(e events.Event) (err error) {
+	var (
+		entry *raft.LogEntry
+		reply chan<- *proposal
+	)
+
+	if entry, reply, err = writeAhead(e); err != nil {
+		return err
+	}
+
+	if r.state != Leader {
+		reply <- &proposal{err: ErrNotLeader}
+		return nil
+	}
+
+	// Proposals are rejected while leadership is transferred so the target can catch up.
+	if r.transfer != nil {
+		reply <- &proposal{err: ErrTransferring}
+		return nil
+	}
+
+	entry.Index = r.log.LastIndex() + 1
+	entry.Term = r.term
+	if err = r.log.Append(entry); err != nil {
+		return err
+	}
+
+	r.track(entry, reply)
+	return nil
+}
+
+// Become a candidate and request votes in a new term; in a single replica quorum the
+// self-vote is enough to become the leader. If the election is started by a leadership
+// transfer, the voters do not ignore the request while the leader is alive.
+//
+// NOTE: This method is not thread-safe and should only be called from the event loop.
+func (r *Replica) campaign(transfer bool) error {
+	if err := r.setState(Candidate); err != nil {
+		return err
+	}
+
+	if r.votes.Passed() {
+		return r.setState(Leader)
+	}
+
+	r.broadcastRequestVote(false, transfer)
+	return nil
+}
+
+// Become a pre-candidate and request pre-votes for the next term, starting an election
+// once a majority of the quorum has granted their pre-vote.
+//
+// NOTE: This method is not thread-safe and should only be called from the event loop.
+func (r *Replica) preCampaign() error {
+	if err := r.setState(PreCandidate); err != nil {
+		return err
+	}
+
+	if r.votes.Passed() {
+		return r.campaign(false)
+	}
+
+	r.broadcastRequestVote(true, false)
+	return nil
+}
+
+//===========================================================================
+// Commit Management
+//===========================================================================
+
+// Replicate newly appended entries to the followers and commit them immediately if the
+// local replica is the only voting member of the quorum.
+//
+// NOTE: This method is not thread-safe and should only be called from the event loop.
+func (r *Replica) replicate() error {
+	if r.state != Leader {
+		return nil
+	}
+
+	r.broadcastAppendEntries(false)
+	return r.updateCommitIndex()
+}
+
+// The leader commits the latest entry in the current term that has been replicated to
+// a majority of the quorum. Entries from previous terms are committed indirectly.
+//
+// NOTE: This method is not thread-safe and should only be called from the event loop.
+func (r *Replica) updateCommitIndex() error {
+	for index := r.log.LastIndex(); index > r.log.CommitIndex(); index-- {
+		entry, err := r.log.Get(index)
+		if err != nil {
+			return err
+		}
+
+		// Only entries from the current term are committed by counting replicas.
+		if entry.Term != r.term {
+			return nil
+		}
+
+		// The leader does not vote if it is being removed from the quorum.
+		votes := r.quorum.Election()
+		votes.Vote(r.name)
+		for name, match := range r.matchIndex {
+			if match >= index {
+				votes.Vote(name)
+			}
+		}
+
+		if votes.Passed() {
+			return r.commit(index)
+		}
+	}
+	return nil
+}
+
+// Commit the log up to the specified index and apply the committed entries.
+//
+// NOTE: This method is not thread-safe and should only be called from the event loop.
+func (r *Replica) commit(index uint64) (err error) {
+	r.mu.Lock()
+	err = r.log.Commit(index)
+	r.mu.Unlock()
+
+	if err != nil {
+		return err
+	}
+
+	log.Trace().Uint64("commit_index", index).Msg("entries committed")
+	return r.apply()
+}
+
+// Apply all committed entries that have not been applied to the state machine in index
+// order, notifying pending proposals of the result of each entry. The last applied index
+// is recorded in the log meta once all of the entries have been applied. When the leader
+// applies a joint configuration it appends the final configuration, and if the leader is
+// not a member of the final configuration it steps down once it has been applied.
+//
+// NOTE: This method is not thread-safe and should only be called from the event loop.
+func (r *Replica) apply() (err error) {
+	lastApplied := r.log.LastApplied()
+	if lastApplied >= r.log.CommitIndex() {
+		return nil
+	}
+
+	var leftJoint bool
+
+	for index := lastApplied + 1; index <= r.log.CommitIndex(); index++ {
+		var entry *raft.LogEntry
+		if entry, err = r.log.Get(index); err != nil {
+			return err
+		}
+
+		var result interface{}
+		if r.fsm != nil {
+			if result, err = r.fsm.Apply(entry); err != nil {
+				return fmt.Errorf("could not apply entry %d: %w", index, err)
+			}
+		}
+
+		if entry.Name == Config && r.state == Leader && entry.Index == r.configIndex && len(r.config.Next) > 0 {
+			if err = r.leaveJoint(); err != nil {
+				return err
+			}
+			leftJoint = true
+		}
+
+		r.notifyApplied(entry, result)
+		lastApplied = index
+	}
+
+	r.mu.Lock()
+	err = r.log.Applied(lastApplied)
+	r.mu.Unlock()
+
+	if err != nil {
+		return err
+	}
+
+	log.Trace().Uint64("last_applied", lastApplied).Msg("entries applied")
+
+	// Replicate the final configuration once all committed entries have been applied.
+	if leftJoint {
+		if err = r.replicate(); err != nil {
+			return err
+		}
+	}
+
+	if r.state == Leader && !r.quorum.Contains(r.name) && r.configIndex <= r.log.LastApplied() {
+		log.Info().Uint64("term", r.term).Msg("leader removed from the quorum, stepping down")
+		if err = r.setState(Follower); err != nil {
+			return err
+		}
+	}
+
+	r.serveReads()
+	r.maybeSnapshot()
+	return nil
+}
+
+//===========================================================================
+// Event Value Helpers
+//===========================================================================
+
+func voteRequest(e events.Event) (req *raft.VoteRequest, reply chan<- *raft.VoteReply, err error) {
+	var (
+		msg *events.Message
+		ok  bool
+	)
+
+	if msg, ok = e.(*events.Message); !ok {
+		return nil, nil, ErrEventTypeError
+	}
+
+	if req, ok = msg.Value.(*raft.VoteRequest); !ok {
+		return nil, nil, ErrEventTypeError
+	}
+
+	if reply, ok = msg.Source.(chan *raft.VoteReply); !ok {
+		return nil, nil, ErrEventSourceError
+	}
+	return req, reply, nil
+}
+
+func voteReply(e events.Event) (reply *raft.VoteReply, err error) {
+	var (
+		msg *events.Message
+		ok  bool
+	)
+
+	if msg, ok = e.(*events.Message); !ok {
+		return nil, ErrEventTypeError
+	}
+
+	if reply, ok = msg.Value.(*raft.VoteReply); !ok {
+		return nil, ErrEventTypeError
+	}
+	return reply, nil
+}
+
+func appendRequest(e events.Event) (req *raft.AppendRequest, reply chan<- *raft.AppendReply, err error) {
+	var (
+		msg *events.Message
+		ok  bool
+	)
+
+	if msg, ok = e.(*events.Message); !ok {
+		return nil, nil, ErrEventTypeError
+	}
+
+	if req, ok = msg.Value.(*raft.AppendRequest); !ok {
+		return nil, nil, ErrEventTypeError
+	}
+
+	if reply, ok = msg.Source.(chan *raft.AppendReply); !ok {
+		return nil, nil, ErrEventSourceError
+	}
+	return req, reply, nil
+}
+
+func writeAhead(e events.Event) (entry *raft.LogEntry, reply chan<- *proposal, err error) {
+	var (
+		msg *events.Message
+		ok  bool
+	)
+
+	if msg, ok = e.(*events.Message); !ok {
+		return nil, nil, ErrEventTypeError
+	}
+
+	if entry, ok = msg.Value.(*raft.LogEntry); !ok {
+		return nil, nil, ErrEventTypeError
+	}
+
+	if reply, ok = msg.Source.(chan *proposal); !ok {
+		return nil, nil, ErrEventSourceError
+	}
+	return entry, reply, nil
+}
+
+func appendReply(e events.Event) (rep *appendResponse, err error) {
+	var (
+		msg *events.Message
+		ok  bool
+	)
+
+	if msg, ok = e.(*events.Message); !ok {
+		return nil, ErrEventTypeError
+	}
+
+	if rep, ok = msg.Value.(*appendResponse); !ok {
+		return nil, ErrEventTypeError
+	}
+	return rep, nil
+}
+
+func readIndex(e events.Event) (lease bool, reply chan<- error, err error) {
+	var (
+		msg *events.Message
+		ok  bool
+	)
+
+	if msg, ok = e.(*events.Message); !ok {
+		return false, nil, ErrEventTypeError
+	}
+
+	if lease, ok = msg.Value.(bool); !ok {
+		return false, nil, ErrEventTypeError
+	}
+
+	if reply, ok = msg.Source.(chan error); !ok {
+		return false, nil, ErrEventSourceError
+	}
+	return lease, reply, nil
+}
+
+func snapshotEvent(e events.Event) (taken *snapshotTaken, err error) {
+	var (
+		msg *events.Message
+		ok  bool
+	)
+
+	if msg, ok = e.(*events.Message); !ok {
+		return nil, ErrEventTypeError
+	}
+
+	if taken, ok = msg.Value.(*snapshotTaken); !ok {
+		return nil, ErrEventTypeError
+	}
+	return taken, nil
+}
@@ -0,0 +1,455 @@
+package replica
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"time"
+
+	"github.com/bbengfort/otterdb/pkg/replica/events"
+	"github.com/bbengfort/otterdb/pkg/replica/peers"
+	"github.com/bbengfort/otterdb/pkg/replica/raft/v1"
+	"github.com/bbengfort/otterdb/pkg/replica/snapshot"
+
+	"github.com/rs/zerolog/log"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// The maximum size of the data in each chunk of a snapshot sent to a follower.
+	snapshotChunkSize = 1024 * 1024
+
+	// The maximum amount of time to send a snapshot to a follower; snapshots may be
+	// much larger than append entries requests so the request timeout is not used.
+	installTimeout = 10 * time.Minute
+)
+
+// Chunks of a snapshot are checksummed with CRC-32 using the Castagnoli polynomial.
+var castagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+//===========================================================================
+// Raft Server RPCs
+//===========================================================================
+
+// InstallSnapshot is called by the leader to send a snapshot to a follower that needs
+// entries that the leader has compacted from its log. The snapshot is streamed in
+// chunks that are verified and written to a temporary file; once the last chunk is
+// received the snapshot is dispatched to the event loop, which replaces the state
+// machine with the snapshot and resets the log to follow it.
+func (r *Replica) InstallSnapshot(stream raft.Raft_InstallSnapshotServer) (err error) {
+	var chunk *raft.SnapshotChunk
+	if chunk, err = stream.Recv(); err != nil {
+		if errors.Is(err, io.EOF) {
+			return status.Error(codes.InvalidArgument, "no snapshot was sent")
+		}
+		return err
+	}
+
+	if chunk.Meta == nil {
+		return status.Error(codes.InvalidArgument, "first chunk of the snapshot must include its meta")
+	}
+
+	if err = r.checkCluster(chunk.Leader, chunk.ClusterID); err != nil {
+		return err
+	}
+
+	if err = r.checkRemote(stream.Context(), chunk.Leader); err != nil {
+		return err
+	}
+
+	// Reject snapshots from leaders of previous terms without receiving the snapshot.
+	if term := r.Term(); chunk.Term < term {
+		return stream.SendAndClose(&raft.InstallReply{Remote: r.name, Term: term})
+	}
+
+	if r.snapshots == nil {
+		return status.Error(codes.Unimplemented, "the state machine of the replica does not support snapshots")
+	}
+
+	var path string
+	if path, err = r.snapshots.Temp(); err != nil {
+		log.Error().Err(err).Msg("could not create temporary snapshot file")
+		return status.Error(codes.Internal, "could not receive snapshot")
+	}
+
+	if err = receiveSnapshot(stream, chunk, path); err != nil {
+		os.Remove(path)
+		return err
+	}
+
+	// Once dispatched, the event loop is responsible for the temporary snapshot file.
+	req := &installRequest{term: chunk.Term, leader: chunk.Leader, meta: chunk.Meta, path: path}
+	reply := make(chan *raft.InstallReply, 1)
+	if err = r.Dispatch(&events.Message{Type: events.InstallRequest, Source: reply, Value: req}); err != nil {
+		os.Remove(path)
+		return status.Error(codes.Unavailable, err.Error())
+	}
+
+	select {
+	case out := <-reply:
+		return stream.SendAndClose(out)
+	case <-stream.Context().Done():
+		return status.FromContextError(stream.Context().Err()).Err()
+	}
+}
+
+// Write the chunks of a snapshot to the file at the path, starting with the first chunk
+// that was already received, verifying that the chunks are in order and are not
+// corrupted. The file is synced to disk once the last chunk has been written.
+func receiveSnapshot(stream raft.Raft_InstallSnapshotServer, chunk *raft.SnapshotChunk, path string) (err error) {
+	var f *os.File
+	if f, err = os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644); err != nil {
+		log.Error().Err(err).Str("path", path).Msg("could not open temporary snapshot file")
+		return status.Error(codes.Internal, "could not receive snapshot")
+	}
+	defer f.Close()
+
+	var offset uint64
+	for {
+		if chunk.Offset != offset {
+			return status.Errorf(codes.InvalidArgument, "expected chunk at offset %d but received offset %d", offset, chunk.Offset)
+		}
+
+		if crc32.Checksum(chunk.Data, castagnoli) != chunk.Checksum {
+			return status.Errorf(codes.DataLoss, "checksum mismatch for chunk at offset %d", chunk.Offset)
+		}
+
+		if _, err = f.Write(chunk.Data); err != nil {
+			log.Error().Err(err).Str("path", path).Msg("could not write snapshot chunk")
+			return status.Error(codes.Internal, "could not receive snapshot")
+		}
+		offset += uint64(len(chunk.Data))
+
+		if chunk.Done {
+			break
+		}
+
+		if chunk, err = stream.Recv(); err != nil {
+			if errors.Is(err, io.EOF) {
+				return status.Error(codes.InvalidArgument, "snapshot stream closed before the last chunk")
+			}
+			return err
+		}
+	}
+
+	if err = f.Sync(); err != nil {
+		log.Error().Err(err).Str("path", path).Msg("could not sync snapshot")
+		return status.Error(codes.Internal, "could not receive snapshot")
+	}
+	return nil
+}
+
+//===========================================================================
+// Raft Client Snapshots
+//===========================================================================
+
+// Send the latest snapshot to a peer that needs entries that have been compacted from
+// the log. The snapshot is streamed in the background and the reply is dispatched to
+// the event loop; only one snapshot is sent to a peer at a time.
+//
+// NOTE: This method is not thread-safe and should only be called from the event loop.
+func (r *Replica) sendSnapshot(peer *peers.Peer) {
+	if r.installing[peer.Name] {
+		return
+	}
+
+	if r.snapshots == nil {
+		log.Error().Str("peer", peer.Name).Msg("peer is behind the compacted log but there are no snapshots")
+		return
+	}
+
+	latest, err := r.snapshots.Latest()
+	if err != nil {
+		log.Error().Err(err).Str("peer", peer.Name).Msg("could not load latest snapshot for peer")
+		return
+	}
+
+	r.installing[peer.Name] = true
+	first := &raft.SnapshotChunk{Term: r.term, Leader: r.name, Meta: latest.Meta, ClusterID: r.clusterID}
+	log.Info().Str("peer", peer.Name).Uint64("index", latest.Meta.LastApplied).Msg("sending snapshot to peer")
+
+	go func() {
+		rep := &installResponse{peer: peer.Name, meta: latest.Meta, sent: time.Now()}
+		rep.reply, rep.err = streamSnapshot(peer, latest.Path, first)
+		r.Dispatch(&events.Message{Type: events.InstallReply, Value: rep})
+	}()
+}
+
+// Stream the snapshot file at the path to the peer in checksummed chunks, starting with
+// the first chunk that identifies the leader and the snapshot.
+func streamSnapshot(peer *peers.Peer, path string, chunk *raft.SnapshotChunk) (_ *raft.InstallReply, err error) {
+	var f *os.File
+	if f, err = os.Open(path); err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), installTimeout)
+	defer cancel()
+
+	var stream raft.Raft_InstallSnapshotClient
+	if stream, err = peer.InstallSnapshot(ctx); err != nil {
+		return nil, err
+	}
+
+	var (
+		n      int
+		offset uint64
+		buf    = make([]byte, snapshotChunkSize)
+	)
+
+	for {
+		if n, err = io.ReadFull(f, buf); err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+			return nil, err
+		}
+
+		chunk.Offset = offset
+		chunk.Data = buf[:n]
+		chunk.Checksum = crc32.Checksum(chunk.Data, castagnoli)
+		chunk.Done = err != nil
+
+		if err = stream.Send(chunk); err != nil {
+			// The follower closed the stream early; its status is returned on receive.
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, err
+		}
+
+		if chunk.Done {
+			break
+		}
+
+		offset += uint64(n)
+		chunk = &raft.SnapshotChunk{}
+	}
+
+	return stream.CloseAndRecv()
+}
+
+//===========================================================================
+// Event Handlers
+//===========================================================================
+
+// A snapshot received from the leader that is waiting to be installed.
+type installRequest struct {
+	term   uint64
+	leader string
+	meta   *raft.LogMeta
+	path   string
+}
+
+// The outcome of sending a snapshot to a peer along with the time that the snapshot was
+// sent; the peer acknowledged the leadership of the local replica no earlier than the
+// send time.
+type installResponse struct {
+	peer  string
+	meta  *raft.LogMeta
+	reply *raft.InstallReply
+	sent  time.Time
+	err   error
+}
+
+// Accept the remote as the leader if it is in the current term and install the
+// snapshot: the snapshot is saved to the snapshot store, the state machine is replaced
+// with the snapshot and the log is reset to follow the last entry of the snapshot. If
+// the state machine has already applied the entries in the snapshot it is discarded.
+//
+// NOTE: This method is not thread-safe and should only be called from the event loop.
+func (r *Replica) onInstallRequest(e events.Event) (err error) {
+	var (
+		req   *installRequest
+		reply chan<- *raft.InstallReply
+	)
+
+	if req, reply, err = installEvent(e); err != nil {
+		return err
+	}
+
+	// If the leader is in a later term, step down and move into that term.
+	if req.term > r.term {
+		if err = r.setTerm(req.term); err != nil {
+			return err
+		}
+		if err = r.setState(Follower); err != nil {
+			return err
+		}
+	}
+
+	out := &raft.InstallReply{Remote: r.name, Term: r.term, Index: r.log.LastIndex()}
+
+	// Reject snapshots from leaders of previous terms.
+	if req.term < r.term {
+		os.Remove(req.path)
+		reply <- out
+		return nil
+	}
+
+	// A candidate that hears from the leader of its term concedes the election.
+	if r.state == Candidate || r.state == PreCandidate {
+		if err = r.setState(Follower); err != nil {
+			return err
+		}
+	}
+
+	if r.leader != req.leader {
+		r.setLeader(req.leader)
+		log.Info().Uint64("term", r.term).Str("leader", req.leader).Msg("following leader")
+	}
+	r.contact = time.Now()
+	r.resetElectionTimeout()
+
+	// The snapshot is stale if all of its entries have already been applied.
+	index, term := req.meta.LastApplied, req.meta.Term
+	if index <= r.log.LastApplied() {
+		os.Remove(req.path)
+		out.Success = true
+		reply <- out
+		return nil
+	}
+
+	var snap *snapshot.Snapshot
+	if snap, err = r.snapshots.Save(req.path, req.meta); err != nil {
+		os.Remove(req.path)
+		if !errors.Is(err, snapshot.ErrAlreadyExists) {
+			log.Error().Err(err).Uint64("index", index).Msg("could not save snapshot from leader")
+			reply <- out
+			return nil
+		}
+
+		// The snapshot was saved but not installed before the replica was stopped.
+		if snap, err = r.snapshots.Get(index); err != nil {
+			log.Error().Err(err).Uint64("index", index).Msg("could not load snapshot from leader")
+			reply <- out
+			return nil
+		}
+	}
+
+	// The snapshot can no longer be rejected once the state machine is restored.
+	if err = r.fsm.(Snapshotter).Restore(snap.Path); err != nil {
+		return fmt.Errorf("could not restore snapshot at index %d: %w", index, err)
+	}
+
+	if err = r.log.Install(index, term); err != nil {
+		return err
+	}
+
+	// The configuration of the snapshot is used if the log no longer contains one.
+	if err = r.reloadConfig(); err != nil {
+		return err
+	}
+
+	r.snapshotIndex = index
+	r.mu.Lock()
+	err = r.log.Applied(index)
+	r.mu.Unlock()
+
+	if err != nil {
+		return err
+	}
+
+	log.Info().Uint64("index", index).Uint64("term", term).Str("leader", req.leader).Msg("snapshot installed")
+	out.Success = true
+	out.Index = r.log.LastIndex()
+	reply <- out
+	return nil
+}
+
+// Leaders step down if the follower has moved on to a later term, otherwise the
+// progress of the follower is advanced to the last entry of the snapshot and the
+// entries that follow the snapshot are sent to the follower.
+//
+// NOTE: This method is not thread-safe and should only be called from the event loop.
+func (r *Replica) onInstallReply(e events.Event) (err error) {
+	var rep *installResponse
+	if rep, err = installReply(e); err != nil {
+		return err
+	}
+
+	// A snapshot can be sent to the peer again, e.g. on the next heartbeat.
+	delete(r.installing, rep.peer)
+
+	if rep.err != nil {
+		log.Warn().Err(rep.err).Str("peer", rep.peer).Msg("could not send snapshot to peer")
+		return nil
+	}
+
+	if rep.reply.Term > r.term {
+		if err = r.setTerm(rep.reply.Term); err != nil {
+			return err
+		}
+		return r.setState(Follower)
+	}
+
+	// Ignore replies from previous terms or if no longer the leader.
+	if r.state != Leader || rep.reply.Term != r.term {
+		return nil
+	}
+
+	// Any reply in the current term acknowledges the leadership of the local replica.
+	r.ack(rep.peer, rep.sent)
+	r.serveReads()
+
+	if !rep.reply.Success {
+		return nil
+	}
+
+	if rep.meta.LastApplied > r.matchIndex[rep.peer] {
+		r.matchIndex[rep.peer] = rep.meta.LastApplied
+	}
+	r.nextIndex[rep.peer] = r.matchIndex[rep.peer] + 1
+
+	// Immediately send the follower the entries that follow the snapshot.
+	var peer *peers.Peer
+	if peer, err = r.peers.Get(rep.peer); err != nil {
+		log.Debug().Err(err).Msg("install reply from unknown peer")
+		return nil
+	}
+
+	r.sendAppendEntries(peer, false)
+	return nil
+}
+
+//===========================================================================
+// Event Value Helpers
+//===========================================================================
+
+func installEvent(e events.Event) (req *installRequest, reply chan<- *raft.InstallReply, err error) {
+	var (
+		msg *events.Message
+		ok  bool
+	)
+
+	if msg, ok = e.(*events.Message); !ok {
+		return nil, nil, ErrEventTypeError
+	}
+
+	if req, ok = msg.Value.(*installRequest); !ok {
+		return nil, nil, ErrEventTypeError
+	}
+
+	if reply, ok = msg.Source.(chan *raft.InstallReply); !ok {
+		return nil, nil, ErrEventSourceError
+	}
+	return req, reply, nil
+}
+
+func installReply(e events.Event) (rep *installResponse, err error) {
+	var (
+		msg *events.Message
+		ok  bool
+	)
+
+	if msg, ok = e.(*events.Message); !ok {
+		return nil, ErrEventTypeError
+	}
+
+	if rep, ok = msg.Value.(*installResponse); !ok {
+		return nil, ErrEventTypeError
+	}
+	return rep, nil
+}
@@ -0,0 +1,253 @@
+package replica
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/bbengfort/otterdb/pkg/replica/logstore"
+	"github.com/bbengfort/otterdb/pkg/replica/raft/v1"
+)
+
+// The null entry precedes the first entry in the log.
+var nullEntry = &raft.LogEntry{Index: 0, Term: 0}
+
+// Log implements the sequence of commands that are replicated between peers and
+// applied to the state machine once they are committed. Entries are stored in a
+// LogStore so that the log can be durable or in-memory; the log itself tracks the
+// commit and applied indices and enforces the invariants of the raft log. The log has
+// a null entry at index 0 so that the first real entry in the log is at index 1 and so
+// that the previous entry of the first append is always defined.
+//
+// NOTE: the log is not thread-safe and should only be accessed from the event loop.
+type Log struct {
+	lastApplied uint64            // The index of the last entry applied to the state machine
+	commitIndex uint64            // The index of the last committed entry
+	store       logstore.LogStore // The storage for the entries in the log
+}
+
+// NewLog creates a log on top of the specified store, e.g. a durable write-ahead log
+// whose entries were recovered from disk or an empty in-memory store.
+func NewLog(store logstore.LogStore) *Log {
+	return &Log{store: store}
+}
+
+// Close the underlying store of the log.
+func (l *Log) Close() error {
+	return l.store.Close()
+}
+
+//===========================================================================
+// Log Accessors
+//===========================================================================
+
+// FirstIndex returns the index of the first entry in the log; entries before the first
+// index have been compacted into a snapshot.
+func (l *Log) FirstIndex() uint64 {
+	return l.store.FirstIndex()
+}
+
+// LastIndex returns the index of the last entry in the log.
+func (l *Log) LastIndex() uint64 {
+	return l.store.LastIndex()
+}
+
+// LastTerm returns the term of the last entry in the log.
+func (l *Log) LastTerm() uint64 {
+	return l.store.LastTerm()
+}
+
+// CommitIndex returns the index of the last committed entry in the log.
+func (l *Log) CommitIndex() uint64 {
+	return l.commitIndex
+}
+
+// LastApplied returns the index of the last entry applied to the state machine.
+func (l *Log) LastApplied() uint64 {
+	return l.lastApplied
+}
+
+// Get the entry at the specified index; returns an error if the index is not in the log.
+func (l *Log) Get(index uint64) (entry *raft.LogEntry, err error) {
+	// The null entry precedes the first entry so it is compacted along with it.
+	if index == 0 {
+		if l.FirstIndex() > 1 {
+			return nil, logstore.ErrCompacted
+		}
+		return nullEntry, nil
+	}
+
+	if entry, err = l.store.Get(index); err != nil {
+		if errors.Is(err, logstore.ErrNotFound) {
+			return nil, ErrMissingEntry
+		}
+		return nil, err
+	}
+	return entry, nil
+}
+
+// After returns up to limit entries that follow the specified index in the log. If the
+// limit is zero then all of the entries after the index are returned.
+func (l *Log) After(index uint64, limit int) ([]*raft.LogEntry, error) {
+	hi := l.LastIndex() + 1
+	if limit > 0 && index+1+uint64(limit) < hi {
+		hi = index + 1 + uint64(limit)
+	}
+	return l.store.Entries(index+1, hi)
+}
+
+// AsUpToDate returns true if a remote log with the specified last index and term is
+// at least as up to date as the local log. A log is more up to date if its last entry
+// has a later term; if the terms are equal, then the longer log is more up to date.
+func (l *Log) AsUpToDate(lastIndex, lastTerm uint64) bool {
+	localTerm := l.LastTerm()
+	if lastTerm != localTerm {
+		return lastTerm > localTerm
+	}
+	return lastIndex >= l.LastIndex()
+}
+
+// Meta returns the metadata of the replica that was saved with the log.
+func (l *Log) Meta() logstore.LogMeta {
+	return l.store.Meta()
+}
+
+// Matches returns true if the log contains an entry at the specified index whose term
+// matches the specified term; e.g. the log consistency check for append entries.
+// Compacted entries always match since only applied (and therefore committed) entries
+// are compacted and committed entries are in the log of every future leader.
+func (l *Log) Matches(index, term uint64) bool {
+	entry, err := l.Get(index)
+	if err != nil {
+		return errors.Is(err, logstore.ErrCompacted)
+	}
+	return entry.Term == term
+}
+
+//===========================================================================
+// Log Modification
+//===========================================================================
+
+// Append entries to the end of the log. The index of each entry must immediately
+// follow the index of the last entry in the log and entries cannot go back in time.
+func (l *Log) Append(entries ...*raft.LogEntry) (err error) {
+	index, term := l.LastIndex(), l.LastTerm()
+	for _, entry := range entries {
+		if entry.Index != index+1 {
+			return fmt.Errorf("cannot append entry %d after index %d: %w", entry.Index, index, ErrOutOfOrder)
+		}
+
+		if entry.Term < term {
+			return fmt.Errorf("cannot append entry from term %d after term %d: %w", entry.Term, term, ErrOutOfOrder)
+		}
+		index, term = entry.Index, entry.Term
+	}
+	return l.store.Append(entries...)
+}
+
+// Truncate the log by removing all entries after the specified index. Committed
+// entries cannot be removed from the log.
+func (l *Log) Truncate(index uint64) error {
+	if index < l.commitIndex {
+		return ErrCommitIndex
+	}
+
+	if index >= l.LastIndex() {
+		return nil
+	}
+
+	return l.store.TruncateSuffix(index)
+}
+
+// Compact the log by removing all entries before the specified index, e.g. once the
+// entries are included in a snapshot. The entry at the index is kept so that the term
+// of the entry that precedes the remaining entries is known. Only applied entries can
+// be compacted.
+func (l *Log) Compact(index uint64) error {
+	if index > l.lastApplied {
+		return ErrNotApplied
+	}
+
+	if index <= l.FirstIndex() {
+		return nil
+	}
+
+	return l.store.TruncatePrefix(index)
+}
+
+// Install resets the log to follow a snapshot whose last entry has the specified index
+// and term, e.g. when the leader sends a snapshot to a follower that is missing entries
+// that the leader has compacted. If the log contains the last entry of the snapshot, the
+// entries that follow it are kept; otherwise the log is discarded. The last entry of the
+// snapshot becomes the first entry of the log so that its term is known. All entries in
+// the snapshot are committed; the caller must mark them as applied once the snapshot
+// has been restored to the state machine.
+func (l *Log) Install(index, term uint64) (err error) {
+	if index < l.FirstIndex() {
+		return logstore.ErrCompacted
+	}
+
+	if l.Matches(index, term) && index <= l.LastIndex() {
+		if err = l.store.TruncatePrefix(index); err != nil {
+			return err
+		}
+	} else {
+		if index <= l.LastIndex() {
+			if err = l.store.TruncateSuffix(index - 1); err != nil {
+				return err
+			}
+		}
+
+		if err = l.store.TruncatePrefix(index); err != nil {
+			return err
+		}
+
+		if err = l.store.Append(&raft.LogEntry{Index: index, Term: term}); err != nil {
+			return err
+		}
+	}
+
+	if index > l.commitIndex {
+		l.commitIndex = index
+	}
+	return nil
+}
+
+// Commit all entries up to and including the specified index.
+func (l *Log) Commit(index uint64) error {
+	if index < l.commitIndex {
+		return ErrAlreadyCommitted
+	}
+
+	if index > l.LastIndex() {
+		return ErrMissingCommit
+	}
+
+	l.commitIndex = index
+	return nil
+}
+
+// Applied marks all entries up to and including the specified index as applied to the
+// state machine and durably records the last applied index with the log meta.
+func (l *Log) Applied(index uint64) error {
+	if index > l.commitIndex {
+		return ErrNotCommitted
+	}
+
+	meta := l.store.Meta()
+	meta.LastApplied = index
+	if err := l.store.SaveMeta(meta); err != nil {
+		return err
+	}
+
+	l.lastApplied = index
+	return nil
+}
+
+// Restore the applied index from the state machine when the log is opened; entries that
+// have been applied are known to be committed so the commit index is restored as well.
+func (l *Log) Restore(lastApplied uint64) {
+	l.lastApplied = lastApplied
+	if lastApplied <= l.LastIndex() {
+		l.commitIndex = lastApplied
+	}
+}
@@ -0,0 +1,142 @@
+package replica
+
+import (
+	"testing"
+
+	"github.com/bbengfort/otterdb/pkg/replica/logstore"
+	"github.com/bbengfort/otterdb/pkg/replica/raft/v1"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLog(t *testing.T) {
+	log := NewLog(logstore.NewMemory())
+	require.Equal(t, uint64(0), log.LastIndex())
+	require.Equal(t, uint64(0), log.LastTerm())
+	require.Equal(t, uint64(0), log.CommitIndex())
+	require.True(t, log.Matches(0, 0))
+
+	// Cannot append entries out of order
+	require.ErrorIs(t, log.Append(&raft.LogEntry{Index: 2, Term: 1}), ErrOutOfOrder)
+
+	err := log.Append(
+		&raft.LogEntry{Index: 1, Term: 1, Name: "foo"},
+		&raft.LogEntry{Index: 2, Term: 1, Name: "bar"},
+		&raft.LogEntry{Index: 3, Term: 2, Name: "baz"},
+	)
+	require.NoError(t, err, "could not append entries")
+	require.Equal(t, uint64(3), log.LastIndex())
+	require.Equal(t, uint64(2), log.LastTerm())
+
+	// Cannot append entries from a previous term
+	require.ErrorIs(t, log.Append(&raft.LogEntry{Index: 4, Term: 1}), ErrOutOfOrder)
+
+	entry, err := log.Get(2)
+	require.NoError(t, err)
+	require.Equal(t, "bar", entry.Name)
+
+	_, err = log.Get(4)
+	require.ErrorIs(t, err, ErrMissingEntry)
+
+	require.True(t, log.Matches(3, 2))
+	require.False(t, log.Matches(3, 1))
+	require.False(t, log.Matches(4, 2))
+
+	requireAfter(t, log, 0, 0, 3)
+	requireAfter(t, log, 0, 2, 2)
+	requireAfter(t, log, 2, 0, 1)
+	requireAfter(t, log, 3, 0, 0)
+
+	require.True(t, log.AsUpToDate(3, 2))
+	require.True(t, log.AsUpToDate(1, 3))
+	require.False(t, log.AsUpToDate(2, 2))
+	require.False(t, log.AsUpToDate(8, 1))
+
+	// Test commit
+	require.ErrorIs(t, log.Commit(4), ErrMissingCommit)
+	require.NoError(t, log.Commit(2))
+	require.Equal(t, uint64(2), log.CommitIndex())
+	require.ErrorIs(t, log.Commit(1), ErrAlreadyCommitted)
+
+	// Cannot truncate committed entries
+	require.ErrorIs(t, log.Truncate(1), ErrCommitIndex)
+	require.NoError(t, log.Truncate(2))
+	require.Equal(t, uint64(2), log.LastIndex())
+	require.Equal(t, uint64(1), log.LastTerm())
+}
+
+func TestLogCompact(t *testing.T) {
+	log := NewLog(logstore.NewMemory())
+	for i := uint64(1); i <= 10; i++ {
+		require.NoError(t, log.Append(&raft.LogEntry{Index: i, Term: 1}))
+	}
+
+	require.NoError(t, log.Commit(8))
+	require.NoError(t, log.Applied(6))
+
+	// Only applied entries can be compacted
+	require.ErrorIs(t, log.Compact(7), ErrNotApplied)
+	require.NoError(t, log.Compact(5))
+	require.Equal(t, uint64(5), log.FirstIndex())
+	require.Equal(t, uint64(10), log.LastIndex())
+
+	// The entry at the compaction index is kept
+	entry, err := log.Get(5)
+	require.NoError(t, err)
+	require.Equal(t, uint64(5), entry.Index)
+
+	_, err = log.Get(4)
+	require.ErrorIs(t, err, logstore.ErrCompacted)
+
+	_, err = log.Get(0)
+	require.ErrorIs(t, err, logstore.ErrCompacted)
+
+	// Compacted entries match any term since they were committed
+	require.True(t, log.Matches(4, 3))
+	require.True(t, log.Matches(5, 1))
+	require.False(t, log.Matches(5, 2))
+
+	// Compacting behind the first index does nothing
+	require.NoError(t, log.Compact(3))
+	require.Equal(t, uint64(5), log.FirstIndex())
+}
+
+func TestLogInstall(t *testing.T) {
+	log := NewLog(logstore.NewMemory())
+	for i := uint64(1); i <= 10; i++ {
+		require.NoError(t, log.Append(&raft.LogEntry{Index: i, Term: 1}))
+	}
+	require.NoError(t, log.Commit(4))
+
+	// If the log contains the last entry of the snapshot, the entries after it are kept
+	require.NoError(t, log.Install(6, 1))
+	require.Equal(t, uint64(6), log.FirstIndex())
+	require.Equal(t, uint64(10), log.LastIndex())
+	require.Equal(t, uint64(6), log.CommitIndex())
+	require.NoError(t, log.Applied(6))
+
+	// Snapshots behind the first index cannot be installed
+	require.ErrorIs(t, log.Install(5, 1), logstore.ErrCompacted)
+
+	// If the last entry of the snapshot conflicts, the log is discarded
+	require.NoError(t, log.Install(8, 2))
+	require.Equal(t, uint64(8), log.FirstIndex())
+	require.Equal(t, uint64(8), log.LastIndex())
+	require.Equal(t, uint64(2), log.LastTerm())
+	require.Equal(t, uint64(8), log.CommitIndex())
+
+	// If the snapshot is past the end of the log, the log is discarded
+	require.NoError(t, log.Install(20, 3))
+	require.Equal(t, uint64(20), log.FirstIndex())
+	require.Equal(t, uint64(20), log.LastIndex())
+	require.Equal(t, uint64(3), log.LastTerm())
+
+	// Entries are appended after the snapshot
+	require.NoError(t, log.Append(&raft.LogEntry{Index: 21, Term: 3}))
+	require.True(t, log.Matches(20, 3))
+}
+
+func requireAfter(t *testing.T, log *Log, index uint64, limit, expected int) {
+	entries, err := log.After(index, limit)
+	require.NoError(t, err)
+	require.Len(t, entries, expected)
+}
@@ -0,0 +1,10 @@
+package logstore
+
+import "errors"
+
+var (
+	ErrClosed     = errors.New("log store is closed")
+	ErrNotFound   = errors.New("no entry exists in the log store at the specified index")
+	ErrCompacted  = errors.New("entry has been removed from the log store by compaction")
+	ErrOutOfOrder = errors.New("entries must be appended to the log store in order")
+)
@@ -0,0 +1,59 @@
+/*
+Package logstore defines the storage interface for the entries of the replicated log so
+that the consensus algorithm can be run against an in-memory store for testing or a
+durable store on disk in production.
+*/
+package logstore
+
+import "github.com/bbengfort/otterdb/pkg/replica/raft/v1"
+
+// LogStore stores the entries of the raft log along with the metadata that must be
+// persisted with the log (e.g. the last applied index of the replica). Entries are
+// contiguous from the first index to the last index of the store; a new store is empty
+// and the first entry appended to it must have index 1. Implementations must be safe
+// for concurrent use and must not acknowledge a write until it is durable.
+type LogStore interface {
+	// Append entries to the end of the store; the index of the first entry must directly
+	// follow the last index of the store and the entries must be contiguous.
+	Append(entries ...*raft.LogEntry) error
+
+	// Get the entry at the specified index; returns ErrNotFound if the index is after the
+	// last index and ErrCompacted if the index is before the first index.
+	Get(index uint64) (*raft.LogEntry, error)
+
+	// Entries returns the entries in the range [lo, hi); if hi is after the last index
+	// then all of the entries from lo to the end of the store are returned.
+	Entries(lo, hi uint64) ([]*raft.LogEntry, error)
+
+	// TruncateSuffix removes all entries after the specified index from the store.
+	TruncateSuffix(index uint64) error
+
+	// TruncatePrefix removes all entries before the specified index from the store so
+	// that the index becomes the first index. If the index is after the last index, the
+	// store becomes empty and the next appended entry must have the specified index.
+	TruncatePrefix(index uint64) error
+
+	// FirstIndex returns the index of the first entry in the store.
+	FirstIndex() uint64
+
+	// LastIndex returns the index of the last entry in the store or the index before
+	// the first index if the store is empty.
+	LastIndex() uint64
+
+	// LastTerm returns the term of the last entry in the store or zero if it is empty.
+	LastTerm() uint64
+
+	// Meta returns the metadata that was most recently saved to the store.
+	Meta() LogMeta
+
+	// SaveMeta durably stores the metadata.
+	SaveMeta(LogMeta) error
+
+	// Close the store; it cannot be used after it has been closed.
+	Close() error
+}
+
+// LogMeta is the persistent state of a replica that must be stored with the log.
+type LogMeta struct {
+	LastApplied uint64 // The index of the last entry applied to the state machine
+}
@@ -0,0 +1,216 @@
+package logstore_test
+
+import (
+	"testing"
+
+	"github.com/bbengfort/otterdb/pkg/replica/logstore"
+	"github.com/bbengfort/otterdb/pkg/replica/raft/v1"
+	"github.com/bbengfort/otterdb/pkg/replica/wal"
+	"github.com/stretchr/testify/require"
+)
+
+// Every LogStore implementation must pass the conformance tests.
+var stores = map[string]func(t *testing.T) logstore.LogStore{
+	"memory": func(t *testing.T) logstore.LogStore {
+		return logstore.NewMemory()
+	},
+	"wal": func(t *testing.T) logstore.LogStore {
+		store, err := wal.Open(t.TempDir(), wal.WithSegmentSize(512))
+		require.NoError(t, err, "could not open wal")
+		return store
+	},
+}
+
+func TestConformance(t *testing.T) {
+	tests := []struct {
+		name string
+		test func(t *testing.T, store logstore.LogStore)
+	}{
+		{"Empty", testEmpty},
+		{"Append", testAppend},
+		{"Entries", testEntries},
+		{"TruncateSuffix", testTruncateSuffix},
+		{"TruncatePrefix", testTruncatePrefix},
+		{"Meta", testMeta},
+		{"Close", testClose},
+	}
+
+	for name, open := range stores {
+		t.Run(name, func(t *testing.T) {
+			for _, tc := range tests {
+				t.Run(tc.name, func(t *testing.T) {
+					store := open(t)
+					t.Cleanup(func() { store.Close() })
+					tc.test(t, store)
+				})
+			}
+		})
+	}
+}
+
+func testEmpty(t *testing.T, store logstore.LogStore) {
+	require.Equal(t, uint64(1), store.FirstIndex())
+	require.Equal(t, uint64(0), store.LastIndex())
+	require.Equal(t, uint64(0), store.LastTerm())
+	require.Equal(t, logstore.LogMeta{}, store.Meta())
+
+	_, err := store.Get(1)
+	require.ErrorIs(t, err, logstore.ErrNotFound)
+
+	entries, err := store.Entries(1, 10)
+	require.NoError(t, err)
+	require.Empty(t, entries)
+}
+
+func testAppend(t *testing.T, store logstore.LogStore) {
+	require.NoError(t, store.Append(makeEntries(1, 10, 1)...))
+	require.NoError(t, store.Append(makeEntries(11, 15, 2)...))
+	require.Equal(t, uint64(1), store.FirstIndex())
+	require.Equal(t, uint64(15), store.LastIndex())
+	require.Equal(t, uint64(2), store.LastTerm())
+
+	entry, err := store.Get(11)
+	require.NoError(t, err)
+	require.Equal(t, uint64(11), entry.Index)
+	require.Equal(t, uint64(2), entry.Term)
+
+	_, err = store.Get(16)
+	require.ErrorIs(t, err, logstore.ErrNotFound)
+
+	// Entries must be appended contiguously
+	require.ErrorIs(t, store.Append(makeEntries(17, 18, 2)...), logstore.ErrOutOfOrder)
+	require.ErrorIs(t, store.Append(makeEntries(15, 16, 2)...), logstore.ErrOutOfOrder)
+	require.Equal(t, uint64(15), store.LastIndex())
+}
+
+func testEntries(t *testing.T, store logstore.LogStore) {
+	require.NoError(t, store.Append(makeEntries(1, 20, 1)...))
+
+	entries, err := store.Entries(5, 10)
+	require.NoError(t, err)
+	requireIndices(t, entries, 5, 9)
+
+	// The range is capped at the end of the store
+	entries, err = store.Entries(15, 100)
+	require.NoError(t, err)
+	requireIndices(t, entries, 15, 20)
+
+	entries, err = store.Entries(21, 30)
+	require.NoError(t, err)
+	require.Empty(t, entries)
+
+	// Modifying the returned slice must not modify the store
+	entries, err = store.Entries(1, 3)
+	require.NoError(t, err)
+	entries[0] = nil
+	entry, err := store.Get(1)
+	require.NoError(t, err)
+	require.NotNil(t, entry)
+}
+
+func testTruncateSuffix(t *testing.T, store logstore.LogStore) {
+	require.NoError(t, store.Append(makeEntries(1, 20, 1)...))
+
+	// Truncating at or after the last index is a no-op
+	require.NoError(t, store.TruncateSuffix(20))
+	require.NoError(t, store.TruncateSuffix(42))
+	require.Equal(t, uint64(20), store.LastIndex())
+
+	require.NoError(t, store.TruncateSuffix(8))
+	require.Equal(t, uint64(8), store.LastIndex())
+	_, err := store.Get(9)
+	require.ErrorIs(t, err, logstore.ErrNotFound)
+
+	// Entries from a later term can be appended after the truncation
+	require.NoError(t, store.Append(makeEntries(9, 12, 3)...))
+	require.Equal(t, uint64(3), store.LastTerm())
+
+	// Truncating everything leaves an empty store
+	require.NoError(t, store.TruncateSuffix(0))
+	require.Equal(t, uint64(0), store.LastIndex())
+	require.Equal(t, uint64(0), store.LastTerm())
+	require.NoError(t, store.Append(makeEntries(1, 2, 4)...))
+}
+
+func testTruncatePrefix(t *testing.T, store logstore.LogStore) {
+	require.NoError(t, store.Append(makeEntries(1, 40, 1)...))
+
+	// Truncating at or before the first index is a no-op
+	require.NoError(t, store.TruncatePrefix(1))
+	require.Equal(t, uint64(1), store.FirstIndex())
+
+	require.NoError(t, store.TruncatePrefix(25))
+	require.Equal(t, uint64(25), store.FirstIndex())
+	require.Equal(t, uint64(40), store.LastIndex())
+
+	_, err := store.Get(24)
+	require.ErrorIs(t, err, logstore.ErrCompacted)
+
+	_, err = store.Entries(20, 30)
+	require.ErrorIs(t, err, logstore.ErrCompacted)
+
+	entries, err := store.Entries(25, 41)
+	require.NoError(t, err)
+	requireIndices(t, entries, 25, 40)
+
+	// Cannot truncate the suffix before the first index
+	require.ErrorIs(t, store.TruncateSuffix(10), logstore.ErrCompacted)
+
+	// Truncating past the end of the store leaves an empty store at the index
+	require.NoError(t, store.TruncatePrefix(51))
+	require.Equal(t, uint64(51), store.FirstIndex())
+	require.Equal(t, uint64(50), store.LastIndex())
+	require.Equal(t, uint64(0), store.LastTerm())
+
+	require.ErrorIs(t, store.Append(makeEntries(41, 41, 1)...), logstore.ErrOutOfOrder)
+	require.NoError(t, store.Append(makeEntries(51, 55, 2)...))
+	requireIndices(t, mustEntries(t, store, 51, 56), 51, 55)
+}
+
+func testMeta(t *testing.T, store logstore.LogStore) {
+	meta := logstore.LogMeta{LastApplied: 42}
+	require.NoError(t, store.SaveMeta(meta))
+	require.Equal(t, meta, store.Meta())
+
+	// Meta is not affected by modifications to the entries
+	require.NoError(t, store.Append(makeEntries(1, 10, 42)...))
+	require.NoError(t, store.TruncateSuffix(5))
+	require.NoError(t, store.TruncatePrefix(3))
+	require.Equal(t, meta, store.Meta())
+
+	meta = logstore.LogMeta{LastApplied: 43}
+	require.NoError(t, store.SaveMeta(meta))
+	require.Equal(t, meta, store.Meta())
+}
+
+func testClose(t *testing.T, store logstore.LogStore) {
+	require.NoError(t, store.Append(makeEntries(1, 10, 1)...))
+	require.NoError(t, store.Close())
+	require.ErrorIs(t, store.Close(), logstore.ErrClosed)
+	require.ErrorIs(t, store.Append(makeEntries(11, 11, 1)...), logstore.ErrClosed)
+	require.ErrorIs(t, store.SaveMeta(logstore.LogMeta{LastApplied: 2}), logstore.ErrClosed)
+	require.ErrorIs(t, store.TruncateSuffix(5), logstore.ErrClosed)
+	require.ErrorIs(t, store.TruncatePrefix(5), logstore.ErrClosed)
+}
+
+// Create entries from start to end inclusive in the specified term.
+func makeEntries(start, end, term uint64) []*raft.LogEntry {
+	out := make([]*raft.LogEntry, 0, end-start+1)
+	for i := start; i <= end; i++ {
+		out = append(out, &raft.LogEntry{Index: i, Term: term, Name: "put", Value: []byte("otters are cute")})
+	}
+	return out
+}
+
+func mustEntries(t *testing.T, store logstore.LogStore, lo, hi uint64) []*raft.LogEntry {
+	entries, err := store.Entries(lo, hi)
+	require.NoError(t, err)
+	return entries
+}
+
+func requireIndices(t *testing.T, entries []*raft.LogEntry, start, end uint64) {
+	require.Len(t, entries, int(end-start+1))
+	for i, entry := range entries {
+		require.Equal(t, start+uint64(i), entry.Index)
+	}
+}
@@ -0,0 +1,171 @@
+package logstore
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/bbengfort/otterdb/pkg/replica/raft/v1"
+)
+
+// Memory is a LogStore that keeps all entries in memory and is primarily used to test
+// the consensus algorithm without touching disk. Nothing is durable.
+type Memory struct {
+	sync.RWMutex
+	first   uint64
+	entries []*raft.LogEntry
+	meta    LogMeta
+	closed  bool
+}
+
+var _ LogStore = &Memory{}
+
+// NewMemory returns an empty in-memory log store.
+func NewMemory() *Memory {
+	return &Memory{first: 1}
+}
+
+func (m *Memory) Append(entries ...*raft.LogEntry) error {
+	m.Lock()
+	defer m.Unlock()
+
+	if m.closed {
+		return ErrClosed
+	}
+
+	last := m.lastIndex()
+	for _, entry := range entries {
+		if entry.Index != last+1 {
+			return fmt.Errorf("cannot append entry %d after %d: %w", entry.Index, last, ErrOutOfOrder)
+		}
+		last = entry.Index
+	}
+
+	m.entries = append(m.entries, entries...)
+	return nil
+}
+
+func (m *Memory) Get(index uint64) (*raft.LogEntry, error) {
+	m.RLock()
+	defer m.RUnlock()
+
+	if index < m.first {
+		return nil, ErrCompacted
+	}
+
+	if index > m.lastIndex() {
+		return nil, ErrNotFound
+	}
+	return m.entries[index-m.first], nil
+}
+
+func (m *Memory) Entries(lo, hi uint64) ([]*raft.LogEntry, error) {
+	m.RLock()
+	defer m.RUnlock()
+	return Slice(m.entries, m.first, lo, hi)
+}
+
+func (m *Memory) TruncateSuffix(index uint64) error {
+	m.Lock()
+	defer m.Unlock()
+
+	if m.closed {
+		return ErrClosed
+	}
+
+	if index >= m.lastIndex() {
+		return nil
+	}
+
+	if index+1 < m.first {
+		return ErrCompacted
+	}
+
+	// Clear the pointers to the truncated entries so they can be garbage collected.
+	keep := index + 1 - m.first
+	for i := keep; i < uint64(len(m.entries)); i++ {
+		m.entries[i] = nil
+	}
+	m.entries = m.entries[:keep]
+	return nil
+}
+
+func (m *Memory) TruncatePrefix(index uint64) error {
+	m.Lock()
+	defer m.Unlock()
+
+	if m.closed {
+		return ErrClosed
+	}
+
+	if index <= m.first {
+		return nil
+	}
+
+	if index > m.lastIndex() {
+		m.entries = nil
+	} else {
+		// Copy the remaining entries so the removed entries can be garbage collected.
+		entries := make([]*raft.LogEntry, m.lastIndex()-index+1)
+		copy(entries, m.entries[index-m.first:])
+		m.entries = entries
+	}
+
+	m.first = index
+	return nil
+}
+
+func (m *Memory) FirstIndex() uint64 {
+	m.RLock()
+	defer m.RUnlock()
+	return m.first
+}
+
+func (m *Memory) LastIndex() uint64 {
+	m.RLock()
+	defer m.RUnlock()
+	return m.lastIndex()
+}
+
+func (m *Memory) lastIndex() uint64 {
+	return m.first + uint64(len(m.entries)) - 1
+}
+
+func (m *Memory) LastTerm() uint64 {
+	m.RLock()
+	defer m.RUnlock()
+
+	if len(m.entries) == 0 {
+		return 0
+	}
+	return m.entries[len(m.entries)-1].Term
+}
+
+func (m *Memory) Meta() LogMeta {
+	m.RLock()
+	defer m.RUnlock()
+	return m.meta
+}
+
+func (m *Memory) SaveMeta(meta LogMeta) error {
+	m.Lock()
+	defer m.Unlock()
+
+	if m.closed {
+		return ErrClosed
+	}
+
+	m.meta = meta
+	return nil
+}
+
+func (m *Memory) Close() error {
+	m.Lock()
+	defer m.Unlock()
+
+	if m.closed {
+		return ErrClosed
+	}
+
+	m.closed = true
+	return nil
+}
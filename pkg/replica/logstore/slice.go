@@ -0,0 +1,25 @@
+package logstore
+
+import "github.com/bbengfort/otterdb/pkg/replica/raft/v1"
+
+// Slice returns a copy of the entries in the range [lo, hi) from a contiguous slice of
+// entries whose first entry has the specified index. Stores that cache their entries in
+// memory can use this helper to implement Entries.
+func Slice(entries []*raft.LogEntry, first, lo, hi uint64) ([]*raft.LogEntry, error) {
+	if lo < first {
+		return nil, ErrCompacted
+	}
+
+	last := first + uint64(len(entries))
+	if hi > last {
+		hi = last
+	}
+
+	if lo >= hi {
+		return nil, nil
+	}
+
+	out := make([]*raft.LogEntry, hi-lo)
+	copy(out, entries[lo-first:hi-first])
+	return out, nil
+}
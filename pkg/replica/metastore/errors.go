@@ -0,0 +1,8 @@
+package metastore
+
+import "errors"
+
+var (
+	ErrCorrupt = errors.New("replica metadata is corrupt")
+	ErrClosed  = errors.New("replica metadata store is closed")
+)
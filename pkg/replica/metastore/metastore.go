@@ -0,0 +1,212 @@
+/*
+Package metastore durably stores the small amount of metadata that a replica must not
+lose when it crashes: the current term, the vote in the current term, the quorum id
+sequence, and the cluster id. The metadata is written to a single file that is replaced
+atomically on every save: the new metadata is written to a temporary file that is
+synced to disk and then renamed over the previous file, so that the file always contains
+either the previous or the new metadata, never a partial write. The metadata is stored
+with a checksum so that a corrupt file is detected when the store is opened rather than
+silently resetting the term or the vote of the replica.
+*/
+package metastore
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+const (
+	headerSize = 8
+	tempExt    = ".tmp"
+)
+
+var crcTable = crc32.MakeTable(crc32.Castagnoli)
+
+// State is the metadata of a replica that must survive a restart.
+type State struct {
+	Term      uint64 // The latest term the replica has seen
+	VotedFor  string // The candidate the replica voted for in the current term, if any
+	QuorumID  uint64 // The id of the most recently created quorum
+	ClusterID string // The unique id of the cluster the replica belongs to
+}
+
+// Store persists the replica state to a file on disk and caches the latest state in
+// memory. The Store is thread-safe.
+type Store struct {
+	sync.RWMutex
+	path   string
+	state  State
+	closed bool
+}
+
+// Open the metadata store at the specified path, creating the parent directory if it
+// does not exist. If there is no file at the path the store starts with an empty state;
+// a temporary file left behind by a crash during a save is removed.
+func Open(path string) (s *Store, err error) {
+	s = &Store{path: path}
+	if err = os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("could not create metadata directory: %w", err)
+	}
+
+	// The previous file is intact if the crash happened before the rename.
+	if err = os.Remove(path + tempExt); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("could not remove temporary metadata file: %w", err)
+	}
+
+	var data []byte
+	if data, err = os.ReadFile(path); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return s, nil
+		}
+		return nil, fmt.Errorf("could not read metadata: %w", err)
+	}
+
+	if s.state, err = decode(data); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// State returns the most recently saved state.
+func (s *Store) State() State {
+	s.RLock()
+	defer s.RUnlock()
+	return s.state
+}
+
+// Save the state, syncing it to disk before returning. If the save fails the previous
+// state is still stored on disk and returned by State.
+func (s *Store) Save(state State) (err error) {
+	s.Lock()
+	defer s.Unlock()
+
+	if s.closed {
+		return ErrClosed
+	}
+
+	temp := s.path + tempExt
+	if err = writeFile(temp, encode(state)); err != nil {
+		os.Remove(temp)
+		return fmt.Errorf("could not write metadata: %w", err)
+	}
+
+	if err = os.Rename(temp, s.path); err != nil {
+		os.Remove(temp)
+		return fmt.Errorf("could not replace metadata: %w", err)
+	}
+
+	// Sync the directory so that the rename is durable.
+	if err = syncDir(filepath.Dir(s.path)); err != nil {
+		return fmt.Errorf("could not sync metadata directory: %w", err)
+	}
+
+	s.state = state
+	return nil
+}
+
+// Close the store so that the state cannot be saved after the replica has stopped; the
+// most recently saved state can still be read.
+func (s *Store) Close() error {
+	s.Lock()
+	defer s.Unlock()
+
+	if s.closed {
+		return ErrClosed
+	}
+	s.closed = true
+	return nil
+}
+
+// Encode the state as a header containing the length and checksum of the data followed
+// by the data itself.
+func encode(state State) []byte {
+	data := make([]byte, headerSize+4*binary.MaxVarintLen64+len(state.VotedFor)+len(state.ClusterID))
+	n := headerSize
+	n += binary.PutUvarint(data[n:], state.Term)
+	n += binary.PutUvarint(data[n:], state.QuorumID)
+	n += binary.PutUvarint(data[n:], uint64(len(state.VotedFor)))
+	n += copy(data[n:], state.VotedFor)
+	n += binary.PutUvarint(data[n:], uint64(len(state.ClusterID)))
+	n += copy(data[n:], state.ClusterID)
+
+	binary.LittleEndian.PutUint32(data[0:4], uint32(n-headerSize))
+	binary.LittleEndian.PutUint32(data[4:8], crc32.Checksum(data[headerSize:n], crcTable))
+	return data[:n]
+}
+
+// Decode the state, verifying the length and checksum of the data.
+func decode(data []byte) (state State, err error) {
+	if len(data) < headerSize {
+		return state, fmt.Errorf("metadata is truncated: %w", ErrCorrupt)
+	}
+
+	size := binary.LittleEndian.Uint32(data[0:4])
+	crc := binary.LittleEndian.Uint32(data[4:8])
+	data = data[headerSize:]
+
+	if uint64(size) != uint64(len(data)) {
+		return state, fmt.Errorf("metadata length does not match: %w", ErrCorrupt)
+	}
+
+	if crc32.Checksum(data, crcTable) != crc {
+		return state, fmt.Errorf("metadata checksum does not match: %w", ErrCorrupt)
+	}
+
+	var n int
+	for _, field := range []*uint64{&state.Term, &state.QuorumID} {
+		var m int
+		if *field, m = binary.Uvarint(data[n:]); m <= 0 {
+			return state, fmt.Errorf("could not decode metadata: %w", ErrCorrupt)
+		}
+		n += m
+	}
+
+	for _, field := range []*string{&state.VotedFor, &state.ClusterID} {
+		length, m := binary.Uvarint(data[n:])
+		if m <= 0 || length > uint64(len(data)-n-m) {
+			return state, fmt.Errorf("could not decode metadata: %w", ErrCorrupt)
+		}
+		n += m
+
+		*field = string(data[n : n+int(length)])
+		n += int(length)
+	}
+
+	if n != len(data) {
+		return state, fmt.Errorf("could not decode metadata: %w", ErrCorrupt)
+	}
+	return state, nil
+}
+
+// Write the data to a new file and sync it to disk.
+func writeFile(path string, data []byte) (err error) {
+	var f *os.File
+	if f, err = os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644); err != nil {
+		return err
+	}
+
+	if _, err = f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+
+	if err = f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func syncDir(dir string) (err error) {
+	var f *os.File
+	if f, err = os.Open(dir); err != nil {
+		return err
+	}
+	defer f.Close()
+	return f.Sync()
+}
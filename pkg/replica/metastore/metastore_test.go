@@ -0,0 +1,80 @@
+package metastore_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bbengfort/otterdb/pkg/replica/metastore"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "meta", "replica.meta")
+	store, err := metastore.Open(path)
+	require.NoError(t, err, "could not open empty store")
+	require.Equal(t, metastore.State{}, store.State())
+
+	state := metastore.State{Term: 3, VotedFor: "jade", QuorumID: 12, ClusterID: "c9d8e7f6"}
+	require.NoError(t, store.Save(state))
+	require.Equal(t, state, store.State())
+
+	state.Term, state.VotedFor = 4, ""
+	require.NoError(t, store.Save(state))
+
+	// The state cannot be saved once the store is closed
+	require.NoError(t, store.Close())
+	require.ErrorIs(t, store.Close(), metastore.ErrClosed)
+	require.ErrorIs(t, store.Save(metastore.State{Term: 5}), metastore.ErrClosed)
+	require.Equal(t, state, store.State())
+
+	// Reopen the store and ensure the latest state has been recovered
+	store, err = metastore.Open(path)
+	require.NoError(t, err, "could not reopen store")
+	require.Equal(t, state, store.State())
+}
+
+func TestTemporaryFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "replica.meta")
+	store, err := metastore.Open(path)
+	require.NoError(t, err)
+
+	state := metastore.State{Term: 7, VotedFor: "kira", QuorumID: 2}
+	require.NoError(t, store.Save(state))
+
+	// A crash before the rename leaves a partial temporary file behind.
+	require.NoError(t, os.WriteFile(path+".tmp", []byte{0x08, 0x00}, 0644))
+
+	store, err = metastore.Open(path)
+	require.NoError(t, err, "could not reopen store with a temporary file")
+	require.Equal(t, state, store.State())
+	require.NoFileExists(t, path+".tmp")
+}
+
+func TestCorrupt(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "replica.meta")
+	store, err := metastore.Open(path)
+	require.NoError(t, err)
+	require.NoError(t, store.Save(metastore.State{Term: 42, VotedFor: "opal", QuorumID: 8, ClusterID: "otters"}))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	// Flip a bit in the data
+	corrupt := append([]byte{}, data...)
+	corrupt[len(corrupt)-1] ^= 0x01
+	require.NoError(t, os.WriteFile(path, corrupt, 0644))
+
+	_, err = metastore.Open(path)
+	require.ErrorIs(t, err, metastore.ErrCorrupt)
+
+	// Truncate the data
+	require.NoError(t, os.WriteFile(path, data[:len(data)-3], 0644))
+	_, err = metastore.Open(path)
+	require.ErrorIs(t, err, metastore.ErrCorrupt)
+
+	// Truncate the header
+	require.NoError(t, os.WriteFile(path, data[:4], 0644))
+	_, err = metastore.Open(path)
+	require.ErrorIs(t, err, metastore.ErrCorrupt)
+}
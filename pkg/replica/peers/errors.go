@@ -6,4 +6,5 @@ var (
 	ErrNoEndpoint       = errors.New("peer does not have an endpoint to connect on")
 	ErrAlreadyConnected = errors.New("already connected to remote peer")
 	ErrNotConnected     = errors.New("not connected to remote peer")
+	ErrNotServing       = errors.New("remote peer is not serving")
 )
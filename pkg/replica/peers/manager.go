@@ -0,0 +1,206 @@
+package peers
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+
+	health "github.com/bbengfort/otterdb/pkg/grpc/health/v1"
+	"github.com/rs/zerolog/log"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
+)
+
+const (
+	// After a health check of a peer fails it is retried after the backoff, which
+	// doubles after each consecutive failure up to the maximum; the connection to the
+	// peer is reestablished on the same schedule rather than the default of gRPC.
+	minReconnectBackoff = 100 * time.Millisecond
+	maxReconnectBackoff = 10 * time.Second
+)
+
+// Manager maintains the connections to the remote peers of the replica. Connections are
+// dialed lazily and the health of each peer is watched using the grpc.health.v1 Watch
+// endpoint of the peer so that the reachability, last contact, and round trip time of
+// every peer is known. If the health of a peer cannot be checked, the connection is
+// reestablished with exponential backoff.
+type Manager struct {
+	sync.Mutex
+	opts     func(peer *Peer) []grpc.DialOption
+	monitors map[string]*monitor
+}
+
+// Watches the health of a single peer until it is canceled.
+type monitor struct {
+	peer   *Peer
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewManager creates a connection manager that connects to peers with the dial options
+// returned by opts; if opts is nil then peers are connected with an insecure client.
+func NewManager(opts func(peer *Peer) []grpc.DialOption) *Manager {
+	return &Manager{opts: opts, monitors: make(map[string]*monitor)}
+}
+
+// Connect the peer and start watching its health.
+func (m *Manager) Connect(peer *Peer) (err error) {
+	m.Lock()
+	defer m.Unlock()
+
+	if _, ok := m.monitors[peer.Name]; ok {
+		return ErrAlreadyConnected
+	}
+
+	var opts []grpc.DialOption
+	if m.opts != nil {
+		opts = m.opts(peer)
+	}
+
+	if err = peer.Connect(opts...); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	mon := &monitor{peer: peer, cancel: cancel, done: make(chan struct{})}
+	m.monitors[peer.Name] = mon
+
+	go mon.run(ctx)
+	return nil
+}
+
+// Disconnect the peer and stop watching its health.
+func (m *Manager) Disconnect(peer *Peer) error {
+	m.Lock()
+	mon, ok := m.monitors[peer.Name]
+	delete(m.monitors, peer.Name)
+	m.Unlock()
+
+	if ok {
+		mon.stop()
+	}
+	return peer.Close()
+}
+
+// Status returns the reachability of every connected peer sorted by name.
+func (m *Manager) Status() []Status {
+	m.Lock()
+	statuses := make([]Status, 0, len(m.monitors))
+	for _, mon := range m.monitors {
+		statuses = append(statuses, mon.peer.Status())
+	}
+	m.Unlock()
+
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Name < statuses[j].Name })
+	return statuses
+}
+
+// Close disconnects all peers and stops watching their health.
+func (m *Manager) Close() (err error) {
+	m.Lock()
+	monitors := m.monitors
+	m.monitors = make(map[string]*monitor)
+	m.Unlock()
+
+	for _, mon := range monitors {
+		mon.stop()
+		if cerr := mon.peer.Close(); cerr != nil {
+			err = errors.Join(err, cerr)
+		}
+	}
+	return err
+}
+
+// Watch the health of the peer, reconnecting with exponential backoff whenever the
+// health of the peer cannot be checked or the peer is not serving.
+func (m *monitor) run(ctx context.Context) {
+	defer close(m.done)
+
+	var backoff time.Duration
+	for {
+		contacted, err := m.peer.watchHealth(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+
+		if contacted {
+			backoff = minReconnectBackoff
+		} else {
+			backoff = min(max(backoff*2, minReconnectBackoff), maxReconnectBackoff)
+		}
+
+		if failures := m.peer.unreachable(err); failures == 1 {
+			log.Debug().Err(err).Str("peer", m.peer.Name).Msg("peer is unreachable")
+		}
+
+		// Jitter the backoff so that replicas do not reconnect in lockstep.
+		wait := backoff/2 + time.Duration(rand.Int63n(int64(backoff/2)+1))
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return
+		}
+
+		m.peer.reconnect()
+	}
+}
+
+// Stop watching the health of the peer and wait for the monitor to exit.
+func (m *monitor) stop() {
+	m.cancel()
+	<-m.done
+}
+
+// Watch the health of the remote until the watch fails or the remote stops serving,
+// returning true if the remote replied to the health check. The watch is ended when the
+// remote is not serving so that it is not prevented from shutting down gracefully.
+func (p *Peer) watchHealth(ctx context.Context) (contacted bool, err error) {
+	var conn *grpc.ClientConn
+	p.Lock()
+	conn, err = p.dial()
+	p.Unlock()
+
+	if err != nil {
+		return false, err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	start := time.Now()
+	var stream health.Health_WatchClient
+	if stream, err = health.NewHealthClient(conn).Watch(ctx, &health.HealthCheckRequest{}); err != nil {
+		return false, err
+	}
+
+	for {
+		var rep *health.HealthCheckResponse
+		if rep, err = stream.Recv(); err != nil {
+			return contacted, err
+		}
+
+		if !contacted {
+			p.roundTrip(time.Since(start))
+			contacted = true
+		}
+
+		p.healthy(rep.Status)
+		if rep.Status != health.StatusServing {
+			return contacted, ErrNotServing
+		}
+	}
+}
+
+// Reconnect immediately if the connection to the remote has failed rather than waiting
+// for the connection backoff of the gRPC client.
+func (p *Peer) reconnect() {
+	p.RLock()
+	defer p.RUnlock()
+
+	if p.conn != nil && p.conn.GetState() == connectivity.TransientFailure {
+		p.conn.ResetConnectBackoff()
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"sync"
+	"time"
 
 	"github.com/bbengfort/otterdb/pkg/replica/raft/v1"
 	"google.golang.org/grpc"
@@ -13,20 +14,67 @@ import (
 // Peer represents a replica in a distributed consensus quorum and provides connection
 // functionality to maintain a remote connection to that replica for RPCs.
 type Peer struct {
-	PID    uint16 `json:"pid"`              // The precedence id of the peer
-	Name   string `json:"name"`             // The unique name of the replica in the quorum
-	Addr   string `json:"addr"`             // The dial address of the peer including port
-	Region string `json:"region,omitempty"` // The region that the peer is located in
+	PID        uint16 `json:"pid"`                   // The precedence id of the peer
+	Name       string `json:"name"`                  // The unique name of the replica in the quorum
+	Addr       string `json:"addr"`                  // The dial address of the peer including port
+	ClientAddr string `json:"client_addr,omitempty"` // The address of the database server of the peer
+	Region     string `json:"region,omitempty"`      // The region that the peer is located in
+	Learner    bool   `json:"learner,omitempty"`     // Learners receive entries but do not vote
 
 	sync.RWMutex
-	conn   *grpc.ClientConn // grpc dial connection to the remote
-	client raft.RaftClient  // grpc raft client
+	open   bool              // true if the peer is connected, the connection is dialed lazily
+	opts   []grpc.DialOption // options used to dial the connection to the remote
+	conn   *grpc.ClientConn  // grpc dial connection to the remote
+	client raft.RaftClient   // grpc raft client
+
+	// The reachability of the remote as observed by the local replica.
+	reachable   bool
+	health      string
+	lastContact time.Time
+	rtt         time.Duration
+	failures    int
+	lastError   error
+
+	// The long-lived append entries stream to the remote and the backoff before the
+	// stream is reopened after it fails; unary is set if the remote has no stream RPC.
+	stream  *appendStream
+	retry   time.Time
+	backoff time.Duration
+	unary   bool
+}
+
+// FromMember creates a peer that is not connected from the description of a member of
+// a configuration that was replicated to the quorum.
+func FromMember(member *raft.Member) *Peer {
+	return &Peer{
+		PID:        uint16(member.Pid),
+		Name:       member.Name,
+		Addr:       member.Addr,
+		ClientAddr: member.ClientAddr,
+		Region:     member.Region,
+		Learner:    member.Learner,
+	}
+}
+
+// Member returns the description of the peer that is replicated in configurations.
+func (p *Peer) Member() *raft.Member {
+	return &raft.Member{
+		Pid:        uint32(p.PID),
+		Name:       p.Name,
+		Addr:       p.Addr,
+		ClientAddr: p.ClientAddr,
+		Region:     p.Region,
+		Learner:    p.Learner,
+	}
 }
 
 //===========================================================================
 // Network Connection and RPCs
 //===========================================================================
 
+// Connect prepares the peer to send RPCs to the remote with the specified dial options.
+// The connection is dialed lazily when it is first used; if the connection is lost, the
+// gRPC client reconnects when the connection is next used.
 func (p *Peer) Connect(opts ...grpc.DialOption) (err error) {
 	p.Lock()
 	defer p.Unlock()
@@ -35,7 +83,7 @@ func (p *Peer) Connect(opts ...grpc.DialOption) (err error) {
 		return ErrNoEndpoint
 	}
 
-	if p.conn != nil {
+	if p.open {
 		return ErrAlreadyConnected
 	}
 
@@ -44,37 +92,116 @@ func (p *Peer) Connect(opts ...grpc.DialOption) (err error) {
 		opts = append(opts, grpc.WithTransportCredentials(insecure.NewCredentials()))
 	}
 
-	if p.conn, err = grpc.NewClient(p.Addr, opts...); err != nil {
-		return fmt.Errorf("could not connect to %s: %w", p.Name, err)
-	}
-
-	p.client = raft.NewRaftClient(p.conn)
+	p.opts = opts
+	p.open = true
+	p.retry, p.backoff, p.unary = time.Time{}, 0, false
 	return nil
 }
 
+// Close the connection to the remote, if it has been dialed. The peer cannot send RPCs
+// until it is connected again.
 func (p *Peer) Close() (err error) {
 	p.Lock()
 	defer p.Unlock()
 
-	err = p.conn.Close()
+	if p.stream != nil {
+		p.stream.close()
+		p.stream = nil
+	}
 
+	if p.conn != nil {
+		err = p.conn.Close()
+	}
+
+	p.open = false
+	p.opts = nil
 	p.conn = nil
 	p.client = nil
+	p.reachable = false
 	return err
 }
 
-func (p *Peer) RequestVote(ctx context.Context, in *raft.VoteRequest) (*raft.VoteReply, error) {
-	if p.client == nil {
+// Returns the connection to the remote, dialing it if it has not been dialed yet.
+//
+// NOTE: the peer must be locked when this method is called.
+func (p *Peer) dial() (_ *grpc.ClientConn, err error) {
+	if !p.open {
 		return nil, ErrNotConnected
 	}
 
-	return p.client.RequestVote(ctx, in)
+	if p.conn == nil {
+		if p.conn, err = grpc.NewClient(p.Addr, p.opts...); err != nil {
+			return nil, fmt.Errorf("could not connect to %s: %w", p.Name, err)
+		}
+		p.client = raft.NewRaftClient(p.conn)
+	}
+	return p.conn, nil
 }
 
-func (p *Peer) AppendEntries(ctx context.Context, in *raft.AppendRequest) (*raft.AppendReply, error) {
-	if p.client == nil {
-		return nil, ErrNotConnected
+// Returns the raft client of the connection to the remote, dialing it if necessary.
+func (p *Peer) raftClient() (_ raft.RaftClient, err error) {
+	p.Lock()
+	defer p.Unlock()
+
+	if _, err = p.dial(); err != nil {
+		return nil, err
+	}
+	return p.client, nil
+}
+
+func (p *Peer) RequestVote(ctx context.Context, in *raft.VoteRequest) (out *raft.VoteReply, err error) {
+	var client raft.RaftClient
+	if client, err = p.raftClient(); err != nil {
+		return nil, err
 	}
 
-	return p.client.AppendEntries(ctx, in)
+	if out, err = client.RequestVote(ctx, in); err == nil {
+		p.contact()
+	}
+	return out, err
+}
+
+func (p *Peer) AppendEntries(ctx context.Context, in *raft.AppendRequest) (out *raft.AppendReply, err error) {
+	var client raft.RaftClient
+	if client, err = p.raftClient(); err != nil {
+		return nil, err
+	}
+
+	if out, err = client.AppendEntries(ctx, in); err == nil {
+		p.contact()
+	}
+	return out, err
+}
+
+func (p *Peer) Forward(ctx context.Context, in *raft.ForwardRequest) (out *raft.ForwardReply, err error) {
+	var client raft.RaftClient
+	if client, err = p.raftClient(); err != nil {
+		return nil, err
+	}
+
+	if out, err = client.Forward(ctx, in); err == nil {
+		p.contact()
+	}
+	return out, err
+}
+
+func (p *Peer) InstallSnapshot(ctx context.Context) (raft.Raft_InstallSnapshotClient, error) {
+	client, err := p.raftClient()
+	if err != nil {
+		return nil, err
+	}
+
+	return client.InstallSnapshot(ctx)
+}
+
+func (p *Peer) TimeoutNow(ctx context.Context, in *raft.TimeoutNowRequest) (out *raft.TimeoutNowReply, err error) {
+	var client raft.RaftClient
+	if client, err = p.raftClient(); err != nil {
+		return nil, err
+	}
+
+	if out, err = client.TimeoutNow(ctx, in); err == nil {
+		p.contact()
+	}
+	return out, err
 }
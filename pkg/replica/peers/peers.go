@@ -6,9 +6,10 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"sync"
+	"time"
 
 	"github.com/bbengfort/otterdb/pkg/replica/raft/v1"
-	"github.com/rs/zerolog/log"
 	"google.golang.org/grpc"
 )
 
@@ -28,6 +29,16 @@ func (p Peers) Names() []string {
 	return names
 }
 
+// Members returns the description of every peer in the collection that is replicated
+// in configurations.
+func (p Peers) Members() []*raft.Member {
+	members := make([]*raft.Member, 0, len(p))
+	for _, peer := range p {
+		members = append(members, peer.Member())
+	}
+	return members
+}
+
 // Get a peer by name; returns an error if no peer with that name exists.
 func (p Peers) Get(name string) (_ *Peer, err error) {
 	for _, peer := range p {
@@ -78,34 +89,70 @@ func (p Peers) Close() (err error) {
 	return err
 }
 
-// Broadcast request vote to all remote peers, sending replies on the recv channel.
-// Any errors that occur will be logged after all handling is complete.
-func (p Peers) RequestVote(ctx context.Context, in *raft.VoteRequest, recv chan<- *raft.VoteReply) {
-	// TODO: use a send channel rather than starting an arbitrary number of go routines.
-	for _, peer := range p {
-		go func() {
-			out, err := peer.RequestVote(ctx, in)
-			if err != nil {
-				log.Warn().Err(err).Msg("request vote rpc failed")
-				return
-			}
-			recv <- out
-		}()
-	}
+// VoteResult is the outcome of a request vote RPC sent to a single peer.
+type VoteResult struct {
+	Peer    string          // The name of the peer the request was sent to
+	Reply   *raft.VoteReply // The reply of the peer, nil if the request failed
+	Err     error           // The error if the request failed
+	Latency time.Duration   // The amount of time the peer took to reply or fail
 }
 
-func (p Peers) AppendEntries(ctx context.Context, in *raft.AppendRequest, recv chan<- *raft.AppendReply) {
-	// TODO: use a send channel rather than starting an arbitrary number of go routines.
+// AppendResult is the outcome of an append entries RPC sent to a single peer.
+type AppendResult struct {
+	Peer    string            // The name of the peer the request was sent to
+	Reply   *raft.AppendReply // The reply of the peer, nil if the request failed
+	Err     error             // The error if the request failed
+	Latency time.Duration     // The amount of time the peer took to reply or fail
+}
+
+// RequestVote sends the vote request to all remote peers concurrently. Exactly one
+// result is sent on the returned channel for each peer as it replies or fails and the
+// channel is closed once every peer has a result. If the context is canceled, the
+// peers that have not yet replied fail with the error of the context.
+func (p Peers) RequestVote(ctx context.Context, in *raft.VoteRequest) <-chan *VoteResult {
+	results := make(chan *VoteResult, len(p))
+	p.fanout(func(peer *Peer) {
+		start := time.Now()
+		out, err := peer.RequestVote(ctx, in)
+		results <- &VoteResult{Peer: peer.Name, Reply: out, Err: err, Latency: time.Since(start)}
+	}, func() {
+		close(results)
+	})
+	return results
+}
+
+// AppendEntries sends the append entries request to all remote peers concurrently.
+// Exactly one result is sent on the returned channel for each peer as it replies or
+// fails and the channel is closed once every peer has a result. If the context is
+// canceled, the peers that have not yet replied fail with the error of the context.
+func (p Peers) AppendEntries(ctx context.Context, in *raft.AppendRequest) <-chan *AppendResult {
+	results := make(chan *AppendResult, len(p))
+	p.fanout(func(peer *Peer) {
+		start := time.Now()
+		out, err := peer.AppendEntries(ctx, in)
+		results <- &AppendResult{Peer: peer.Name, Reply: out, Err: err, Latency: time.Since(start)}
+	}, func() {
+		close(results)
+	})
+	return results
+}
+
+// Call the rpc for every peer in its own go routine and call done once every rpc has
+// returned. The results channels are buffered so the rpcs never block on the caller.
+func (p Peers) fanout(rpc func(peer *Peer), done func()) {
+	var wg sync.WaitGroup
+	wg.Add(len(p))
 	for _, peer := range p {
-		go func() {
-			out, err := peer.AppendEntries(ctx, in)
-			if err != nil {
-				log.Warn().Err(err).Msg("append entries rpc failed")
-				return
-			}
-			recv <- out
-		}()
+		go func(peer *Peer) {
+			defer wg.Done()
+			rpc(peer)
+		}(peer)
 	}
+
+	go func() {
+		wg.Wait()
+		done()
+	}()
 }
 
 //===========================================================================
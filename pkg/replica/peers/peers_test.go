@@ -1,13 +1,36 @@
 package peers_test
 
 import (
+	"context"
+	"io"
+	"net"
+	"os"
 	"path/filepath"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"github.com/bbengfort/otterdb/pkg/bufconn"
+	health "github.com/bbengfort/otterdb/pkg/grpc/health/v1"
+	"github.com/bbengfort/otterdb/pkg/logger"
 	. "github.com/bbengfort/otterdb/pkg/replica/peers"
+	"github.com/bbengfort/otterdb/pkg/replica/raft/v1"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
 )
 
+func TestMain(m *testing.M) {
+	logger.Discard()
+	exitVal := m.Run()
+	logger.ResetLogger()
+	os.Exit(exitVal)
+}
+
 func TestPeers(t *testing.T) {
 	peers, err := Load("testdata/peers.json")
 	require.NoError(t, err, "could not load testdata peers")
@@ -21,6 +44,7 @@ func TestPeers(t *testing.T) {
 		peer, err := peers.Get("jade")
 		require.NoError(t, err, "could not get peer")
 		require.Equal(t, uint16(30), peer.PID)
+		require.Equal(t, "jade.local:3256", peer.ClientAddr)
 
 		peer, err = peers.Get("artemis")
 		require.EqualError(t, err, "no peer found named \"artemis\"")
@@ -30,6 +54,21 @@ func TestPeers(t *testing.T) {
 	t.Run("Presiding", func(t *testing.T) {
 		require.Equal(t, "kira", peers.Presiding())
 	})
+
+	t.Run("Members", func(t *testing.T) {
+		members := peers.Members()
+		require.Len(t, members, len(peers))
+
+		for i, member := range members {
+			peer := FromMember(member)
+			require.Equal(t, peers[i].PID, peer.PID)
+			require.Equal(t, peers[i].Name, peer.Name)
+			require.Equal(t, peers[i].Addr, peer.Addr)
+			require.Equal(t, peers[i].ClientAddr, peer.ClientAddr)
+			require.Equal(t, peers[i].Region, peer.Region)
+			require.Equal(t, peers[i].Learner, peer.Learner)
+		}
+	})
 }
 
 func TestSerialization(t *testing.T) {
@@ -47,10 +86,11 @@ func TestSerialization(t *testing.T) {
 			Region: "eu-west1",
 		},
 		{
-			PID:    40,
-			Name:   "jade",
-			Addr:   "jade.co:443",
-			Region: "us-east4",
+			PID:     40,
+			Name:    "jade",
+			Addr:    "jade.co:443",
+			Region:  "us-east4",
+			Learner: true,
 		},
 	}
 
@@ -65,3 +105,314 @@ func TestSerialization(t *testing.T) {
 
 	require.Equal(t, peers, cmp)
 }
+
+func TestManager(t *testing.T) {
+	// The dialer connects to the current socket so that the server can be restarted.
+	var (
+		mu   sync.Mutex
+		sock *bufconn.Listener
+	)
+
+	start := func() (*grpc.Server, *health.ProbeServer) {
+		probe := &health.ProbeServer{}
+		probe.Healthy()
+
+		srv := grpc.NewServer()
+		health.RegisterHealthServer(srv, probe)
+		raft.RegisterRaftServer(srv, &unaryServer{})
+
+		mu.Lock()
+		sock = bufconn.New()
+		go srv.Serve(sock.Sock())
+		mu.Unlock()
+		return srv, probe
+	}
+
+	dialer := func(ctx context.Context, addr string) (net.Conn, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		return sock.Dialer(ctx, addr)
+	}
+
+	srv, probe := start()
+	manager := NewManager(func(*Peer) []grpc.DialOption {
+		return []grpc.DialOption{grpc.WithContextDialer(dialer), grpc.WithTransportCredentials(insecure.NewCredentials())}
+	})
+	defer manager.Close()
+
+	peer := &Peer{Name: "jade", Addr: bufconn.Endpoint}
+	require.NoError(t, manager.Connect(peer), "could not connect peer")
+	require.ErrorIs(t, manager.Connect(peer), ErrAlreadyConnected)
+
+	reachable := func(expected bool) func() bool {
+		return func() bool {
+			statuses := manager.Status()
+			return len(statuses) == 1 && statuses[0].Reachable == expected
+		}
+	}
+
+	require.Eventually(t, reachable(true), 2*time.Second, 10*time.Millisecond, "expected peer to be reachable")
+	status := manager.Status()[0]
+	require.Equal(t, "jade", status.Name)
+	require.True(t, status.Connected)
+	require.Equal(t, "SERVING", status.Health)
+	require.NotZero(t, status.RTT)
+	require.False(t, status.LastContact.IsZero())
+	require.Zero(t, status.Failures)
+
+	// RPCs record contact with the peer.
+	contact := status.LastContact
+	_, err := peer.AppendEntries(context.Background(), &raft.AppendRequest{})
+	require.NoError(t, err)
+	require.True(t, peer.Status().LastContact.After(contact))
+
+	// The peer is not reachable while it is not serving.
+	probe.NotHealthy()
+	require.Eventually(t, reachable(false), 2*time.Second, 10*time.Millisecond, "expected peer to be unreachable")
+	require.Equal(t, "NOT_SERVING", manager.Status()[0].Health)
+
+	probe.Healthy()
+	require.Eventually(t, reachable(true), 2*time.Second, 10*time.Millisecond, "expected peer to be reachable")
+
+	// The peer is reconnected after the server is restarted.
+	srv.Stop()
+	require.Eventually(t, func() bool {
+		status := manager.Status()[0]
+		return !status.Reachable && status.Failures > 0 && status.LastError != ""
+	}, 2*time.Second, 10*time.Millisecond, "expected health checks to fail")
+
+	srv, _ = start()
+	defer srv.Stop()
+	require.Eventually(t, reachable(true), 5*time.Second, 10*time.Millisecond, "expected peer to reconnect")
+	require.Zero(t, manager.Status()[0].Failures)
+
+	require.NoError(t, manager.Disconnect(peer))
+	require.Empty(t, manager.Status())
+	require.False(t, peer.Status().Connected)
+
+	_, err = peer.AppendEntries(context.Background(), &raft.AppendRequest{})
+	require.ErrorIs(t, err, ErrNotConnected)
+}
+
+func TestClose(t *testing.T) {
+	// A peer that was never dialed can be closed.
+	peer := &Peer{Name: "jade", Addr: "jade.local:2204"}
+	require.NoError(t, peer.Close())
+
+	require.NoError(t, peer.Connect())
+	require.False(t, peer.Status().Connected, "connection should be dialed lazily")
+	require.NoError(t, peer.Close())
+	require.NoError(t, peer.Connect(), "should be able to connect after close")
+	require.NoError(t, peer.Close())
+}
+
+func TestFanout(t *testing.T) {
+	remotes := Peers{serve(t, &unaryServer{}), serve(t, &unaryServer{}), serve(t, &unaryServer{delay: time.Minute}), {Name: "opal"}}
+	remotes[0].Name, remotes[1].Name, remotes[2].Name = "jade", "kira", "ruby"
+
+	t.Run("RequestVote", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+
+		results := make(map[string]*VoteResult)
+		for result := range remotes.RequestVote(ctx, &raft.VoteRequest{Term: 4}) {
+			results[result.Peer] = result
+		}
+
+		// Every peer has exactly one result once the channel is closed.
+		require.Len(t, results, 4)
+		for _, name := range []string{"jade", "kira"} {
+			require.NoError(t, results[name].Err)
+			require.True(t, results[name].Reply.Granted)
+			require.Equal(t, uint64(4), results[name].Reply.Term)
+		}
+
+		// The slow peer fails when the context is canceled.
+		require.Equal(t, codes.DeadlineExceeded, status.Code(results["ruby"].Err))
+		require.Nil(t, results["ruby"].Reply)
+		require.NotZero(t, results["ruby"].Latency)
+
+		require.ErrorIs(t, results["opal"].Err, ErrNotConnected)
+	})
+
+	t.Run("AppendEntries", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		var failed []string
+		results := remotes[:2].AppendEntries(ctx, &raft.AppendRequest{PrevLogIndex: 8})
+		for result := range results {
+			if result.Err != nil {
+				failed = append(failed, result.Peer)
+				continue
+			}
+			require.True(t, result.Reply.Success)
+			require.Equal(t, uint64(8), result.Reply.Index)
+		}
+		require.Empty(t, failed)
+	})
+
+	t.Run("Empty", func(t *testing.T) {
+		_, ok := <-Peers{}.RequestVote(context.Background(), &raft.VoteRequest{})
+		require.False(t, ok, "expected results to be closed")
+	})
+}
+
+func TestStreamAppendEntries(t *testing.T) {
+	t.Run("Stream", func(t *testing.T) {
+		srv := &streamServer{}
+		peer := serve(t, srv)
+
+		replies := sendAppendEntries(t, peer, 0, 100)
+		for i, reply := range replies {
+			require.Equal(t, uint64(i), reply.Index, "replies were not received in order")
+		}
+
+		require.Equal(t, int64(1), srv.streams.Load(), "expected a single long-lived stream")
+		require.Zero(t, srv.unary.Load(), "expected no unary rpcs")
+	})
+
+	t.Run("Unimplemented", func(t *testing.T) {
+		// Requests are sent with the unary rpc if the remote does not support streams.
+		srv := &unaryServer{}
+		peer := serve(t, srv)
+
+		replies := sendAppendEntries(t, peer, 0, 20)
+		require.Len(t, replies, 20)
+		require.Equal(t, int64(20), srv.unary.Load())
+	})
+
+	t.Run("Reconnect", func(t *testing.T) {
+		// The stream is aborted after every 10 requests; the requests waiting for a
+		// reply fail and the stream is reopened after the backoff.
+		srv := &streamServer{abort: 10}
+		peer := serve(t, srv)
+
+		var (
+			mu     sync.Mutex
+			failed int
+			done   sync.WaitGroup
+		)
+
+		for i := 0; i < 10; i++ {
+			done.Add(1)
+			peer.StreamAppendEntries(&raft.AppendRequest{PrevLogIndex: uint64(i)}, time.Second, func(reply *raft.AppendReply, _ time.Time, err error) {
+				defer done.Done()
+				if err != nil {
+					mu.Lock()
+					failed++
+					mu.Unlock()
+				}
+			})
+		}
+		done.Wait()
+		require.Equal(t, 1, failed, "expected the request that aborted the stream to fail")
+
+		require.Eventually(t, func() bool {
+			sendAppendEntries(t, peer, 10, 1)
+			return srv.streams.Load() > 1
+		}, 2*time.Second, 10*time.Millisecond, "expected the stream to be reopened")
+	})
+
+	t.Run("NotConnected", func(t *testing.T) {
+		peer := &Peer{Name: "jade"}
+		errc := make(chan error, 1)
+		peer.StreamAppendEntries(&raft.AppendRequest{}, time.Second, func(_ *raft.AppendReply, _ time.Time, err error) {
+			errc <- err
+		})
+		require.ErrorIs(t, <-errc, ErrNotConnected)
+	})
+}
+
+// Send count requests to the peer starting at the prev log index and wait for replies.
+func sendAppendEntries(t *testing.T, peer *Peer, start, count int) []*raft.AppendReply {
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		replies []*raft.AppendReply
+	)
+
+	for i := start; i < start+count; i++ {
+		wg.Add(1)
+		peer.StreamAppendEntries(&raft.AppendRequest{PrevLogIndex: uint64(i)}, time.Second, func(reply *raft.AppendReply, _ time.Time, err error) {
+			defer wg.Done()
+			assert.NoError(t, err, "append entries request failed")
+			mu.Lock()
+			replies = append(replies, reply)
+			mu.Unlock()
+		})
+	}
+
+	wg.Wait()
+	return replies
+}
+
+// Serve the raft server on a bufconn and return a peer that is connected to it.
+func serve(t *testing.T, srv raft.RaftServer) *Peer {
+	sock := bufconn.New()
+	gsrv := grpc.NewServer()
+	raft.RegisterRaftServer(gsrv, srv)
+	go gsrv.Serve(sock.Sock())
+
+	peer := &Peer{Name: "jade", Addr: bufconn.Endpoint}
+	err := peer.Connect(grpc.WithContextDialer(sock.Dialer), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err, "could not connect to server")
+
+	t.Cleanup(func() {
+		peer.Close()
+		gsrv.Stop()
+		sock.Close()
+	})
+	return peer
+}
+
+// A raft server that only supports the unary request vote and append entries rpcs,
+// replying after the delay if it is non-zero.
+type unaryServer struct {
+	raft.UnimplementedRaftServer
+	unary atomic.Int64
+	delay time.Duration
+}
+
+func (s *unaryServer) RequestVote(ctx context.Context, in *raft.VoteRequest) (*raft.VoteReply, error) {
+	select {
+	case <-time.After(s.delay):
+		return &raft.VoteReply{Term: in.Term, Granted: true}, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (s *unaryServer) AppendEntries(_ context.Context, in *raft.AppendRequest) (*raft.AppendReply, error) {
+	s.unary.Add(1)
+	return &raft.AppendReply{Success: true, Index: in.PrevLogIndex}, nil
+}
+
+// A raft server that supports append entries streams, aborting each stream after the
+// specified number of requests if abort is non-zero.
+type streamServer struct {
+	unaryServer
+	abort   int
+	streams atomic.Int64
+}
+
+func (s *streamServer) AppendStream(stream raft.Raft_AppendStreamServer) error {
+	s.streams.Add(1)
+	for i := 1; ; i++ {
+		in, err := stream.Recv()
+		if err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+
+		if s.abort > 0 && i == s.abort {
+			return status.Error(codes.Aborted, "stream aborted")
+		}
+
+		if err = stream.Send(&raft.AppendReply{Success: true, Index: in.PrevLogIndex}); err != nil {
+			return err
+		}
+	}
+}
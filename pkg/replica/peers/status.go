@@ -0,0 +1,82 @@
+package peers
+
+import (
+	"time"
+
+	health "github.com/bbengfort/otterdb/pkg/grpc/health/v1"
+)
+
+// Status describes the reachability of a remote peer as observed by the local replica.
+type Status struct {
+	Name        string        `json:"name"`
+	Addr        string        `json:"addr"`
+	Connected   bool          `json:"connected"`              // True if a connection to the peer has been dialed
+	Reachable   bool          `json:"reachable"`              // True if the health service of the peer is serving
+	Health      string        `json:"health,omitempty"`       // The last status reported by the health service of the peer
+	LastContact time.Time     `json:"last_contact,omitempty"` // The last time the peer replied to any request
+	RTT         time.Duration `json:"rtt"`                    // The round trip time of the last health check
+	Failures    int           `json:"failures"`               // The number of consecutive failures to check the health of the peer
+	LastError   string        `json:"last_error,omitempty"`   // The error of the last failed health check, if any
+}
+
+// Status returns the reachability of the peer.
+func (p *Peer) Status() Status {
+	p.RLock()
+	defer p.RUnlock()
+
+	status := Status{
+		Name:        p.Name,
+		Addr:        p.Addr,
+		Connected:   p.conn != nil,
+		Reachable:   p.reachable,
+		Health:      p.health,
+		LastContact: p.lastContact,
+		RTT:         p.rtt,
+		Failures:    p.failures,
+	}
+
+	if p.lastError != nil {
+		status.LastError = p.lastError.Error()
+	}
+	return status
+}
+
+// Record that the peer has replied to a request.
+func (p *Peer) contact() {
+	p.Lock()
+	p.lastContact = time.Now()
+	p.Unlock()
+}
+
+// Record the status reported by the health service of the peer.
+func (p *Peer) healthy(status health.HealthCheckResponse_ServingStatus) {
+	p.Lock()
+	defer p.Unlock()
+
+	p.reachable = status == health.StatusServing
+	p.health = status.String()
+	p.lastContact = time.Now()
+	if p.reachable {
+		p.failures = 0
+		p.lastError = nil
+	}
+}
+
+// Record the round trip time of a health check.
+func (p *Peer) roundTrip(rtt time.Duration) {
+	p.Lock()
+	p.rtt = rtt
+	p.Unlock()
+}
+
+// Record that the health of the peer could not be checked, returning the number of
+// consecutive failures.
+func (p *Peer) unreachable(err error) int {
+	p.Lock()
+	defer p.Unlock()
+
+	p.reachable = false
+	p.failures++
+	p.lastError = err
+	return p.failures
+}
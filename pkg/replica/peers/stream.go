@@ -0,0 +1,293 @@
+package peers
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/bbengfort/otterdb/pkg/replica/raft/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// The number of requests that can be queued or waiting for a reply on an append
+	// entries stream; requests are sent with the unary RPC if the queue is full.
+	streamBuffer = 256
+
+	// After an append entries stream fails it is not reopened until the backoff has
+	// passed; the backoff doubles after each failure up to the maximum and is reset once
+	// the peer replies on a stream.
+	minStreamBackoff = 50 * time.Millisecond
+	maxStreamBackoff = 5 * time.Second
+
+	// How often the stream checks if the oldest request waiting for a reply has timed out.
+	streamCheckInterval = 25 * time.Millisecond
+)
+
+// AppendHandler is called with the reply to an append entries request along with the
+// time that the request was sent. If the request fails the handler is called with the
+// error instead. Handlers may be called concurrently and must not block.
+type AppendHandler func(reply *raft.AppendReply, sent time.Time, err error)
+
+// StreamAppendEntries sends the request to the peer on a long-lived bidirectional stream
+// so that the leader does not wait for a round trip per request. Replies are received on
+// the stream in the order the requests were sent and are passed to the handler. If the
+// stream fails, every request waiting for a reply fails with the error and the stream is
+// reopened with exponential backoff; while the stream is unavailable, and if the remote
+// does not support streams, requests are sent with the unary AppendEntries RPC instead.
+// The request fails if no reply is received before the timeout. This method does not
+// block and the handler is always called from another go routine.
+func (p *Peer) StreamAppendEntries(in *raft.AppendRequest, timeout time.Duration, handler AppendHandler) {
+	req := &streamRequest{in: in, handler: handler, sent: time.Now()}
+	req.deadline = req.sent.Add(timeout)
+
+	if s := p.appendStream(); s != nil && s.send(req) {
+		return
+	}
+	go p.unaryAppendEntries(req)
+}
+
+// Send the request with the unary AppendEntries RPC and pass the reply to the handler.
+func (p *Peer) unaryAppendEntries(req *streamRequest) {
+	ctx, cancel := context.WithDeadline(context.Background(), req.deadline)
+	defer cancel()
+
+	reply, err := p.AppendEntries(ctx, req.in)
+	req.handler(reply, req.sent, err)
+}
+
+// Returns the open append entries stream to the peer, opening a new stream if the
+// peer is connected and the backoff since the last failure has passed, otherwise nil.
+func (p *Peer) appendStream() *appendStream {
+	p.Lock()
+	defer p.Unlock()
+
+	if !p.open || p.unary {
+		return nil
+	}
+
+	if p.stream != nil {
+		return p.stream
+	}
+
+	if time.Now().Before(p.retry) {
+		return nil
+	}
+
+	if _, err := p.dial(); err != nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	p.stream = &appendStream{
+		cancel:   cancel,
+		queue:    make(chan *raft.AppendRequest, streamBuffer),
+		fallback: p.unaryAppendEntries,
+		contact:  p.contact,
+	}
+
+	s := p.stream
+	go s.run(ctx, p.client, func(err error) { p.streamClosed(s, err) })
+	return s
+}
+
+// Called when the stream fails so that it is reopened after the backoff, or so that
+// the unary RPC is always used if the remote does not support streams.
+func (p *Peer) streamClosed(s *appendStream, err error) {
+	p.Lock()
+	defer p.Unlock()
+
+	// The stream was closed with the connection to the peer.
+	if p.stream != s {
+		return
+	}
+	p.stream = nil
+
+	if status.Code(err) == codes.Unimplemented {
+		p.unary = true
+		return
+	}
+
+	if s.hasReplied() || p.backoff == 0 {
+		p.backoff = minStreamBackoff
+	} else {
+		p.backoff = min(p.backoff*2, maxStreamBackoff)
+	}
+	p.retry = time.Now().Add(p.backoff)
+}
+
+// An append entries request that is queued to be sent on the stream or is waiting for
+// the reply from the peer.
+type streamRequest struct {
+	in       *raft.AppendRequest
+	handler  AppendHandler
+	sent     time.Time
+	deadline time.Time
+}
+
+// A long-lived bidirectional stream that sends append entries requests to the peer and
+// receives the replies in the order that the requests were sent.
+type appendStream struct {
+	sync.Mutex
+	cancel   context.CancelFunc
+	queue    chan *raft.AppendRequest
+	fallback func(*streamRequest) // Sends requests if the remote does not support streams
+	contact  func()               // Records contact with the remote when a reply is received
+	pending  []*streamRequest     // Requests waiting for a reply in the order they were queued
+	replied  bool                 // True once the peer has replied on the stream
+	err      error                // The error the stream failed with, if any
+}
+
+// Queue the request to be sent on the stream, returning false if the stream has failed
+// or the queue is full and the request must be sent another way.
+func (s *appendStream) send(req *streamRequest) bool {
+	s.Lock()
+	defer s.Unlock()
+
+	if s.err != nil {
+		return false
+	}
+
+	select {
+	case s.queue <- req.in:
+		s.pending = append(s.pending, req)
+		return true
+	default:
+		return false
+	}
+}
+
+// Open the stream and send queued requests on it until the stream fails; the replies
+// are received in a separate go routine. The closed callback is called once with the
+// error that the stream failed with.
+func (s *appendStream) run(ctx context.Context, client raft.RaftClient, closed func(error)) {
+	defer func() {
+		closed(s.error())
+	}()
+
+	go s.watch(ctx)
+
+	stream, err := client.AppendStream(ctx)
+	if err != nil {
+		s.fail(err)
+		return
+	}
+
+	go s.recv(stream)
+
+	for {
+		select {
+		case <-ctx.Done():
+			s.fail(status.FromContextError(ctx.Err()).Err())
+			return
+		case in := <-s.queue:
+			// If the stream was aborted by the remote, Send returns io.EOF and the
+			// status of the stream is returned by Recv, which fails the stream.
+			if err = stream.Send(in); err != nil && !errors.Is(err, io.EOF) {
+				s.fail(err)
+			}
+		}
+	}
+}
+
+// Fail the stream if the oldest request has not been replied to before its deadline,
+// e.g. if the stream cannot be opened or the peer stops responding.
+func (s *appendStream) watch(ctx context.Context) {
+	ticker := time.NewTicker(streamCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			s.Lock()
+			expired := len(s.pending) > 0 && now.After(s.pending[0].deadline)
+			s.Unlock()
+
+			if expired {
+				s.fail(status.Error(codes.DeadlineExceeded, "append entries stream timed out waiting for a reply"))
+				return
+			}
+		}
+	}
+}
+
+// Receive replies from the peer and pass them to the handlers of the requests in the
+// order the requests were sent until the stream fails.
+func (s *appendStream) recv(stream raft.Raft_AppendStreamClient) {
+	for {
+		reply, err := stream.Recv()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				err = status.Error(codes.Unavailable, "append entries stream closed by peer")
+			}
+			s.fail(err)
+			return
+		}
+
+		s.Lock()
+		if len(s.pending) == 0 {
+			s.Unlock()
+			s.fail(status.Error(codes.Internal, "received append entries reply without a request"))
+			return
+		}
+
+		req := s.pending[0]
+		s.pending = s.pending[1:]
+		s.replied = true
+		s.Unlock()
+
+		s.contact()
+
+		req.handler(reply, req.sent, nil)
+	}
+}
+
+// Fail the stream with the error, aborting the stream and failing the requests that are
+// queued or waiting for a reply. If the remote does not support streams, the requests
+// are sent with the fallback instead. Only the first error fails the stream.
+func (s *appendStream) fail(err error) {
+	s.Lock()
+	if s.err != nil {
+		s.Unlock()
+		return
+	}
+
+	s.err = err
+	pending := s.pending
+	s.pending = nil
+	s.Unlock()
+
+	s.cancel()
+	unimplemented := status.Code(err) == codes.Unimplemented
+	for _, req := range pending {
+		if unimplemented && s.fallback != nil {
+			go s.fallback(req)
+			continue
+		}
+		req.handler(nil, req.sent, err)
+	}
+}
+
+// Returns the error that the stream failed with, if any.
+func (s *appendStream) error() error {
+	s.Lock()
+	defer s.Unlock()
+	return s.err
+}
+
+// Returns true if the peer has replied on the stream.
+func (s *appendStream) hasReplied() bool {
+	s.Lock()
+	defer s.Unlock()
+	return s.replied
+}
+
+// Abort the stream, e.g. when the connection to the peer is closed.
+func (s *appendStream) close() {
+	s.cancel()
+}
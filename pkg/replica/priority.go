@@ -0,0 +1,94 @@
+package replica
+
+import (
+	"time"
+
+	"github.com/bbengfort/otterdb/pkg/replica/raft/v1"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Returns true if a replica with PID a takes precedence over a replica with PID b. Lower
+// PIDs take precedence; a PID of zero is not allowed so it never takes precedence.
+func precedes(a, b uint32) bool {
+	return a > 0 && (b == 0 || a < b)
+}
+
+// Returns the voting members of the latest configuration, which are the members that
+// the quorum is changing to while a membership change is in progress.
+func voters(config *raft.Configuration) []*raft.Member {
+	members := config.Current
+	if len(config.Next) > 0 {
+		members = config.Next
+	}
+
+	voters := make([]*raft.Member, 0, len(members))
+	for _, member := range members {
+		if !member.Learner {
+			voters = append(voters, member)
+		}
+	}
+	return voters
+}
+
+// Returns the rank of the replica in the configuration, which is the number of voting
+// members that take precedence over it. Each rank adds a heartbeat tick to the election
+// timeout of the replica so that the replicas with the lowest PIDs time out first.
+func rank(config *raft.Configuration, name string) (rank int) {
+	var pid uint32
+	members := voters(config)
+	for _, member := range members {
+		if member.Name == name {
+			pid = member.Pid
+		}
+	}
+
+	for _, member := range members {
+		if member.Name != name && precedes(member.Pid, pid) {
+			rank++
+		}
+	}
+	return rank
+}
+
+// If a voting member that takes precedence over the leader has replicated the entire
+// log of the leader, leadership is transferred to it so that it reclaims leadership,
+// e.g. when a replica in the primary region rejoins the quorum. Leadership is not
+// transferred while the membership is changing, before the leader has committed an entry
+// in its term, or for an election timeout after a transfer has failed.
+//
+// NOTE: This method is not thread-safe and should only be called from the event loop.
+func (r *Replica) maybeYield() {
+	if r.state != Leader || r.transfer != nil || len(r.config.Next) > 0 || r.log.CommitIndex() < r.termStart {
+		return
+	}
+
+	if !r.transferFailed.IsZero() && time.Since(r.transferFailed) < r.electionTimeout() {
+		return
+	}
+
+	var pid uint32
+	members := voters(r.config)
+	for _, member := range members {
+		if member.Name == r.name {
+			pid = member.Pid
+		}
+	}
+
+	// Yield to the caught up member with the highest precedence.
+	var target *raft.Member
+	for _, member := range members {
+		if member.Name == r.name || r.matchIndex[member.Name] < r.log.LastIndex() {
+			continue
+		}
+
+		if precedes(member.Pid, pid) && (target == nil || precedes(member.Pid, target.Pid)) {
+			target = member
+		}
+	}
+
+	if target != nil {
+		log.Info().Uint64("term", r.term).Str("target", target.Name).Uint32("pid", target.Pid).Msg("yielding leadership to replica with precedence")
+		r.startTransfer(target.Name, make(chan error, 1))
+	}
+}
@@ -0,0 +1,58 @@
+package replica
+
+// The replication progress of a follower that is tracked by the leader to control the
+// flow of append entries requests to the follower. Followers start in probe mode, where
+// the leader sends a single request at a time and waits for the reply to find the index
+// where the logs of the leader and the follower match. Once the follower accepts a
+// request, the leader pipelines requests to the follower: the next index is advanced
+// optimistically as entries are sent so that multiple requests are in flight without
+// waiting for replies, up to a window of requests and a budget of bytes. If the follower
+// rejects a request or a request fails, the follower is returned to probe mode.
+type progress struct {
+	pipeline bool       // True if requests are pipelined, otherwise the follower is probed
+	paused   bool       // In probe mode, true while the probe is waiting for a reply
+	inflight []inflight // The unacknowledged requests in the order they were sent
+	bytes    int        // The total size of the entries in the unacknowledged requests
+}
+
+// An append entries request that has been sent but not acknowledged by the follower.
+type inflight struct {
+	last  uint64 // The index of the last entry in the request
+	bytes int    // The size of the entries in the request
+}
+
+// Returns true if another request cannot be sent to the follower until a request in
+// flight is acknowledged; a window of less than one allows a single request in flight.
+func (p *progress) full(window, budget int) bool {
+	return len(p.inflight) >= max(window, 1) || (budget > 0 && p.bytes >= budget)
+}
+
+// Track a request containing the entries up to and including the last index.
+func (p *progress) sent(last uint64, bytes int) {
+	p.inflight = append(p.inflight, inflight{last: last, bytes: bytes})
+	p.bytes += bytes
+}
+
+// Release the requests whose entries have all been replicated to the follower.
+func (p *progress) ack(index uint64) {
+	var i int
+	for ; i < len(p.inflight) && p.inflight[i].last <= index; i++ {
+		p.bytes -= p.inflight[i].bytes
+	}
+	p.inflight = p.inflight[i:]
+}
+
+// Switch to probe mode, forgetting any requests in flight since their replies can no
+// longer advance the progress of the follower.
+func (p *progress) probe() {
+	p.pipeline = false
+	p.paused = false
+	p.inflight = nil
+	p.bytes = 0
+}
+
+// Switch to pipeline mode once the logs of the leader and follower match.
+func (p *progress) replicate() {
+	p.pipeline = true
+	p.paused = false
+}
@@ -54,5 +54,10 @@ func (e *Election) Vote(member string) (passed bool, err error) {
 	e.quorum[member] = true
 	e.ballots++
 
-	return e.ballots >= uint16((len(e.quorum)/2)+1), nil
+	return e.Passed(), nil
+}
+
+// Passed returns true if a majority of the quorum has cast accept ballots.
+func (e *Election) Passed() bool {
+	return e.ballots >= uint16((len(e.quorum)/2)+1)
 }
@@ -50,9 +50,11 @@ func TestElection(t *testing.T) {
 		require.False(t, passed)
 		require.Error(t, err)
 
+		require.False(t, election.Passed())
 		passed, err = election.Vote("opal")
 		require.True(t, passed)
 		require.NoError(t, err)
+		require.True(t, election.Passed())
 
 		passed, err = election.Vote("opal")
 		require.False(t, passed)
@@ -105,3 +107,54 @@ func TestElectionSize(t *testing.T) {
 	t.Run("Q99", makeTest(99, 50))
 	t.Run("Q256", makeTest(256, 129))
 }
+
+func TestJointElection(t *testing.T) {
+	t.Run("Single", func(t *testing.T) {
+		joint := NewJoint(New("jade", "kira", "opal"), nil)
+		require.False(t, joint.IsJoint())
+		require.Equal(t, []string{"jade", "kira", "opal"}, joint.Hosts())
+
+		election := joint.Election()
+		passed, err := election.Vote("jade")
+		require.NoError(t, err)
+		require.False(t, passed)
+
+		passed, err = election.Vote("opal")
+		require.NoError(t, err)
+		require.True(t, passed)
+	})
+
+	t.Run("Joint", func(t *testing.T) {
+		joint := NewJoint(New("jade", "kira", "opal"), New("kira", "opal", "ruby", "onyx"))
+		require.True(t, joint.IsJoint())
+		require.True(t, joint.Contains("jade"))
+		require.True(t, joint.Contains("ruby"))
+		require.False(t, joint.Contains("artemis"))
+		require.Equal(t, []string{"jade", "kira", "onyx", "opal", "ruby"}, joint.Hosts())
+
+		election := joint.Election()
+		_, err := election.Vote("artemis")
+		require.EqualError(t, err, `"artemis" is not a member of the quorum`)
+
+		// A majority of the current quorum is not enough to pass the election
+		passed, err := election.Vote("jade")
+		require.NoError(t, err)
+		require.False(t, passed)
+
+		passed, err = election.Vote("kira")
+		require.NoError(t, err)
+		require.False(t, passed)
+
+		_, err = election.Vote("kira")
+		require.EqualError(t, err, `"kira" has already voted in this election`)
+
+		// The election passes once a majority of the next quorum has voted
+		passed, err = election.Vote("ruby")
+		require.NoError(t, err)
+		require.False(t, passed)
+
+		passed, err = election.Vote("onyx")
+		require.NoError(t, err)
+		require.True(t, passed)
+	})
+}
@@ -0,0 +1,127 @@
+package quorum
+
+import (
+	"fmt"
+	"sort"
+)
+
+// NewJoint creates the configuration of a quorum from the current hosts and the hosts
+// that the quorum is changing to. If next is nil, the quorum is not being changed.
+func NewJoint(current, next *Quorum) *Joint {
+	return &Joint{current: current, next: next}
+}
+
+// Joint is the configuration of a quorum whose membership may be changing. While the
+// membership is changing, decisions require a majority of both the current hosts and
+// the next hosts (joint consensus) so that the old and new quorums cannot make
+// decisions independently of each other.
+type Joint struct {
+	current *Quorum // The hosts in the current configuration
+	next    *Quorum // The hosts in the next configuration, nil if not changing
+}
+
+// Election creates a vote that passes once a majority of every quorum in the joint
+// configuration has cast accept ballots.
+func (j *Joint) Election() *JointElection {
+	elections := make([]*Election, 0, 2)
+	for _, q := range j.Quorums() {
+		elections = append(elections, q.Election())
+	}
+	return &JointElection{elections: elections}
+}
+
+//===========================================================================
+// Read-Only Access to Joint Properties
+//===========================================================================
+
+// Current returns the quorum of the current configuration.
+func (j *Joint) Current() *Quorum {
+	return j.current
+}
+
+// Next returns the quorum that is being changed to or nil if the membership is not
+// being changed.
+func (j *Joint) Next() *Quorum {
+	return j.next
+}
+
+// IsJoint returns true if the membership of the quorum is being changed.
+func (j *Joint) IsJoint() bool {
+	return j.next != nil
+}
+
+// Quorums returns every quorum in the configuration that a majority is required from.
+func (j *Joint) Quorums() []*Quorum {
+	if j.next == nil {
+		return []*Quorum{j.current}
+	}
+	return []*Quorum{j.current, j.next}
+}
+
+// Hosts returns the sorted hosts that are members of any quorum in the configuration.
+func (j *Joint) Hosts() (hosts []string) {
+	seen := make(map[string]struct{})
+	for _, q := range j.Quorums() {
+		for host := range q.hosts {
+			if _, ok := seen[host]; !ok {
+				seen[host] = exists
+				hosts = append(hosts, host)
+			}
+		}
+	}
+
+	sort.Strings(hosts)
+	return hosts
+}
+
+// Contains returns true if the host is a member of any quorum in the configuration.
+func (j *Joint) Contains(host string) bool {
+	for _, q := range j.Quorums() {
+		if q.Contains(host) {
+			return true
+		}
+	}
+	return false
+}
+
+//===========================================================================
+// Joint Elections
+//===========================================================================
+
+// JointElection is a vote that requires a majority of every quorum in a joint
+// configuration; a host that is a member of several quorums votes in each of them.
+type JointElection struct {
+	elections []*Election
+}
+
+// Vote records the vote for the given member in every quorum that it belongs to; will
+// return an error if the member is not part of any quorum or has already voted. Passed
+// will return true if a majority of every quorum has voted.
+func (e *JointElection) Vote(member string) (passed bool, err error) {
+	var voted bool
+	for _, election := range e.elections {
+		if _, isMember := election.quorum[member]; !isMember {
+			continue
+		}
+
+		if _, err = election.Vote(member); err != nil {
+			return false, err
+		}
+		voted = true
+	}
+
+	if !voted {
+		return false, fmt.Errorf("%q is not a member of the quorum", member)
+	}
+	return e.Passed(), nil
+}
+
+// Passed returns true if a majority of every quorum has cast accept ballots.
+func (e *JointElection) Passed() bool {
+	for _, election := range e.elections {
+		if !election.Passed() {
+			return false
+		}
+	}
+	return true
+}
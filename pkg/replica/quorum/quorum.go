@@ -35,6 +35,18 @@ func New(hosts ...string) (quorum *Quorum) {
 	return quorum
 }
 
+// LastID returns the id of the most recently created quorum so that the quorum id
+// sequence can be persisted.
+func LastID() uint64 {
+	return idSequence.Current()
+}
+
+// ResumeIDs advances the quorum id sequence past the specified id, e.g. the id that
+// was persisted before the process restarted, so that quorum ids are not reused.
+func ResumeIDs(after uint64) {
+	idSequence.Advance(after)
+}
+
 // Quorum represents a set of hosts that are configured to work together to
 // make decisions. This base structure can be refined on a per-consensus
 // basis, e.g. for leader-oriented quorums or other quorum types. Hosts are
@@ -35,6 +35,18 @@ func TestQuorum(t *testing.T) {
 		require.NotEqual(t, q3.ID(), q4.ID())
 	})
 
+	t.Run("Resume", func(t *testing.T) {
+		q1 := New("192.168.1.1")
+		require.Equal(t, q1.ID(), LastID())
+
+		// Resuming from an earlier id must not reuse ids.
+		ResumeIDs(q1.ID() - 1)
+		require.Greater(t, New("192.168.1.1").ID(), q1.ID())
+
+		ResumeIDs(LastID() + 100)
+		require.Equal(t, LastID()+1, New("192.168.1.1").ID())
+	})
+
 	t.Run("Contains", func(t *testing.T) {
 		q1 := New("192.168.1.1", "192.168.1.2", "192.168.1.3")
 		require.True(t, q1.Contains("192.168.1.1"))
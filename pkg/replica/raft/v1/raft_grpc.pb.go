@@ -19,8 +19,12 @@ import (
 const _ = grpc.SupportPackageIsVersion8
 
 const (
-	Raft_RequestVote_FullMethodName   = "/raft.v1.Raft/RequestVote"
-	Raft_AppendEntries_FullMethodName = "/raft.v1.Raft/AppendEntries"
+	Raft_RequestVote_FullMethodName     = "/raft.v1.Raft/RequestVote"
+	Raft_AppendEntries_FullMethodName   = "/raft.v1.Raft/AppendEntries"
+	Raft_AppendStream_FullMethodName    = "/raft.v1.Raft/AppendStream"
+	Raft_Forward_FullMethodName         = "/raft.v1.Raft/Forward"
+	Raft_InstallSnapshot_FullMethodName = "/raft.v1.Raft/InstallSnapshot"
+	Raft_TimeoutNow_FullMethodName      = "/raft.v1.Raft/TimeoutNow"
 )
 
 // RaftClient is the client API for Raft service.
@@ -29,6 +33,10 @@ const (
 type RaftClient interface {
 	RequestVote(ctx context.Context, in *VoteRequest, opts ...grpc.CallOption) (*VoteReply, error)
 	AppendEntries(ctx context.Context, in *AppendRequest, opts ...grpc.CallOption) (*AppendReply, error)
+	AppendStream(ctx context.Context, opts ...grpc.CallOption) (Raft_AppendStreamClient, error)
+	Forward(ctx context.Context, in *ForwardRequest, opts ...grpc.CallOption) (*ForwardReply, error)
+	InstallSnapshot(ctx context.Context, opts ...grpc.CallOption) (Raft_InstallSnapshotClient, error)
+	TimeoutNow(ctx context.Context, in *TimeoutNowRequest, opts ...grpc.CallOption) (*TimeoutNowReply, error)
 }
 
 type raftClient struct {
@@ -59,12 +67,103 @@ func (c *raftClient) AppendEntries(ctx context.Context, in *AppendRequest, opts
 	return out, nil
 }
 
+func (c *raftClient) AppendStream(ctx context.Context, opts ...grpc.CallOption) (Raft_AppendStreamClient, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Raft_ServiceDesc.Streams[0], Raft_AppendStream_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &raftAppendStreamClient{ClientStream: stream}
+	return x, nil
+}
+
+type Raft_AppendStreamClient interface {
+	Send(*AppendRequest) error
+	Recv() (*AppendReply, error)
+	grpc.ClientStream
+}
+
+type raftAppendStreamClient struct {
+	grpc.ClientStream
+}
+
+func (x *raftAppendStreamClient) Send(m *AppendRequest) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *raftAppendStreamClient) Recv() (*AppendReply, error) {
+	m := new(AppendReply)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *raftClient) Forward(ctx context.Context, in *ForwardRequest, opts ...grpc.CallOption) (*ForwardReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ForwardReply)
+	err := c.cc.Invoke(ctx, Raft_Forward_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *raftClient) InstallSnapshot(ctx context.Context, opts ...grpc.CallOption) (Raft_InstallSnapshotClient, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Raft_ServiceDesc.Streams[1], Raft_InstallSnapshot_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &raftInstallSnapshotClient{ClientStream: stream}
+	return x, nil
+}
+
+type Raft_InstallSnapshotClient interface {
+	Send(*SnapshotChunk) error
+	CloseAndRecv() (*InstallReply, error)
+	grpc.ClientStream
+}
+
+type raftInstallSnapshotClient struct {
+	grpc.ClientStream
+}
+
+func (x *raftInstallSnapshotClient) Send(m *SnapshotChunk) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *raftInstallSnapshotClient) CloseAndRecv() (*InstallReply, error) {
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	m := new(InstallReply)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *raftClient) TimeoutNow(ctx context.Context, in *TimeoutNowRequest, opts ...grpc.CallOption) (*TimeoutNowReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(TimeoutNowReply)
+	err := c.cc.Invoke(ctx, Raft_TimeoutNow_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // RaftServer is the server API for Raft service.
 // All implementations must embed UnimplementedRaftServer
 // for forward compatibility
 type RaftServer interface {
 	RequestVote(context.Context, *VoteRequest) (*VoteReply, error)
 	AppendEntries(context.Context, *AppendRequest) (*AppendReply, error)
+	AppendStream(Raft_AppendStreamServer) error
+	Forward(context.Context, *ForwardRequest) (*ForwardReply, error)
+	InstallSnapshot(Raft_InstallSnapshotServer) error
+	TimeoutNow(context.Context, *TimeoutNowRequest) (*TimeoutNowReply, error)
 	mustEmbedUnimplementedRaftServer()
 }
 
@@ -78,6 +177,18 @@ func (UnimplementedRaftServer) RequestVote(context.Context, *VoteRequest) (*Vote
 func (UnimplementedRaftServer) AppendEntries(context.Context, *AppendRequest) (*AppendReply, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method AppendEntries not implemented")
 }
+func (UnimplementedRaftServer) AppendStream(Raft_AppendStreamServer) error {
+	return status.Errorf(codes.Unimplemented, "method AppendStream not implemented")
+}
+func (UnimplementedRaftServer) Forward(context.Context, *ForwardRequest) (*ForwardReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Forward not implemented")
+}
+func (UnimplementedRaftServer) InstallSnapshot(Raft_InstallSnapshotServer) error {
+	return status.Errorf(codes.Unimplemented, "method InstallSnapshot not implemented")
+}
+func (UnimplementedRaftServer) TimeoutNow(context.Context, *TimeoutNowRequest) (*TimeoutNowReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method TimeoutNow not implemented")
+}
 func (UnimplementedRaftServer) mustEmbedUnimplementedRaftServer() {}
 
 // UnsafeRaftServer may be embedded to opt out of forward compatibility for this service.
@@ -127,6 +238,94 @@ func _Raft_AppendEntries_Handler(srv interface{}, ctx context.Context, dec func(
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Raft_AppendStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(RaftServer).AppendStream(&raftAppendStreamServer{ServerStream: stream})
+}
+
+type Raft_AppendStreamServer interface {
+	Send(*AppendReply) error
+	Recv() (*AppendRequest, error)
+	grpc.ServerStream
+}
+
+type raftAppendStreamServer struct {
+	grpc.ServerStream
+}
+
+func (x *raftAppendStreamServer) Send(m *AppendReply) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *raftAppendStreamServer) Recv() (*AppendRequest, error) {
+	m := new(AppendRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _Raft_Forward_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ForwardRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RaftServer).Forward(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Raft_Forward_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RaftServer).Forward(ctx, req.(*ForwardRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Raft_InstallSnapshot_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(RaftServer).InstallSnapshot(&raftInstallSnapshotServer{ServerStream: stream})
+}
+
+type Raft_InstallSnapshotServer interface {
+	SendAndClose(*InstallReply) error
+	Recv() (*SnapshotChunk, error)
+	grpc.ServerStream
+}
+
+type raftInstallSnapshotServer struct {
+	grpc.ServerStream
+}
+
+func (x *raftInstallSnapshotServer) SendAndClose(m *InstallReply) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *raftInstallSnapshotServer) Recv() (*SnapshotChunk, error) {
+	m := new(SnapshotChunk)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _Raft_TimeoutNow_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TimeoutNowRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RaftServer).TimeoutNow(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Raft_TimeoutNow_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RaftServer).TimeoutNow(ctx, req.(*TimeoutNowRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // Raft_ServiceDesc is the grpc.ServiceDesc for Raft service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -142,7 +341,27 @@ var Raft_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "AppendEntries",
 			Handler:    _Raft_AppendEntries_Handler,
 		},
+		{
+			MethodName: "Forward",
+			Handler:    _Raft_Forward_Handler,
+		},
+		{
+			MethodName: "TimeoutNow",
+			Handler:    _Raft_TimeoutNow_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "AppendStream",
+			Handler:       _Raft_AppendStream_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "InstallSnapshot",
+			Handler:       _Raft_InstallSnapshot_Handler,
+			ClientStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "raft/v1/raft.proto",
 }
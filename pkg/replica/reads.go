@@ -0,0 +1,206 @@
+package replica
+
+import (
+	"context"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/bbengfort/otterdb/pkg/replica/events"
+)
+
+// Staleness returns an upper bound on how far behind the leader the local state machine
+// may be. Followers track the last time they were caught up with the commit index of
+// the leader and leaders track the last time a quorum acknowledged their leadership. If
+// the replica has never been in contact with a leader the maximum duration is returned.
+// If replication is disabled the local replica is the only replica so it is never stale.
+func (r *Replica) Staleness() time.Duration {
+	if !r.conf.Enabled {
+		return 0
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if r.syncedAt.IsZero() {
+		return math.MaxInt64
+	}
+	return time.Since(r.syncedAt)
+}
+
+// ConfirmRead blocks until it is safe to serve a consistent read from the local state
+// machine: the local replica must be the leader and must have applied every entry that
+// was committed before the read was requested. Leadership is confirmed with a round of
+// heartbeats unless lease is true and the leader holds a valid lease, which requires
+// CheckQuorum. If the replica is not the leader, ErrNotLeader is returned.
+func (r *Replica) ConfirmRead(ctx context.Context, lease bool) (err error) {
+	if !r.conf.Enabled {
+		return nil
+	}
+
+	reply := make(chan error, 1)
+	if err = r.Dispatch(&events.Message{Type: events.ReadIndex, Source: reply, Value: lease}); err != nil {
+		return err
+	}
+
+	select {
+	case err = <-reply:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// A read that is waiting for leadership to be confirmed and for the read index to be
+// applied to the state machine before it can be served.
+type readRequest struct {
+	index   uint64
+	started time.Time
+	reply   chan<- error
+}
+
+// Handle a read index request: the read index is the commit index when the request is
+// received, or the first entry of the leader's term if it has not been committed yet,
+// since the commit index of a new leader may be behind the previous leader. If the
+// leader holds a valid lease, leadership does not need to be confirmed.
+//
+// NOTE: This method is not thread-safe and should only be called from the event loop.
+func (r *Replica) onReadIndex(e events.Event) (err error) {
+	var (
+		lease bool
+		reply chan<- error
+	)
+
+	if lease, reply, err = readIndex(e); err != nil {
+		return err
+	}
+
+	if r.state != Leader {
+		reply <- ErrNotLeader
+		return nil
+	}
+
+	req := &readRequest{index: max(r.log.CommitIndex(), r.termStart), started: time.Now(), reply: reply}
+	if lease && r.leaseValid(req.started) {
+		// The lease confirms leadership as of the start of the request.
+		req.started = time.Time{}
+	}
+
+	r.reads = append(r.reads, req)
+	r.serveReads()
+
+	// Send a round of heartbeats to confirm leadership if the read is still waiting.
+	if len(r.reads) > 0 {
+		r.broadcastAppendEntries(true)
+	}
+	return nil
+}
+
+// Reply to all pending reads whose leadership has been confirmed by a quorum and whose
+// read index has been applied to the state machine.
+//
+// NOTE: This method is not thread-safe and should only be called from the event loop.
+func (r *Replica) serveReads() {
+	if len(r.reads) == 0 {
+		return
+	}
+
+	contact := r.quorumContact()
+	lastApplied := r.log.LastApplied()
+
+	waiting := r.reads[:0]
+	for _, req := range r.reads {
+		if !contact.Before(req.started) && lastApplied >= req.index {
+			req.reply <- nil
+			continue
+		}
+		waiting = append(waiting, req)
+	}
+
+	// Clear the references to served reads so they can be garbage collected.
+	for i := len(waiting); i < len(r.reads); i++ {
+		r.reads[i] = nil
+	}
+	r.reads = waiting
+}
+
+// Fail all pending reads, e.g. when the replica is no longer the leader.
+//
+// NOTE: This method is not thread-safe and should only be called from the event loop.
+func (r *Replica) dropReads(err error) {
+	for _, req := range r.reads {
+		req.reply <- err
+	}
+	r.reads = nil
+}
+
+// Record that a peer acknowledged an append entries request in the current term that
+// was sent at the specified time and update the last time the leader was known to be in
+// contact with a quorum.
+//
+// NOTE: This method is not thread-safe and should only be called from the event loop.
+func (r *Replica) ack(peer string, sent time.Time) {
+	if sent.After(r.acks[peer]) {
+		r.acks[peer] = sent
+	}
+
+	contact := r.quorumContact()
+	r.mu.Lock()
+	if contact.After(r.syncedAt) {
+		r.syncedAt = contact
+	}
+	r.mu.Unlock()
+}
+
+// Returns the latest time that a quorum of replicas acknowledged the leadership of the
+// local replica, e.g. the send time of the latest heartbeat that a majority of peers
+// have replied to. The leader always acknowledges itself. While the membership of the
+// quorum is changing, a majority of both the current and next members is required.
+//
+// NOTE: This method is not thread-safe and should only be called from the event loop.
+func (r *Replica) quorumContact() (contact time.Time) {
+	contact = time.Now()
+	for _, q := range r.quorum.Quorums() {
+		hosts := q.Hosts()
+		if len(hosts) == 0 {
+			continue
+		}
+
+		contacts := make([]time.Time, 0, len(hosts))
+		for _, host := range hosts {
+			if host == r.name {
+				contacts = append(contacts, time.Now())
+				continue
+			}
+			contacts = append(contacts, r.acks[host])
+		}
+
+		sort.Slice(contacts, func(i, j int) bool { return contacts[i].After(contacts[j]) })
+		if majority := contacts[q.Size()/2]; majority.Before(contact) {
+			contact = majority
+		}
+	}
+	return contact
+}
+
+// Returns true if the leader holds a valid lease at the specified time; the lease is
+// only valid once an entry from the leader's term has been committed. Voters only refuse
+// to vote for other candidates while they are in the lease of the leader if CheckQuorum
+// is enabled, otherwise a new leader may be elected at any time so leases are never
+// valid and lease reads confirm leadership as linearizable reads do. Voters grant their
+// votes to the target of a leadership transfer even while the leader's lease is valid,
+// so the lease is not valid during a transfer or, if the target was told to start an
+// election, until a quorum acknowledges a heartbeat sent after the transfer ended.
+//
+// NOTE: This method is not thread-safe and should only be called from the event loop.
+func (r *Replica) leaseValid(now time.Time) bool {
+	if !r.conf.CheckQuorum || r.state != Leader || r.transfer != nil || r.log.CommitIndex() < r.termStart {
+		return false
+	}
+
+	contact := r.quorumContact()
+	if !contact.After(r.leaseFence) {
+		return false
+	}
+	return now.Before(contact.Add(r.leaseTimeout()))
+}
@@ -4,19 +4,35 @@ Package replica manages replication between peers in an otterdb cluster.
 package replica
 
 import (
+	"crypto/tls"
 	"fmt"
 	"net"
+	"path/filepath"
+	"sync"
 	"time"
 
 	"github.com/bbengfort/otterdb/pkg/config"
 	"github.com/bbengfort/otterdb/pkg/grpc/health/v1"
 	"github.com/bbengfort/otterdb/pkg/replica/events"
+	"github.com/bbengfort/otterdb/pkg/replica/logstore"
+	"github.com/bbengfort/otterdb/pkg/replica/metastore"
+	"github.com/bbengfort/otterdb/pkg/replica/peers"
+	"github.com/bbengfort/otterdb/pkg/replica/quorum"
 	"github.com/bbengfort/otterdb/pkg/replica/raft/v1"
+	"github.com/bbengfort/otterdb/pkg/replica/snapshot"
+	"github.com/bbengfort/otterdb/pkg/replica/ticker"
+	"github.com/bbengfort/otterdb/pkg/replica/wal"
 
 	"github.com/rs/zerolog/log"
 	"google.golang.org/grpc"
 )
 
+// The name of the directory in the data directory where the write-ahead log is stored.
+const walDir = "wal"
+
+// The name of the file in the data directory where the replica metadata is stored.
+const metaFile = "replica.meta"
+
 type Replica struct {
 	health.ProbeServer
 	raft.UnimplementedRaftServer
@@ -24,22 +40,186 @@ type Replica struct {
 	conf    config.ReplicaConfig
 	srv     *grpc.Server
 	started time.Time
-	events  chan events.Event
-	state   State
+
+	// The "one big pipe" event channel and the done channel that is closed when the
+	// event loop exits. The pipe mutex guards the events channel against sends after
+	// the channel has been closed on shutdown.
+	pipe   sync.RWMutex
+	events chan events.Event
+	done   chan struct{}
+
+	// Closed when the replica is shut down to end long-lived streams, which would
+	// otherwise prevent the gRPC server from stopping gracefully.
+	stopping chan struct{}
+
+	// Consensus state that is only modified by the event loop; the mutex allows other
+	// go routines to read the state, term, leader, and commit index without racing the
+	// event loop.
+	mu       sync.RWMutex
+	state    State
+	term     uint64
+	leader   string
+	votedFor string
+
+	name      string                // The name of the local replica in the quorum
+	clusterID string                // The cluster the replica was initialized with
+	peers     peers.Peers           // The remote peers in the quorum (excludes the local replica)
+	quorum    *quorum.Joint         // The quorum that includes the local replica and all peers
+	votes     *quorum.JointElection // The votes cast for the local replica when a candidate
+	log       *Log                  // The replicated log of commands
+	meta      *metastore.Store      // Durably stores the term, vote, and quorum id sequence
+	fsm       StateMachine          // The state machine that committed entries are applied to
+	local     sync.Mutex            // Serializes commands applied without consensus
+	heartbeat *ticker.Ticker        // Sends heartbeat timeouts when the replica is the leader
+	election  *ticker.Ticker        // Sends election timeouts when the replica is not the leader
+
+	// The members of the quorum, which are changed by replicating configuration entries.
+	initial     *raft.Configuration                      // The configuration loaded from the peers file
+	config      *raft.Configuration                      // The latest configuration in the log
+	configIndex uint64                                   // The index of the entry of the latest configuration
+	rank        int                                      // The number of voting members that take precedence over the replica
+	dialOptions func(peer *peers.Peer) []grpc.DialOption // Options to connect to remote peers
+	conns       *peers.Manager                           // Maintains and monitors the connections to remote peers
+	tls         *tls.Config                              // Mutual TLS configuration for connections between replicas
+
+	// Snapshots of the state machine that allow the log to be compacted.
+	snapshots     *snapshot.Store // The snapshot store, nil if snapshots are disabled
+	snapshotIndex uint64          // The index of the last entry in the latest snapshot
+	snapshotting  bool            // True while a snapshot is being taken in the background
+
+	// Volatile leader state that is reinitialized after every election.
+	nextIndex  map[string]uint64    // The index of the next entry to send to each peer
+	matchIndex map[string]uint64    // The index of the latest entry replicated on each peer
+	progress   map[string]*progress // The flow control of append entries requests to each peer
+	pending    map[uint64]*pending  // Proposals waiting for their entries to be committed
+	acks       map[string]time.Time // The send time of the latest append acknowledged by each peer
+	reads      []*readRequest       // Reads waiting for leadership to be confirmed
+	termStart  uint64               // The index of the first entry of the leader's term
+	elected    time.Time            // The time that the local replica was elected leader
+	installing map[string]bool      // Peers that a snapshot is currently being sent to
+	transfer   *transfer            // The leadership transfer in progress, if any
+
+	// The last time a leadership transfer timed out, which delays yielding leadership.
+	transferFailed time.Time
+
+	// The end of the latest transfer that told its target to start an election; lease
+	// reads are not served until a quorum acknowledges a heartbeat sent after it.
+	leaseFence time.Time
+
+	// The last time the replica heard from the leader of the current term.
+	contact time.Time
+
+	// The last time the replica was known to be up to date with the leader, guarded by mu.
+	syncedAt time.Time
+}
+
+// Option configures the replica when it is created.
+type Option func(r *Replica)
+
+// WithLogStore specifies the store for the entries of the replicated log instead of
+// opening the write-ahead log in the data directory, e.g. to use an in-memory store for
+// testing. The replica closes the store when it is shutdown.
+func WithLogStore(store logstore.LogStore) Option {
+	return func(r *Replica) {
+		r.log = NewLog(store)
+	}
+}
+
+// WithDialOptions specifies the options used to connect to each remote peer instead of
+// an insecure connection to the address of the peer, e.g. to connect via bufconn for
+// testing. The options are also used to connect to peers that join the quorum.
+func WithDialOptions(opts func(peer *peers.Peer) []grpc.DialOption) Option {
+	return func(r *Replica) {
+		r.dialOptions = opts
+	}
+}
+
+// WithStateMachine specifies the state machine that committed entries are applied to.
+// If no state machine is specified, entries are marked as applied without any effect.
+func WithStateMachine(fsm StateMachine) Option {
+	return func(r *Replica) {
+		r.fsm = fsm
+	}
 }
 
-func New(conf config.ReplicaConfig) (r *Replica, err error) {
+func New(conf config.ReplicaConfig, options ...Option) (r *Replica, err error) {
 	// Must supply a valid configuration.
 	if err = conf.Validate(); err != nil {
 		return nil, err
 	}
 
-	r = &Replica{conf: conf}
+	r = &Replica{conf: conf, name: conf.Name, stopping: make(chan struct{})}
+	for _, option := range options {
+		option(r)
+	}
+	r.conns = peers.NewManager(r.peerDialOptions)
+
+	// Load the quorum from the peers configuration and recover the durable log and
+	// state of the replica if replication is enabled.
+	if conf.Enabled {
+		if err = r.loadTLS(); err != nil {
+			return nil, err
+		}
+
+		if err = r.loadPeers(); err != nil {
+			return nil, err
+		}
+
+		if r.log == nil {
+			var store logstore.LogStore
+			if store, err = wal.Open(filepath.Join(conf.DataDir, walDir)); err != nil {
+				return nil, fmt.Errorf("could not open write-ahead log: %w", err)
+			}
+			r.log = NewLog(store)
+		}
+
+		if err = r.loadMeta(filepath.Join(conf.DataDir, metaFile)); err != nil {
+			return nil, fmt.Errorf("could not load replica metadata: %w", err)
+		}
+
+		if err = r.openSnapshots(filepath.Join(conf.DataDir, snapshotDir)); err != nil {
+			return nil, fmt.Errorf("could not open snapshots: %w", err)
+		}
+	}
+
+	if r.log == nil {
+		r.log = NewLog(logstore.NewMemory())
+	}
+
+	// Entries that have been applied to the state machine are not applied again.
+	if r.fsm != nil {
+		lastApplied := r.fsm.LastApplied()
+		if meta := r.log.Meta(); meta.LastApplied > lastApplied {
+			log.Warn().
+				Uint64("log_applied", meta.LastApplied).
+				Uint64("fsm_applied", lastApplied).
+				Msg("state machine is behind the log, entries will be reapplied")
+		}
+
+		// The state machine cannot be caught up from the log if it is missing entries
+		// that have been compacted, e.g. if the database was removed.
+		if lastApplied+1 < r.log.FirstIndex() {
+			if err = r.restoreSnapshot(); err != nil {
+				return nil, fmt.Errorf("could not restore state machine: %w", err)
+			}
+			lastApplied = r.fsm.LastApplied()
+		}
+		r.log.Restore(lastApplied)
+	}
+
+	// The members of the quorum are the latest configuration in the log, which may have
+	// changed since the peers were loaded; connections to remote peers are made lazily.
+	if conf.Enabled {
+		if err = r.reloadConfig(); err != nil {
+			return nil, fmt.Errorf("could not load configuration: %w", err)
+		}
+	}
 
 	// Prepare to receive gRPC requests and configure RPCs
 	opts := make([]grpc.ServerOption, 0, 4)
 	// opts = append(opts, grpc.ChainUnaryInterceptor(s.UnaryInterceptors()...))
 	// opts = append(opts, grpc.ChainStreamInterceptor(s.StreamInterceptors()...))
+	opts = append(opts, r.serverOptions()...)
 	r.srv = grpc.NewServer(opts...)
 
 	// Initialize the gRPC services
@@ -53,15 +233,46 @@ func New(conf config.ReplicaConfig) (r *Replica, err error) {
 	return r, nil
 }
 
+// Load the peers from the configured path as the initial configuration of the quorum.
+// If the local replica is not one of the peers, it is not a member of the quorum and
+// does not start elections until it is added to the quorum by the leader.
+func (r *Replica) loadPeers() (err error) {
+	var all peers.Peers
+	if all, err = peers.Load(r.conf.Peers); err != nil {
+		return fmt.Errorf("could not load peers from %s: %w", r.conf.Peers, err)
+	}
+
+	if _, err = all.Get(r.name); err != nil {
+		log.Info().Str("name", r.name).Msg("replica is not a member of the quorum and is waiting to be added")
+	}
+
+	r.initial = &raft.Configuration{Current: all.Members()}
+	return nil
+}
+
+// Load the term, vote, quorum id sequence, and cluster id of the replica from the
+// metadata store.
+func (r *Replica) loadMeta(path string) (err error) {
+	if r.meta, err = metastore.Open(path); err != nil {
+		return err
+	}
+
+	state := r.meta.State()
+	r.term, r.votedFor, r.clusterID = state.Term, state.VotedFor, state.ClusterID
+	quorum.ResumeIDs(state.QuorumID)
+
+	if r.clusterID == "" {
+		log.Warn().Str("name", r.name).Msg("replica has not been initialized with a cluster id, run otterdb init")
+	}
+	return nil
+}
+
 func (r *Replica) Serve(errc chan<- error) (err error) {
 	if !r.conf.Enabled {
 		log.Warn().Bool("enabled", r.conf.Enabled).Msg("otterdb replication is disabled")
 		return nil
 	}
 
-	// Create the events channel to run the event loop
-	r.events = make(chan events.Event, events.BufferSize)
-
 	// Listen for TCP requests (other sockets such as bufconn for tests should use Run)
 	var sock net.Listener
 	if sock, err = net.Listen("tcp", r.conf.BindAddr); err != nil {
@@ -71,15 +282,12 @@ func (r *Replica) Serve(errc chan<- error) (err error) {
 	// Run the server on the opened socket
 	go r.Run(errc, sock)
 
-	// Now that the server is running mark healthy and set the start time to track uptime
-	r.started = time.Now()
-	r.setState(Running)
-	r.Healthy()
-
-	// Run the event handling loop for "one big pipe synchronization"
-	go r.EventLoop(errc)
+	// Start the consensus protocol
+	if err = r.start(errc); err != nil {
+		return err
+	}
 
-	log.Info().Str("listen", r.conf.BindAddr).Msg("otterdb replica server started")
+	log.Info().Str("listen", r.conf.BindAddr).Str("name", r.name).Msg("otterdb replica server started")
 	return nil
 }
 
@@ -93,14 +301,43 @@ func (r *Replica) Run(errc chan<- error, sock net.Listener) {
 	}
 }
 
+// Start the event loop and bootstrap the replica into the running state. The gRPC
+// server and peer connections must be created before the replica is started.
+func (r *Replica) start(errc chan<- error) (err error) {
+	// Create the events channel to run the event loop
+	r.pipe.Lock()
+	r.events = make(chan events.Event, events.BufferSize)
+	r.done = make(chan struct{})
+	r.pipe.Unlock()
+
+	// Set the replica to running before the event loop starts to avoid races.
+	if err = r.setState(Running); err != nil {
+		return err
+	}
+
+	// Now that the server is running mark healthy and set the start time to track uptime
+	r.started = time.Now()
+	r.Healthy()
+
+	// Run the event handling loop for "one big pipe synchronization"
+	go r.EventLoop(errc)
+	return nil
+}
+
 // Run the one big pipe event loop to handle events
 func (r *Replica) EventLoop(errc chan<- error) {
+	// The events channel is read under the lock since it is cleared on shutdown.
+	r.pipe.RLock()
+	pipe, done := r.events, r.done
+	r.pipe.RUnlock()
+
+	defer close(done)
 	if r.conf.Aggregate {
-		if err := events.AggregatingLoop(r.events, r); err != nil {
+		if err := events.AggregatingLoop(pipe, r); err != nil {
 			errc <- err
 		}
 	} else {
-		if err := events.Loop(r.events, r); err != nil {
+		if err := events.Loop(pipe, r); err != nil {
 			errc <- err
 		}
 	}
@@ -116,10 +353,96 @@ func (r *Replica) Shutdown() (err error) {
 	log.Debug().Msg("gracefully shutting down otterdb replica server")
 	r.NotHealthy()
 
-	// Stop the gRPC server
+	// Stop the gRPC server once the append entries streams have ended
+	close(r.stopping)
 	r.srv.GracefulStop()
 
-	// Stop the event loop and set the state to stopped
+	// Stop the event loop and wait for it to finish handling events
+	r.pipe.Lock()
+	if r.events == nil {
+		// The replica was never started so only the peers and stores need to be closed.
+		r.pipe.Unlock()
+		return r.close()
+	}
 	close(r.events)
-	return r.setState(Stopped)
+	r.events = nil
+	r.pipe.Unlock()
+	<-r.done
+
+	// Now that the event loop is stopped it is safe to modify the state.
+	if err = r.setState(Stopped); err != nil {
+		return err
+	}
+	return r.close()
+}
+
+// Close the connections to the peers, the log, and the metadata store.
+func (r *Replica) close() (err error) {
+	if err = r.conns.Close(); err != nil {
+		return err
+	}
+
+	if err = r.log.Close(); err != nil {
+		return err
+	}
+
+	if r.meta != nil {
+		return r.meta.Close()
+	}
+	return nil
+}
+
+//===========================================================================
+// Accessors
+//===========================================================================
+
+// Name returns the name of the local replica in the quorum.
+func (r *Replica) Name() string {
+	return r.name
+}
+
+// State returns the current consensus state of the replica.
+func (r *Replica) State() State {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.state
+}
+
+// Term returns the current term of the replica.
+func (r *Replica) Term() uint64 {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.term
+}
+
+// Leader returns the name of the leader of the current term if known.
+func (r *Replica) Leader() string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.leader
+}
+
+// PeerStatus returns the reachability, last contact, and round trip time of every
+// remote peer that the replica is connected to.
+func (r *Replica) PeerStatus() []peers.Status {
+	return r.conns.Status()
+}
+
+// CommitIndex returns the index of the last entry committed to the local log.
+func (r *Replica) CommitIndex() uint64 {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.log.CommitIndex()
+}
+
+// LastApplied returns the index of the last entry applied to the state machine.
+func (r *Replica) LastApplied() uint64 {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.log.LastApplied()
+}
+
+// IsLeader returns true if the local replica is the leader of the current term.
+func (r *Replica) IsLeader() bool {
+	return r.State() == Leader
 }
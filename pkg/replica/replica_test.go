@@ -0,0 +1,1401 @@
+package replica
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/binary"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bbengfort/otterdb/pkg/bufconn"
+	"github.com/bbengfort/otterdb/pkg/config"
+	health "github.com/bbengfort/otterdb/pkg/grpc/health/v1"
+	"github.com/bbengfort/otterdb/pkg/logger"
+	"github.com/bbengfort/otterdb/pkg/replica/events"
+	"github.com/bbengfort/otterdb/pkg/replica/logstore"
+	"github.com/bbengfort/otterdb/pkg/replica/metastore"
+	"github.com/bbengfort/otterdb/pkg/replica/peers"
+	"github.com/bbengfort/otterdb/pkg/replica/quorum"
+	"github.com/bbengfort/otterdb/pkg/replica/raft/v1"
+	"github.com/bbengfort/otterdb/pkg/replica/snapshot"
+	"github.com/bbengfort/otterdb/pkg/replica/ticker"
+
+	"github.com/oklog/ulid/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestMain(m *testing.M) {
+	logger.Discard()
+	exitVal := m.Run()
+	logger.ResetLogger()
+	os.Exit(exitVal)
+}
+
+func TestElection(t *testing.T) {
+	cluster := newCluster(t, "jade", "kira", "opal")
+	leader := cluster.waitForLeader(t, 5*time.Second)
+
+	// All replicas should agree on the leader and the term
+	term := leader.Term()
+	for _, r := range cluster.replicas {
+		require.Equal(t, leader.Name(), r.Leader(), "replica %s does not agree on leader", r.Name())
+		require.Equal(t, term, r.Term(), "replica %s does not agree on term", r.Name())
+		if r != leader {
+			require.Equal(t, Follower, r.State())
+		}
+	}
+}
+
+func TestSingleReplicaElection(t *testing.T) {
+	cluster := newCluster(t, "jade")
+	leader := cluster.waitForLeader(t, 2*time.Second)
+	require.Equal(t, "jade", leader.Name())
+	require.Equal(t, uint64(1), leader.Term())
+}
+
+func TestReplication(t *testing.T) {
+	cluster := newCluster(t, "jade", "kira", "opal")
+	leader := cluster.waitForLeader(t, 5*time.Second)
+
+	// Followers cannot commit entries
+	for _, r := range cluster.replicas {
+		if r != leader {
+			_, err := r.Commit(context.Background(), "put", []byte("foo"))
+			require.ErrorIs(t, err, ErrNotLeader)
+			break
+		}
+	}
+
+	// Commit entries concurrently so that they are aggregated
+	var wg sync.WaitGroup
+	for i := 0; i < 64; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			applied, err := leader.Commit(ctx, "put", []byte(fmt.Sprintf("value %d", i)))
+			assert.NoError(t, err, "could not commit entry")
+			if applied != nil {
+				assert.Equal(t, leader.Term(), applied.Entry.Term)
+				assert.Equal(t, applied.Entry.Index, applied.Result, "expected the result of the state machine")
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	// The noop entry plus all of the proposed entries should be in the log.
+	lastIndex := uint64(65)
+	cluster.waitForCommit(t, lastIndex, 5*time.Second)
+
+	// All replicas should apply the same entries in the same order.
+	cluster.waitForApplied(t, lastIndex, 5*time.Second)
+	expected := cluster.fsms[leader.Name()].applied()
+	require.Len(t, expected, int(lastIndex))
+	for name, fsm := range cluster.fsms {
+		actual := fsm.applied()
+		require.Len(t, actual, len(expected), "replica %s applied the wrong number of entries", name)
+		for i, entry := range actual {
+			require.Equal(t, uint64(i+1), entry.Index)
+			require.Equal(t, expected[i].Value, entry.Value, "replica %s applied a different entry", name)
+		}
+	}
+}
+
+func TestConfirmRead(t *testing.T) {
+	cluster := newCluster(t, "jade", "kira", "opal")
+	leader := cluster.waitForLeader(t, 5*time.Second)
+
+	applied, err := leader.Commit(context.Background(), "put", []byte("foo"))
+	require.NoError(t, err, "could not commit entry")
+
+	// Reads on the leader are served once all committed entries have been applied.
+	for _, lease := range []bool{false, true} {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		require.NoError(t, leader.ConfirmRead(ctx, lease), "could not confirm read")
+		require.GreaterOrEqual(t, leader.LastApplied(), applied.Entry.Index)
+		cancel()
+	}
+
+	// Followers cannot confirm reads but are not stale once they are caught up.
+	cluster.waitForApplied(t, applied.Entry.Index, 5*time.Second)
+	for _, r := range cluster.replicas {
+		if r != leader {
+			require.ErrorIs(t, r.ConfirmRead(context.Background(), false), ErrNotLeader)
+			require.ErrorIs(t, r.ConfirmRead(context.Background(), true), ErrNotLeader)
+			require.Less(t, r.Staleness(), time.Second, "follower should be in contact with the leader")
+		}
+	}
+	require.Less(t, leader.Staleness(), time.Second, "leader should be in contact with a quorum")
+}
+
+func TestForward(t *testing.T) {
+	cluster := newCluster(t, "jade", "kira", "opal")
+	leader := cluster.waitForLeader(t, 5*time.Second)
+
+	name, addr, err := leader.LeaderAddr()
+	require.ErrorIs(t, err, ErrNoLeader, "the leader cannot redirect to itself")
+	require.Empty(t, name)
+	require.Empty(t, addr)
+
+	for _, r := range cluster.replicas {
+		if r == leader {
+			continue
+		}
+
+		name, addr, err = r.LeaderAddr()
+		require.NoError(t, err, "could not get leader address")
+		require.Equal(t, leader.Name(), name)
+		require.Equal(t, leader.Name()+":2202", addr)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		applied, err := r.ForwardCommit(ctx, "put", []byte(r.Name()))
+		cancel()
+		require.NoError(t, err, "could not forward command to leader")
+		require.Equal(t, leader.Term(), applied.Entry.Term)
+		require.Equal(t, applied.Entry.Index, applied.Result, "expected the result of the leader's state machine")
+
+		entry, err := leader.log.Get(applied.Entry.Index)
+		require.NoError(t, err, "forwarded entry is not in the leader's log")
+		require.Equal(t, []byte(r.Name()), entry.Value)
+	}
+
+	// Commands forwarded to a follower are rejected.
+	for _, r := range cluster.replicas {
+		if r != leader {
+			_, err = r.Forward(context.Background(), &raft.ForwardRequest{Name: "put", Value: []byte("foo")})
+			require.ErrorIs(t, forwardError(err), ErrNotLeader)
+			break
+		}
+	}
+}
+
+func TestRecovery(t *testing.T) {
+	cluster := newDurableCluster(t, "jade")
+	leader := cluster.waitForLeader(t, 2*time.Second)
+
+	for i := 0; i < 8; i++ {
+		_, err := leader.Commit(context.Background(), "put", []byte(fmt.Sprintf("value %d", i)))
+		require.NoError(t, err, "could not commit entry")
+	}
+
+	// Stop the replica and reopen it from its data directory
+	term := leader.Term()
+	require.NoError(t, leader.Shutdown(), "could not shutdown replica")
+
+	fsm := cluster.fsms["jade"]
+	restarted, err := New(leader.conf, WithStateMachine(fsm))
+	require.NoError(t, err, "could not recover replica")
+	cluster.replicas[0] = restarted
+
+	require.Equal(t, term, restarted.Term())
+	require.Equal(t, "jade", restarted.votedFor)
+	require.Equal(t, term, restarted.meta.State().Term)
+	require.LessOrEqual(t, restarted.meta.State().QuorumID, quorum.LastID())
+	require.NotZero(t, restarted.meta.State().QuorumID, "expected the quorum id sequence to be persisted")
+	require.Equal(t, uint64(9), restarted.log.LastIndex())
+	require.Equal(t, uint64(9), restarted.LastApplied())
+	require.Equal(t, uint64(9), restarted.log.Meta().LastApplied)
+
+	// Entries that were applied before the restart must not be applied again.
+	require.NoError(t, restarted.start(cluster.errc))
+	leader = cluster.waitForLeader(t, 2*time.Second)
+	cluster.waitForApplied(t, 10, 2*time.Second)
+	require.Len(t, fsm.applied(), 10)
+
+	entry, err := restarted.log.Get(9)
+	require.NoError(t, err)
+	require.Equal(t, []byte("value 7"), entry.Value)
+	require.NotEqual(t, term, leader.Term(), "expected a new term after restart")
+}
+
+func TestSnapshot(t *testing.T) {
+	cluster := newSnapshotCluster(t, config.SnapshotConfig{Threshold: 8, Retain: 2, Trailing: 2}, "jade")
+	leader := cluster.waitForLeader(t, 2*time.Second)
+
+	for i := 0; i < 30; i++ {
+		_, err := leader.Commit(context.Background(), "put", []byte(fmt.Sprintf("value %d", i)))
+		require.NoError(t, err, "could not commit entry")
+	}
+
+	// Wait for the snapshots to be taken in the background and the log to be compacted.
+	var snaps []*snapshot.Snapshot
+	require.Eventually(t, func() bool {
+		var err error
+		snaps, err = leader.snapshots.List()
+		require.NoError(t, err, "could not list snapshots")
+		return len(snaps) == 2 && snaps[0].Meta.LastApplied >= 24
+	}, 5*time.Second, 10*time.Millisecond, "expected snapshots to be taken")
+
+	require.Equal(t, leader.Term(), snaps[0].Meta.Term)
+	require.Greater(t, snaps[0].Meta.LastApplied, snaps[1].Meta.LastApplied)
+
+	// Shutdown the replica and restart it with a state machine that has lost its state;
+	// the state machine must be restored from the snapshot since the log is compacted.
+	require.NoError(t, leader.Shutdown(), "could not shutdown replica")
+
+	fsm := &recorder{}
+	restarted, err := New(leader.conf, WithStateMachine(fsm))
+	require.NoError(t, err, "could not recover replica")
+	cluster.replicas[0] = restarted
+	cluster.fsms["jade"] = fsm
+
+	require.Greater(t, restarted.log.FirstIndex(), uint64(1), "expected the log to be compacted")
+	require.Equal(t, uint64(31), restarted.log.LastIndex())
+	require.GreaterOrEqual(t, restarted.LastApplied(), restarted.log.FirstIndex()-1)
+	require.Equal(t, restarted.snapshotIndex, restarted.LastApplied())
+
+	require.NoError(t, restarted.start(cluster.errc))
+	cluster.waitForLeader(t, 2*time.Second)
+	cluster.waitForApplied(t, 32, 2*time.Second)
+
+	applied := fsm.applied()
+	require.Len(t, applied, 32)
+	require.Equal(t, []byte("value 29"), applied[30].Value)
+}
+
+func TestInstallSnapshot(t *testing.T) {
+	// Only start two of the three replicas so that the third falls behind the leader.
+	cluster := createCluster(t, false, config.SnapshotConfig{Threshold: 8, Retain: 2, Trailing: 2}, "jade", "kira", "opal")
+	cluster.start(t, "jade", "kira")
+
+	var leader *Replica
+	require.Eventually(t, func() bool {
+		jade, kira := cluster.replica(t, "jade"), cluster.replica(t, "kira")
+		if jade.Leader() == "" || jade.Leader() != kira.Leader() || jade.Term() != kira.Term() {
+			return false
+		}
+		leader = cluster.replica(t, jade.Leader())
+		return leader.IsLeader()
+	}, 2*time.Second, 10*time.Millisecond, "expected a leader to be elected")
+
+	for i := 0; i < 30; i++ {
+		_, err := leader.Commit(context.Background(), "put", []byte(fmt.Sprintf("value %d", i)))
+		require.NoError(t, err, "could not commit entry")
+	}
+
+	// Wait for the leader to compact the entries that the third replica needs.
+	require.Eventually(t, func() bool {
+		snap, err := leader.snapshots.Latest()
+		return err == nil && snap.Meta.LastApplied >= 24 && leader.log.FirstIndex() > 1
+	}, 5*time.Second, 10*time.Millisecond, "expected the leader to compact its log")
+
+	// The third replica must be sent a snapshot since the leader has compacted its log.
+	cluster.start(t, "opal")
+	leader = cluster.waitForLeader(t, 5*time.Second)
+	cluster.waitForApplied(t, leader.log.LastIndex(), 5*time.Second)
+
+	opal := cluster.replica(t, "opal")
+	require.NotZero(t, opal.snapshotIndex, "expected a snapshot to be installed")
+	require.Greater(t, opal.log.FirstIndex(), uint64(1), "expected the log to start at the snapshot")
+
+	snap, err := opal.snapshots.Latest()
+	require.NoError(t, err, "expected the installed snapshot to be saved")
+	require.Equal(t, opal.snapshotIndex, snap.Meta.LastApplied)
+
+	// The state machine contains the entries in the snapshot and the entries after it.
+	applied, expected := cluster.fsms["opal"].applied(), cluster.fsms[leader.Name()].applied()
+	require.Len(t, applied, len(expected))
+	for i, entry := range expected {
+		require.True(t, proto.Equal(entry, applied[i]), "entry %d does not match the leader", entry.Index)
+	}
+	require.Equal(t, []byte("value 29"), applied[30].Value)
+
+	// Normal replication resumes after the snapshot is installed.
+	entry, err := leader.Commit(context.Background(), "put", []byte("after"))
+	require.NoError(t, err, "could not commit entry")
+	cluster.waitForApplied(t, entry.Entry.Index, 2*time.Second)
+	require.Equal(t, []byte("after"), cluster.fsms["opal"].applied()[entry.Entry.Index-1].Value)
+}
+
+func TestMembership(t *testing.T) {
+	cluster := newCluster(t, "jade", "kira", "opal")
+	leader := cluster.waitForLeader(t, 2*time.Second)
+
+	for i := 0; i < 5; i++ {
+		_, err := leader.Commit(context.Background(), "put", []byte(fmt.Sprintf("value %d", i)))
+		require.NoError(t, err, "could not commit entry")
+	}
+
+	// The new replica is not a member of the quorum until it is added by the leader.
+	ruby := cluster.create(t, "ruby")
+	cluster.start(t, "ruby")
+	require.False(t, ruby.Quorum().Contains("ruby"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	require.NoError(t, leader.AddPeer(ctx, &peers.Peer{PID: 40, Name: "ruby", Addr: bufconn.Endpoint, ClientAddr: "ruby:2202"}))
+	require.ErrorIs(t, leader.AddPeer(ctx, &peers.Peer{Name: "ruby", Addr: bufconn.Endpoint}), ErrMemberExists)
+	require.ErrorIs(t, leader.RemovePeer(ctx, "artemis"), ErrNotMember)
+
+	// Every replica, including the new replica, uses the new configuration.
+	leader = cluster.waitForLeader(t, 2*time.Second)
+	cluster.waitForApplied(t, leader.log.LastIndex(), 2*time.Second)
+	for _, r := range cluster.replicas {
+		joint := r.Quorum()
+		require.False(t, joint.IsJoint(), "expected %s to leave the joint configuration", r.Name())
+		require.Equal(t, []string{"jade", "kira", "opal", "ruby"}, joint.Hosts())
+	}
+
+	entry, err := leader.Commit(ctx, "put", []byte("with ruby"))
+	require.NoError(t, err, "could not commit entry")
+	cluster.waitForApplied(t, entry.Entry.Index, 2*time.Second)
+	require.Len(t, cluster.fsms["ruby"].applied(), int(entry.Entry.Index))
+
+	// Only the leader can change the configuration.
+	for _, r := range cluster.replicas {
+		if r != leader {
+			require.ErrorIs(t, r.RemovePeer(ctx, leader.Name()), ErrNotLeader)
+			break
+		}
+	}
+
+	// Removing the leader causes it to step down once the change is committed.
+	removed := leader.Name()
+	require.NoError(t, leader.RemovePeer(ctx, removed))
+	require.Eventually(t, func() bool { return !leader.IsLeader() }, 2*time.Second, 10*time.Millisecond)
+	cluster.remove(t, removed)
+
+	leader = cluster.waitForLeader(t, 5*time.Second)
+	require.NotEqual(t, removed, leader.Name())
+	require.False(t, leader.Quorum().Contains(removed))
+	require.Equal(t, 3, leader.Quorum().Current().Size())
+
+	entry, err = leader.Commit(ctx, "put", []byte("without "+removed))
+	require.NoError(t, err, "could not commit entry")
+	cluster.waitForApplied(t, entry.Entry.Index, 2*time.Second)
+}
+
+func TestLearner(t *testing.T) {
+	cluster := newCluster(t, "jade", "kira", "opal")
+	leader := cluster.waitForLeader(t, 2*time.Second)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	for i := 0; i < 5; i++ {
+		_, err := leader.Commit(ctx, "put", []byte(fmt.Sprintf("value %d", i)))
+		require.NoError(t, err, "could not commit entry")
+	}
+
+	// Add a learner in another region that receives entries but does not vote.
+	ruby := cluster.create(t, "ruby")
+	cluster.start(t, "ruby")
+	require.NoError(t, leader.AddPeer(ctx, &peers.Peer{PID: 40, Name: "ruby", Addr: bufconn.Endpoint, Region: "eu-west1", Learner: true}))
+
+	entry, err := leader.Commit(ctx, "put", []byte("learner"))
+	require.NoError(t, err, "could not commit entry")
+	cluster.waitForApplied(t, entry.Entry.Index, 2*time.Second)
+	require.Equal(t, Learner, ruby.State())
+
+	for _, r := range cluster.replicas {
+		require.Equal(t, []string{"jade", "kira", "opal"}, r.Quorum().Hosts(), "learners must not be members of the quorum")
+	}
+
+	// Only learners can be promoted and voters cannot be demoted.
+	require.ErrorIs(t, leader.PromotePeer(ctx, "jade"), ErrNotLearner)
+	require.ErrorIs(t, leader.PromotePeer(ctx, "artemis"), ErrNotMember)
+
+	// A caught up learner is promoted to a voter with a membership change.
+	require.NoError(t, leader.PromotePeer(ctx, "ruby"))
+	leader = cluster.waitForLeader(t, 2*time.Second)
+	cluster.waitForApplied(t, leader.log.LastIndex(), 2*time.Second)
+
+	require.Equal(t, Follower, ruby.State())
+	for _, r := range cluster.replicas {
+		require.Equal(t, []string{"jade", "kira", "opal", "ruby"}, r.Quorum().Hosts())
+	}
+}
+
+func TestTransferLeadership(t *testing.T) {
+	cluster := newCluster(t, "jade", "kira", "opal")
+	leader := cluster.waitForLeader(t, 2*time.Second)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	for i := 0; i < 5; i++ {
+		_, err := leader.Commit(ctx, "put", []byte(fmt.Sprintf("value %d", i)))
+		require.NoError(t, err, "could not commit entry")
+	}
+
+	// Only the leader can transfer leadership to a voting member of the quorum.
+	for _, r := range cluster.replicas {
+		if r != leader {
+			require.ErrorIs(t, r.TransferLeadership(ctx, ""), ErrNotLeader)
+		}
+	}
+	require.ErrorIs(t, leader.TransferLeadership(ctx, "artemis"), ErrNotMember)
+
+	// By default leadership is transferred to the peer with the lowest PID.
+	target := "jade"
+	if leader.Name() == "jade" {
+		target = "kira"
+	}
+
+	term := leader.Term()
+	require.NoError(t, leader.TransferLeadership(ctx, target))
+	require.Eventually(t, func() bool {
+		return cluster.replica(t, target).IsLeader()
+	}, 2*time.Second, 10*time.Millisecond, "target was not elected leader")
+	require.Greater(t, cluster.replica(t, target).Term(), term)
+	require.False(t, leader.IsLeader())
+
+	// Transfer leadership to a specific replica and commit with the new leader.
+	leader = cluster.replica(t, target)
+	require.NoError(t, leader.TransferLeadership(ctx, "opal"))
+	require.Eventually(t, func() bool {
+		return cluster.replica(t, "opal").IsLeader()
+	}, 2*time.Second, 10*time.Millisecond, "opal was not elected leader")
+
+	leader = cluster.waitForLeader(t, 2*time.Second)
+	entry, err := leader.Commit(ctx, "put", []byte("after transfer"))
+	require.NoError(t, err, "could not commit entry after transfer")
+	cluster.waitForApplied(t, entry.Entry.Index, 2*time.Second)
+}
+
+func TestTransferLease(t *testing.T) {
+	// The replica is not started so that the lease can be checked outside the event loop.
+	cluster := createCluster(t, false, config.SnapshotConfig{}, "jade", "kira", "opal")
+	jade := cluster.replica(t, "jade")
+	jade.state = Leader
+	jade.acks = make(map[string]time.Time)
+
+	heartbeat := func() {
+		sent := time.Now()
+		jade.ack("kira", sent)
+		jade.ack("opal", sent)
+	}
+
+	heartbeat()
+	require.True(t, jade.leaseValid(time.Now()), "expected the leader to hold a lease")
+
+	// Lease reads are refused while leadership is being transferred.
+	reply := make(chan error, 1)
+	jade.transfer = &transfer{target: "kira", reply: reply, timer: time.NewTimer(time.Hour)}
+	require.False(t, jade.leaseValid(time.Now()), "lease reads must be refused during a transfer")
+
+	heartbeat()
+	require.False(t, jade.leaseValid(time.Now()), "lease reads must be refused during a transfer")
+
+	// A transfer that did not reach the target does not fence the lease.
+	jade.endTransfer(ErrTransferTimeout)
+	require.ErrorIs(t, <-reply, ErrTransferTimeout)
+	require.True(t, jade.leaseValid(time.Now()), "expected the leader to hold a lease")
+
+	// If the target was told to start an election, the lease is not valid until a quorum
+	// acknowledges a heartbeat sent after the transfer was aborted.
+	heartbeat()
+	jade.transfer = &transfer{target: "kira", reply: reply, timer: time.NewTimer(time.Hour), sent: true}
+	jade.endTransfer(ErrTransferTimeout)
+	require.ErrorIs(t, <-reply, ErrTransferTimeout)
+	require.False(t, jade.leaseValid(time.Now()), "lease reads must be refused after an aborted transfer")
+
+	time.Sleep(time.Millisecond)
+	jade.ack("kira", time.Now())
+	require.True(t, jade.leaseValid(time.Now()), "expected a quorum to renew the lease")
+
+	// Without CheckQuorum voters do not honor the lease so it is never valid.
+	jade.conf.CheckQuorum = false
+	heartbeat()
+	require.False(t, jade.leaseValid(time.Now()), "lease reads require check quorum")
+}
+
+func TestPreVoteLease(t *testing.T) {
+	// The replica is not started so that votes can be requested outside the event loop.
+	cluster := createCluster(t, false, config.SnapshotConfig{}, "jade", "kira", "opal")
+	kira := cluster.replica(t, "kira")
+	kira.conf.CheckQuorum = false
+	kira.term, kira.leader, kira.contact = 1, "jade", time.Now()
+
+	vote := func(req *raft.VoteRequest) *raft.VoteReply {
+		reply := make(chan *raft.VoteReply, 1)
+		require.NoError(t, kira.onVoteRequest(&events.Message{Type: events.VoteRequest, Source: reply, Value: req}))
+		return <-reply
+	}
+
+	// Pre-votes are refused while the leader is alive even without CheckQuorum.
+	rep := vote(&raft.VoteRequest{Term: 2, Candidate: "opal", PreVote: true})
+	require.False(t, rep.Granted, "pre-vote granted in the lease of the leader")
+	require.Equal(t, uint64(1), kira.term)
+
+	// Pre-votes for the target of a leadership transfer are granted.
+	rep = vote(&raft.VoteRequest{Term: 2, Candidate: "opal", PreVote: true, Transfer: true})
+	require.True(t, rep.Granted, "pre-vote refused for the target of a transfer")
+
+	// Once the leader has not been heard from within an election timeout the pre-vote
+	// is granted without changing the term of the replica.
+	kira.contact = time.Now().Add(-time.Minute)
+	rep = vote(&raft.VoteRequest{Term: 2, Candidate: "opal", PreVote: true})
+	require.True(t, rep.Granted, "pre-vote refused after the leader lease")
+	require.Equal(t, uint64(1), kira.term)
+}
+
+func TestPreVote(t *testing.T) {
+	cluster := newCluster(t, "jade", "kira", "opal")
+	leader := cluster.waitForLeader(t, 2*time.Second)
+
+	entry, err := leader.Commit(context.Background(), "put", []byte("before"))
+	require.NoError(t, err, "could not commit entry")
+	cluster.waitForApplied(t, entry.Entry.Index, 2*time.Second)
+
+	var follower *Replica
+	for _, r := range cluster.replicas {
+		if r != leader {
+			follower = r
+			break
+		}
+	}
+
+	// A follower that repeatedly times out, e.g. because it cannot hear from the leader,
+	// does not win a pre-vote while the leader is alive so its term is not inflated.
+	term := leader.Term()
+	timeouts := ticker.NewElectionTicker(ticker.Fixed(5 * time.Millisecond))
+	deadline := time.After(500 * time.Millisecond)
+
+loop:
+	for {
+		select {
+		case e := <-timeouts.C:
+			require.NoError(t, follower.Dispatch(e))
+		case <-deadline:
+			timeouts.Stop()
+			break loop
+		}
+	}
+
+	require.True(t, leader.IsLeader(), "leader was deposed by a follower that timed out")
+	for _, r := range cluster.replicas {
+		require.Equal(t, term, r.Term(), "term of %s was inflated", r.Name())
+	}
+
+	// The follower follows the leader again once it hears a heartbeat.
+	require.Eventually(t, func() bool {
+		return follower.State() == Follower && follower.Leader() == leader.Name()
+	}, 2*time.Second, 10*time.Millisecond, "follower did not rejoin the leader")
+
+	entry, err = leader.Commit(context.Background(), "put", []byte("after"))
+	require.NoError(t, err, "could not commit entry")
+	cluster.waitForApplied(t, entry.Entry.Index, 2*time.Second)
+}
+
+func TestCheckQuorum(t *testing.T) {
+	cluster := newCluster(t, "jade", "kira", "opal")
+	leader := cluster.waitForLeader(t, 2*time.Second)
+
+	// The leader steps down once it no longer hears from a majority of the quorum.
+	for _, r := range append([]*Replica(nil), cluster.replicas...) {
+		if r != leader {
+			cluster.remove(t, r.Name())
+		}
+	}
+
+	require.Eventually(t, func() bool {
+		return !leader.IsLeader() && leader.Leader() == ""
+	}, 2*time.Second, 10*time.Millisecond, "leader did not step down without a quorum")
+
+	// Proposals are rejected since the replica is no longer the leader.
+	_, err := leader.Commit(context.Background(), "put", []byte("partitioned"))
+	require.ErrorIs(t, err, ErrNotLeader)
+}
+
+func TestPriority(t *testing.T) {
+	// Replicas with lower PIDs take precedence: jade (10), kira (20), then opal (30).
+	cluster := createCluster(t, false, config.SnapshotConfig{}, "jade", "kira", "opal")
+	for i, r := range cluster.replicas {
+		r.conf.Priority = true
+		require.Equal(t, i, r.rank, "unexpected rank for %s", r.Name())
+	}
+
+	// Start the replicas without jade so that kira is elected leader; if opal is elected
+	// first it yields to kira once kira has caught up.
+	cluster.start(t, "kira", "opal")
+
+	var leader *Replica
+	require.Eventually(t, func() bool {
+		kira, opal := cluster.replica(t, "kira"), cluster.replica(t, "opal")
+		if kira.Leader() != "kira" || opal.Leader() != "kira" || kira.Term() != opal.Term() {
+			return false
+		}
+		leader = kira
+		return leader.IsLeader()
+	}, 5*time.Second, 10*time.Millisecond, "expected kira to be elected leader")
+
+	for i := 0; i < 5; i++ {
+		_, err := leader.Commit(context.Background(), "put", []byte(fmt.Sprintf("value %d", i)))
+		require.NoError(t, err, "could not commit entry")
+	}
+
+	// Once jade has caught up with the leader it reclaims leadership.
+	cluster.start(t, "jade")
+	jade := cluster.replica(t, "jade")
+	require.Eventually(t, func() bool {
+		return jade.IsLeader()
+	}, 5*time.Second, 10*time.Millisecond, "expected jade to reclaim leadership")
+
+	leader = cluster.waitForLeader(t, 2*time.Second)
+	require.Equal(t, "jade", leader.Name())
+
+	entry, err := leader.Commit(context.Background(), "put", []byte("after"))
+	require.NoError(t, err, "could not commit entry")
+	cluster.waitForApplied(t, entry.Entry.Index, 2*time.Second)
+}
+
+func TestBootstrap(t *testing.T) {
+	cluster := newDurableCluster(t, "jade")
+	jade := cluster.waitForLeader(t, 2*time.Second)
+	require.Empty(t, jade.ClusterID(), "replica should not be initialized")
+
+	_, err := Bootstrap(jade.conf, "not a ulid")
+	require.ErrorIs(t, err, ErrInvalidClusterID)
+
+	clusterID, err := Bootstrap(jade.conf, "")
+	require.NoError(t, err, "could not bootstrap a new cluster")
+	require.NotEmpty(t, clusterID)
+
+	// Initializing the replica again is idempotent but cannot change the cluster.
+	again, err := Bootstrap(jade.conf, clusterID)
+	require.NoError(t, err)
+	require.Equal(t, clusterID, again)
+
+	_, err = Bootstrap(jade.conf, ulid.Make().String())
+	require.ErrorIs(t, err, ErrInitialized)
+
+	// The cluster id is loaded when the replica is restarted.
+	require.NoError(t, jade.Shutdown(), "could not shutdown replica")
+	require.ErrorIs(t, jade.meta.Save(jade.meta.State()), metastore.ErrClosed, "metadata store should be closed")
+	restarted, err := New(jade.conf, WithStateMachine(cluster.fsms["jade"]))
+	require.NoError(t, err, "could not recover replica")
+	cluster.replicas[0] = restarted
+	require.Equal(t, clusterID, restarted.ClusterID())
+
+	conf := jade.conf
+	conf.Enabled = false
+	_, err = Bootstrap(conf, "")
+	require.ErrorIs(t, err, ErrNotEnabled)
+}
+
+func TestConfigMeta(t *testing.T) {
+	// The quorum id sequence must be persisted before a configuration is used.
+	cluster := createCluster(t, false, config.SnapshotConfig{}, "jade", "kira", "opal")
+	jade := cluster.replica(t, "jade")
+	require.NoError(t, jade.reloadConfig(), "could not set configuration")
+
+	require.NoError(t, os.RemoveAll(jade.conf.DataDir), "could not remove data directory")
+	require.ErrorContains(t, jade.reloadConfig(), "could not save quorum id sequence")
+}
+
+func TestClusterID(t *testing.T) {
+	// Opal was accidentally configured with the peers of another cluster.
+	clusterID := ulid.Make().String()
+	cluster := createCluster(t, false, config.SnapshotConfig{}, "jade", "kira", "opal")
+	for _, r := range cluster.replicas {
+		r.clusterID = clusterID
+	}
+	opal := cluster.replica(t, "opal")
+	opal.clusterID = ulid.Make().String()
+	cluster.start(t, "jade", "kira", "opal")
+
+	var leader *Replica
+	require.Eventually(t, func() bool {
+		jade, kira := cluster.replica(t, "jade"), cluster.replica(t, "kira")
+		if jade.Leader() == "" || jade.Leader() != kira.Leader() || jade.Term() != kira.Term() {
+			return false
+		}
+		leader = cluster.replica(t, jade.Leader())
+		return leader.IsLeader()
+	}, 2*time.Second, 10*time.Millisecond, "expected a leader to be elected")
+
+	entry, err := leader.Commit(context.Background(), "put", []byte("value"))
+	require.NoError(t, err, "could not commit entry without opal")
+
+	// Opal rejects entries from the leader of the other cluster.
+	time.Sleep(4 * leader.conf.Tick)
+	require.Zero(t, opal.log.LastIndex(), "opal should not have accepted entries")
+	require.NotEqual(t, "opal", leader.Name())
+	require.Empty(t, opal.Leader())
+
+	_, err = opal.AppendEntries(context.Background(), &raft.AppendRequest{Term: leader.Term(), Leader: leader.Name(), ClusterID: clusterID})
+	require.Equal(t, codes.FailedPrecondition, status.Code(err))
+	require.Contains(t, err.Error(), ErrClusterMismatch.Error())
+
+	_, err = leader.RequestVote(context.Background(), &raft.VoteRequest{Term: entry.Entry.Term + 1, Candidate: "opal", ClusterID: opal.clusterID})
+	require.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+	// The leader does not commit commands forwarded by opal.
+	_, err = leader.Forward(context.Background(), &raft.ForwardRequest{Remote: "opal", Name: "put", Value: []byte("forwarded"), ClusterID: opal.clusterID})
+	require.Equal(t, codes.FailedPrecondition, status.Code(err))
+	require.ErrorIs(t, forwardError(err), ErrClusterMismatch)
+	require.Equal(t, entry.Entry.Index, leader.log.LastIndex(), "forwarded command should not be appended")
+}
+
+func TestApplyLocal(t *testing.T) {
+	// When replication is disabled commands are applied directly to the state machine;
+	// the consensus state must remain readable while a command is being applied.
+	cluster := createCluster(t, false, config.SnapshotConfig{}, "jade")
+	jade := cluster.replica(t, "jade")
+	jade.conf.Enabled = false
+
+	fsm := &blocking{applying: make(chan struct{}), release: make(chan struct{})}
+	jade.fsm = fsm
+
+	applied := make(chan *Applied, 1)
+	go func() {
+		entry, err := jade.Commit(context.Background(), "put", []byte("value"))
+		assert.NoError(t, err, "could not apply command")
+		applied <- entry
+	}()
+	<-fsm.applying
+
+	read := make(chan struct{})
+	go func() {
+		jade.State()
+		jade.Term()
+		jade.Leader()
+		close(read)
+	}()
+
+	select {
+	case <-read:
+	case <-time.After(time.Second):
+		require.Fail(t, "consensus state was blocked while a command was applied")
+	}
+
+	close(fsm.release)
+	entry := <-applied
+	require.Equal(t, uint64(1), entry.Entry.Index)
+	require.Equal(t, uint64(1), fsm.LastApplied())
+}
+
+func TestPipeline(t *testing.T) {
+	// Use a small window and byte budget so that flow control limits the replication of
+	// entries; opal is not started so that it is probed while it is unavailable.
+	cluster := createCluster(t, false, config.SnapshotConfig{}, "jade", "kira", "opal")
+	for _, r := range cluster.replicas {
+		r.conf.MaxInflight = 2
+		r.conf.MaxInflightBytes = 512
+	}
+	cluster.start(t, "jade", "kira")
+
+	var leader *Replica
+	require.Eventually(t, func() bool {
+		jade, kira := cluster.replica(t, "jade"), cluster.replica(t, "kira")
+		if jade.Leader() == "" || jade.Leader() != kira.Leader() || jade.Term() != kira.Term() {
+			return false
+		}
+		leader = cluster.replica(t, jade.Leader())
+		return leader.IsLeader()
+	}, 2*time.Second, 10*time.Millisecond, "expected a leader to be elected")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 25; j++ {
+				_, err := leader.Commit(context.Background(), "put", []byte(fmt.Sprintf("value %d-%d", i, j)))
+				assert.NoError(t, err, "could not commit entry")
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	// Opal catches up once it is started even though it was unavailable.
+	cluster.start(t, "opal")
+	index := leader.CommitIndex()
+	cluster.waitForApplied(t, index, 5*time.Second)
+
+	for _, r := range cluster.replicas {
+		require.Len(t, cluster.fsms[r.Name()].applied(), int(index), "replica %s did not apply every entry", r.Name())
+	}
+}
+
+func TestPeerStatus(t *testing.T) {
+	cluster := createCluster(t, false, config.SnapshotConfig{}, "jade", "kira", "opal")
+	cluster.start(t, "jade", "kira")
+
+	// Opal is unreachable until it is started.
+	jade := cluster.replica(t, "jade")
+	reachable := func(name string) bool {
+		for _, status := range jade.PeerStatus() {
+			if status.Name == name {
+				return status.Reachable
+			}
+		}
+		return false
+	}
+
+	require.Len(t, jade.PeerStatus(), 2)
+	require.Eventually(t, func() bool { return reachable("kira") }, 2*time.Second, 10*time.Millisecond, "expected kira to be reachable")
+	require.False(t, reachable("opal"), "expected opal to be unreachable")
+
+	cluster.start(t, "opal")
+	require.Eventually(t, func() bool { return reachable("opal") }, 5*time.Second, 10*time.Millisecond, "expected opal to be reachable")
+}
+
+func TestMutualTLS(t *testing.T) {
+	// Mallory has a certificate signed by the cluster authority but is not a peer; the
+	// rogue authority signs a certificate for kira that the cluster does not trust.
+	certs := t.TempDir()
+	writeCertificates(t, certs, "jade", "kira", "opal", "mallory")
+	writeCertificates(t, filepath.Join(certs, "rogue"), "kira")
+
+	cluster := createTLSCluster(t, certs, "jade", "kira", "opal")
+	cluster.start(t, "jade", "kira", "opal")
+
+	leader := cluster.waitForLeader(t, 2*time.Second)
+	for i := 0; i < 10; i++ {
+		_, err := leader.Commit(context.Background(), "put", []byte(fmt.Sprintf("value %d", i)))
+		require.NoError(t, err, "could not commit entry")
+	}
+	cluster.waitForApplied(t, leader.CommitIndex(), 2*time.Second)
+
+	// Connect to opal with the specified credentials.
+	connect := func(creds credentials.TransportCredentials) *grpc.ClientConn {
+		cc, err := cluster.socks["opal"].Connect(context.Background(), grpc.WithTransportCredentials(creds))
+		require.NoError(t, err, "could not create client")
+		t.Cleanup(func() { cc.Close() })
+		return cc
+	}
+
+	clientTLS := func(dir, name string) credentials.TransportCredentials {
+		conf := &tls.Config{ServerName: "opal", RootCAs: loadPool(t, filepath.Join(certs, "ca.crt"))}
+		if name != "" {
+			cert, err := tls.LoadX509KeyPair(filepath.Join(dir, name+".crt"), filepath.Join(dir, name+".key"))
+			require.NoError(t, err, "could not load certificate")
+			conf.Certificates = []tls.Certificate{cert}
+		}
+		return credentials.NewTLS(conf)
+	}
+
+	vote := func(cc *grpc.ClientConn, candidate string) error {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		_, err := raft.NewRaftClient(cc).RequestVote(ctx, &raft.VoteRequest{Candidate: candidate, ClusterID: leader.ClusterID()})
+		return err
+	}
+
+	t.Run("Insecure", func(t *testing.T) {
+		require.Equal(t, codes.Unavailable, status.Code(vote(connect(insecure.NewCredentials()), "kira")))
+	})
+
+	t.Run("NoCertificate", func(t *testing.T) {
+		require.Error(t, vote(connect(clientTLS(certs, "")), "kira"))
+	})
+
+	t.Run("UntrustedAuthority", func(t *testing.T) {
+		require.Error(t, vote(connect(clientTLS(filepath.Join(certs, "rogue"), "kira")), "kira"))
+	})
+
+	t.Run("NotPeer", func(t *testing.T) {
+		cc := connect(clientTLS(certs, "mallory"))
+		require.Equal(t, codes.PermissionDenied, status.Code(vote(cc, "mallory")))
+
+		// Authenticated clients that are not peers can still check the health of the replica.
+		rep, err := health.NewHealthClient(cc).Check(context.Background(), &health.HealthCheckRequest{})
+		require.NoError(t, err)
+		require.Equal(t, health.StatusServing, rep.Status)
+	})
+
+	t.Run("Impersonation", func(t *testing.T) {
+		cc := connect(clientTLS(certs, "kira"))
+		require.Equal(t, codes.PermissionDenied, status.Code(vote(cc, "jade")))
+		require.NoError(t, vote(cc, "kira"))
+	})
+}
+
+func TestProgress(t *testing.T) {
+	pr := &progress{}
+	require.False(t, pr.full(2, 100))
+
+	pr.replicate()
+	pr.sent(10, 40)
+	require.False(t, pr.full(2, 100))
+	pr.sent(20, 40)
+	require.True(t, pr.full(2, 100), "window should be full")
+
+	pr.ack(10)
+	require.False(t, pr.full(2, 100))
+	require.Equal(t, 40, pr.bytes)
+
+	pr.sent(30, 80)
+	require.True(t, pr.full(4, 100), "byte budget should be exhausted")
+	require.False(t, pr.full(4, 0), "zero byte budget is unlimited")
+
+	pr.ack(25)
+	require.Len(t, pr.inflight, 1)
+	require.Equal(t, 80, pr.bytes)
+
+	pr.probe()
+	require.False(t, pr.pipeline)
+	require.Empty(t, pr.inflight)
+	require.Zero(t, pr.bytes)
+}
+
+func TestTransport(t *testing.T) {
+	// Opal replicates with unary rpcs while the other replicas use streams so entries
+	// are replicated whichever replica is the leader and whatever transport it uses.
+	cluster := createCluster(t, false, config.SnapshotConfig{}, "jade", "kira", "opal")
+	cluster.replica(t, "opal").conf.Stream = false
+	cluster.start(t, "jade", "kira", "opal")
+
+	for _, name := range []string{"opal", "kira"} {
+		leader := cluster.waitForLeader(t, 2*time.Second)
+		if leader.Name() != name {
+			require.NoError(t, leader.TransferLeadership(context.Background(), name), "could not transfer leadership to %s", name)
+			leader = cluster.waitForLeader(t, 2*time.Second)
+		}
+
+		for i := 0; i < 20; i++ {
+			_, err := leader.Commit(context.Background(), "put", []byte(fmt.Sprintf("%s value %d", name, i)))
+			require.NoError(t, err, "could not commit entry")
+		}
+	}
+
+	index := cluster.replica(t, "kira").CommitIndex()
+	cluster.waitForApplied(t, index, 5*time.Second)
+	for _, r := range cluster.replicas {
+		require.Len(t, cluster.fsms[r.Name()].applied(), int(index), "replica %s did not apply every entry", r.Name())
+	}
+}
+
+//===========================================================================
+// Test Cluster Helpers
+//===========================================================================
+
+// A cluster of replicas that communicate via bufconn connections in memory.
+type cluster struct {
+	sync.Mutex
+	replicas []*Replica
+	socks    map[string]*bufconn.Listener
+	fsms     map[string]*recorder
+	errc     chan error
+	dir      string
+	durable  bool
+	snaps    config.SnapshotConfig
+	certs    string
+}
+
+// Create and start a cluster of replicas with the specified names whose logs are stored
+// in memory.
+func newCluster(t *testing.T, names ...string) *cluster {
+	return startCluster(t, false, config.SnapshotConfig{}, names...)
+}
+
+// Create and start a cluster of replicas whose logs are written to disk in a temporary
+// data directory so that replicas can be recovered.
+func newDurableCluster(t *testing.T, names ...string) *cluster {
+	return startCluster(t, true, config.SnapshotConfig{}, names...)
+}
+
+// Create and start a durable cluster of replicas that snapshot their state machines and
+// compact their logs.
+func newSnapshotCluster(t *testing.T, snapshots config.SnapshotConfig, names ...string) *cluster {
+	return startCluster(t, true, snapshots, names...)
+}
+
+func startCluster(t *testing.T, durable bool, snapshots config.SnapshotConfig, names ...string) *cluster {
+	c := createCluster(t, durable, snapshots, names...)
+	c.start(t, names...)
+	return c
+}
+
+// Create a cluster of replicas that are connected to each other but not started so
+// that tests can control when each replica joins the cluster.
+func createCluster(t *testing.T, durable bool, snapshots config.SnapshotConfig, names ...string) *cluster {
+	return buildCluster(t, durable, snapshots, "", names...)
+}
+
+// Create a cluster of replicas that connect to each other with mutual TLS using the
+// certificates in the certs directory, which are named after each replica.
+func createTLSCluster(t *testing.T, certs string, names ...string) *cluster {
+	return buildCluster(t, false, config.SnapshotConfig{}, certs, names...)
+}
+
+func buildCluster(t *testing.T, durable bool, snapshots config.SnapshotConfig, certs string, names ...string) *cluster {
+	c := &cluster{
+		replicas: make([]*Replica, 0, len(names)),
+		socks:    make(map[string]*bufconn.Listener, len(names)),
+		fsms:     make(map[string]*recorder, len(names)),
+		errc:     make(chan error, len(names)),
+		dir:      t.TempDir(),
+		durable:  durable,
+		snaps:    snapshots,
+		certs:    certs,
+	}
+
+	// Write a peers file that all replicas will load from.
+	quorum := make(peers.Peers, 0, len(names))
+	for i, name := range names {
+		quorum = append(quorum, &peers.Peer{PID: uint16(i+1) * 10, Name: name, Addr: bufconn.Endpoint, ClientAddr: name + ":2202"})
+		c.socks[name] = bufconn.New()
+	}
+
+	data, err := json.Marshal(quorum)
+	require.NoError(t, err, "could not marshal peers")
+	require.NoError(t, os.WriteFile(filepath.Join(c.dir, "peers.json"), data, 0644), "could not write peers")
+
+	for _, name := range names {
+		c.create(t, name)
+	}
+
+	t.Cleanup(func() {
+		for _, r := range c.replicas {
+			require.NoError(t, r.Shutdown(), "could not shutdown replica %s", r.Name())
+		}
+	})
+
+	return c
+}
+
+// Create a replica that loads the peers of the cluster; if the replica is not one of the
+// peers it must be added to the quorum once it is started.
+func (c *cluster) create(t *testing.T, name string) *Replica {
+	conf := config.ReplicaConfig{
+		Enabled:     true,
+		BindAddr:    bufconn.Endpoint,
+		Aggregate:   true,
+		Name:        name,
+		Peers:       filepath.Join(c.dir, "peers.json"),
+		DataDir:     filepath.Join(c.dir, name),
+		Tick:        50 * time.Millisecond,
+		Timeout:     100 * time.Millisecond,
+		PreVote:     true,
+		CheckQuorum: true,
+		MaxInflight: 8,
+		Stream:      true,
+		Snapshot:    c.snaps,
+	}
+
+	if c.certs != "" {
+		conf.TLS = config.TLSConfig{
+			CertPath: filepath.Join(c.certs, name+".crt"),
+			KeyPath:  filepath.Join(c.certs, name+".key"),
+			CAPath:   filepath.Join(c.certs, "ca.crt"),
+		}
+	}
+
+	c.Lock()
+	if _, ok := c.socks[name]; !ok {
+		c.socks[name] = bufconn.New()
+	}
+	c.Unlock()
+
+	c.fsms[name] = &recorder{}
+	opts := []Option{WithStateMachine(c.fsms[name]), WithDialOptions(c.dialOptions)}
+	if !c.durable {
+		opts = append(opts, WithLogStore(logstore.NewMemory()))
+	}
+
+	r, err := New(conf, opts...)
+	require.NoError(t, err, "could not create replica %s", name)
+	c.replicas = append(c.replicas, r)
+	return r
+}
+
+// Remove the replica from the cluster and shut it down, e.g. once it has been removed
+// from the quorum.
+func (c *cluster) remove(t *testing.T, name string) {
+	for i, r := range c.replicas {
+		if r.Name() == name {
+			c.replicas = append(c.replicas[:i], c.replicas[i+1:]...)
+			require.NoError(t, r.Shutdown(), "could not shutdown replica %s", name)
+			return
+		}
+	}
+	t.Fatalf("no replica named %s in the cluster", name)
+}
+
+// Connect remote peers via the bufconn listener of the peer.
+func (c *cluster) dialOptions(peer *peers.Peer) []grpc.DialOption {
+	dialer := func(ctx context.Context, addr string) (net.Conn, error) {
+		c.Lock()
+		sock, ok := c.socks[peer.Name]
+		c.Unlock()
+
+		if !ok {
+			return nil, fmt.Errorf("no bufconn listener for %s", peer.Name)
+		}
+		return sock.Dialer(ctx, addr)
+	}
+
+	return []grpc.DialOption{
+		grpc.WithContextDialer(dialer),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	}
+}
+
+// Serve and start the replicas with the specified names.
+func (c *cluster) start(t *testing.T, names ...string) {
+	for _, name := range names {
+		r := c.replica(t, name)
+		go r.Run(c.errc, c.socks[name].Sock())
+		require.NoError(t, r.start(c.errc), "could not start replica %s", name)
+	}
+}
+
+// Returns the replica with the specified name.
+func (c *cluster) replica(t *testing.T, name string) *Replica {
+	for _, r := range c.replicas {
+		if r.Name() == name {
+			return r
+		}
+	}
+
+	t.Fatalf("no replica named %s in the cluster", name)
+	return nil
+}
+
+// Wait until exactly one replica is the leader and all replicas agree on the leader.
+func (c *cluster) waitForLeader(t *testing.T, timeout time.Duration) *Replica {
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		select {
+		case err := <-c.errc:
+			require.NoError(t, err, "cluster crashed")
+		default:
+		}
+
+		if leader, err := c.leader(); err == nil {
+			return leader
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	t.Fatalf("cluster did not converge on a leader after %s", timeout)
+	return nil
+}
+
+// Wait until all replicas have committed the specified index.
+func (c *cluster) waitForCommit(t *testing.T, index uint64, timeout time.Duration) {
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		committed := 0
+		for _, r := range c.replicas {
+			if r.CommitIndex() >= index {
+				committed++
+			}
+		}
+
+		if committed == len(c.replicas) {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	t.Fatalf("cluster did not commit index %d after %s", index, timeout)
+}
+
+// Wait until all replicas have applied the specified index.
+func (c *cluster) waitForApplied(t *testing.T, index uint64, timeout time.Duration) {
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		applied := 0
+		for _, r := range c.replicas {
+			if r.LastApplied() >= index {
+				applied++
+			}
+		}
+
+		if applied == len(c.replicas) {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	t.Fatalf("cluster did not apply index %d after %s", index, timeout)
+}
+
+// Returns the leader if there is exactly one leader that all replicas have accepted.
+func (c *cluster) leader() (leader *Replica, err error) {
+	for _, r := range c.replicas {
+		if r.IsLeader() {
+			if leader != nil {
+				return nil, fmt.Errorf("multiple leaders: %s and %s", leader.Name(), r.Name())
+			}
+			leader = r
+		}
+	}
+
+	if leader == nil {
+		return nil, fmt.Errorf("no leader elected")
+	}
+
+	for _, r := range c.replicas {
+		if r.Leader() != leader.Name() || r.Term() != leader.Term() {
+			return nil, fmt.Errorf("replica %s has not accepted leader %s", r.Name(), leader.Name())
+		}
+	}
+	return leader, nil
+}
+
+// A state machine that blocks applying entries until it is released.
+type blocking struct {
+	recorder
+	applying chan struct{}
+	release  chan struct{}
+}
+
+func (b *blocking) Apply(entry *raft.LogEntry) (interface{}, error) {
+	close(b.applying)
+	<-b.release
+	return b.recorder.Apply(entry)
+}
+
+// A state machine that records the entries applied to it; the result of each entry is
+// its index.
+type recorder struct {
+	sync.Mutex
+	entries []*raft.LogEntry
+}
+
+func (r *recorder) Apply(entry *raft.LogEntry) (interface{}, error) {
+	r.Lock()
+	defer r.Unlock()
+
+	if entry.Index <= uint64(len(r.entries)) {
+		return entry.Index, nil
+	}
+
+	r.entries = append(r.entries, entry)
+	return entry.Index, nil
+}
+
+func (r *recorder) EncodeResult(result interface{}) ([]byte, error) {
+	return binary.AppendUvarint(nil, result.(uint64)), nil
+}
+
+func (r *recorder) DecodeResult(data []byte) (interface{}, error) {
+	index, n := binary.Uvarint(data)
+	if n <= 0 {
+		return nil, fmt.Errorf("could not decode result")
+	}
+	return index, nil
+}
+
+func (r *recorder) Snapshot(path string) (uint64, error) {
+	r.Lock()
+	defer r.Unlock()
+
+	data, err := proto.Marshal(&raft.LogSnapshot{Entries: r.entries})
+	if err != nil {
+		return 0, err
+	}
+
+	if err = os.WriteFile(path, data, 0644); err != nil {
+		return 0, err
+	}
+	return uint64(len(r.entries)), nil
+}
+
+func (r *recorder) Restore(path string) error {
+	r.Lock()
+	defer r.Unlock()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	snap := &raft.LogSnapshot{}
+	if err = proto.Unmarshal(data, snap); err != nil {
+		return err
+	}
+
+	r.entries = snap.Entries
+	return nil
+}
+
+func (r *recorder) LastApplied() uint64 {
+	r.Lock()
+	defer r.Unlock()
+	return uint64(len(r.entries))
+}
+
+func (r *recorder) applied() []*raft.LogEntry {
+	r.Lock()
+	defer r.Unlock()
+	return append([]*raft.LogEntry(nil), r.entries...)
+}
+
+// Write a certificate authority and a certificate and key for each of the names, which
+// are valid for both servers and clients, to the directory.
+func writeCertificates(t *testing.T, dir string, names ...string) {
+	require.NoError(t, os.MkdirAll(dir, 0755))
+
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err, "could not generate ca key")
+
+	ca := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "otterdb test ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, ca, ca, &caKey.PublicKey, caKey)
+	require.NoError(t, err, "could not create ca certificate")
+	writePEM(t, filepath.Join(dir, "ca.crt"), "CERTIFICATE", der)
+
+	for i, name := range names {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		require.NoError(t, err, "could not generate key")
+
+		cert := &x509.Certificate{
+			SerialNumber: big.NewInt(int64(i + 2)),
+			Subject:      pkix.Name{CommonName: name},
+			DNSNames:     []string{name},
+			NotBefore:    time.Now().Add(-time.Hour),
+			NotAfter:     time.Now().Add(time.Hour),
+			KeyUsage:     x509.KeyUsageDigitalSignature,
+			ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		}
+
+		der, err := x509.CreateCertificate(rand.Reader, cert, ca, &key.PublicKey, caKey)
+		require.NoError(t, err, "could not create certificate")
+		writePEM(t, filepath.Join(dir, name+".crt"), "CERTIFICATE", der)
+
+		pkcs8, err := x509.MarshalPKCS8PrivateKey(key)
+		require.NoError(t, err, "could not marshal key")
+		writePEM(t, filepath.Join(dir, name+".key"), "PRIVATE KEY", pkcs8)
+	}
+}
+
+func writePEM(t *testing.T, path, kind string, der []byte) {
+	data := pem.EncodeToMemory(&pem.Block{Type: kind, Bytes: der})
+	require.NoError(t, os.WriteFile(path, data, 0600), "could not write %s", path)
+}
+
+func loadPool(t *testing.T, path string) *x509.CertPool {
+	data, err := os.ReadFile(path)
+	require.NoError(t, err, "could not read certificate authority")
+
+	pool := x509.NewCertPool()
+	require.True(t, pool.AppendCertsFromPEM(data), "no certificates in %s", path)
+	return pool
+}
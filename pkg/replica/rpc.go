@@ -0,0 +1,377 @@
+package replica
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+
+	"github.com/bbengfort/otterdb/pkg/replica/events"
+	"github.com/bbengfort/otterdb/pkg/replica/logstore"
+	"github.com/bbengfort/otterdb/pkg/replica/peers"
+	"github.com/bbengfort/otterdb/pkg/replica/raft/v1"
+
+	"github.com/rs/zerolog/log"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// The maximum number of entries that are sent to a follower in a single append entries
+// request to limit the size of messages when a follower is far behind the leader.
+const maxAppendEntries = 1024
+
+// The number of requests received on an append entries stream that may be waiting for
+// the event loop to reply before the stream stops receiving requests.
+const streamBuffer = 64
+
+//===========================================================================
+// Raft Server RPCs
+//===========================================================================
+
+// RequestVote is called by candidates to gather votes. The request is dispatched to the
+// event loop and the handler blocks until the event loop replies or the request is
+// canceled by the remote.
+func (r *Replica) RequestVote(ctx context.Context, in *raft.VoteRequest) (out *raft.VoteReply, err error) {
+	if err = r.checkCluster(in.Candidate, in.ClusterID); err != nil {
+		return nil, err
+	}
+
+	if err = r.checkRemote(ctx, in.Candidate); err != nil {
+		return nil, err
+	}
+
+	reply := make(chan *raft.VoteReply, 1)
+	if err = r.Dispatch(&events.Message{Type: events.VoteRequest, Source: reply, Value: in}); err != nil {
+		return nil, status.Error(codes.Unavailable, err.Error())
+	}
+
+	select {
+	case out = <-reply:
+		return out, nil
+	case <-ctx.Done():
+		return nil, status.FromContextError(ctx.Err()).Err()
+	}
+}
+
+// AppendEntries is called by the leader to replicate log entries and as a heartbeat.
+// The request is dispatched to the event loop and the handler blocks until the event
+// loop replies or the request is canceled by the remote.
+func (r *Replica) AppendEntries(ctx context.Context, in *raft.AppendRequest) (out *raft.AppendReply, err error) {
+	if err = r.checkCluster(in.Leader, in.ClusterID); err != nil {
+		return nil, err
+	}
+
+	if err = r.checkRemote(ctx, in.Leader); err != nil {
+		return nil, err
+	}
+
+	reply := make(chan *raft.AppendReply, 1)
+	if err = r.Dispatch(&events.Message{Type: events.AppendRequest, Source: reply, Value: in}); err != nil {
+		return nil, status.Error(codes.Unavailable, err.Error())
+	}
+
+	select {
+	case out = <-reply:
+		return out, nil
+	case <-ctx.Done():
+		return nil, status.FromContextError(ctx.Err()).Err()
+	}
+}
+
+// AppendStream is a long-lived bidirectional stream that the leader uses to replicate
+// entries to the local replica without a round trip per request. Each request received
+// on the stream is dispatched to the event loop and the replies are sent back on the
+// stream in the order that the requests were received. The stream ends when the leader
+// closes it, when the stream fails, or when the replica is shut down.
+func (r *Replica) AppendStream(stream raft.Raft_AppendStreamServer) error {
+	ctx, cancel := context.WithCancel(stream.Context())
+	defer cancel()
+
+	// The reply channels are queued in the order the requests were received so that
+	// the replies are sent in the same order even though they are sent asynchronously.
+	replies := make(chan chan *raft.AppendReply, streamBuffer)
+	sent := make(chan error, 1)
+	go func() {
+		sent <- r.sendStreamReplies(ctx, stream, replies)
+	}()
+
+	recv := make(chan error, 1)
+	go func() {
+		defer close(replies)
+		recv <- r.recvStreamRequests(ctx, stream, replies)
+	}()
+
+	var err error
+	select {
+	case err = <-recv:
+		if err == nil {
+			// The leader closed the stream, so send the remaining replies before returning.
+			return <-sent
+		}
+	case err = <-sent:
+		return err
+	case <-r.stopping:
+		err = status.Error(codes.Unavailable, ErrNotListening.Error())
+	}
+
+	// Replies must not be sent after the handler returns.
+	cancel()
+	<-sent
+	return err
+}
+
+// Receive requests from the stream and dispatch them to the event loop until the leader
+// closes the stream (returning nil) or the stream fails.
+func (r *Replica) recvStreamRequests(ctx context.Context, stream raft.Raft_AppendStreamServer, replies chan<- chan *raft.AppendReply) (err error) {
+	for {
+		var in *raft.AppendRequest
+		if in, err = stream.Recv(); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+
+		if err = r.checkCluster(in.Leader, in.ClusterID); err != nil {
+			return err
+		}
+
+		if err = r.checkRemote(stream.Context(), in.Leader); err != nil {
+			return err
+		}
+
+		reply := make(chan *raft.AppendReply, 1)
+		if err = r.Dispatch(&events.Message{Type: events.AppendRequest, Source: reply, Value: in}); err != nil {
+			return status.Error(codes.Unavailable, err.Error())
+		}
+
+		select {
+		case replies <- reply:
+		case <-ctx.Done():
+			return status.FromContextError(ctx.Err()).Err()
+		}
+	}
+}
+
+// Send the replies from the event loop on the stream in the order that the requests
+// were received until all replies have been sent or the stream fails.
+func (r *Replica) sendStreamReplies(ctx context.Context, stream raft.Raft_AppendStreamServer, replies <-chan chan *raft.AppendReply) error {
+	for {
+		var (
+			reply chan *raft.AppendReply
+			ok    bool
+		)
+
+		select {
+		case reply, ok = <-replies:
+			if !ok {
+				return nil
+			}
+		case <-ctx.Done():
+			return status.FromContextError(ctx.Err()).Err()
+		}
+
+		select {
+		case out := <-reply:
+			if err := stream.Send(out); err != nil {
+				return err
+			}
+		case <-ctx.Done():
+			return status.FromContextError(ctx.Err()).Err()
+		}
+	}
+}
+
+//===========================================================================
+// Raft Client Broadcasts
+//===========================================================================
+
+// Send a vote request for the current term to all peers, or a pre-vote request for the
+// next term; the replies are dispatched to the event loop as they are received and the
+// peers that fail to reply before the timeout are logged.
+//
+// NOTE: This method is not thread-safe and should only be called from the event loop.
+func (r *Replica) broadcastRequestVote(preVote, transfer bool) {
+	req := &raft.VoteRequest{
+		Term:         r.term,
+		Candidate:    r.name,
+		LastLogIndex: r.log.LastIndex(),
+		LastLogTerm:  r.log.LastTerm(),
+		PreVote:      preVote,
+		Transfer:     transfer,
+		ClusterID:    r.clusterID,
+	}
+
+	if preVote {
+		req.Term++
+	}
+
+	// The peers may change when a configuration is appended to the log.
+	ctx, cancel := context.WithTimeout(context.Background(), r.conf.Timeout)
+	results := r.peers.RequestVote(ctx, req)
+
+	go func() {
+		defer cancel()
+		for result := range results {
+			if result.Err != nil {
+				if status.Code(result.Err) == codes.FailedPrecondition {
+					log.Warn().Err(result.Err).Str("peer", result.Peer).Msg("peer rejected request vote")
+				} else {
+					log.Debug().Err(result.Err).Str("peer", result.Peer).Dur("latency", result.Latency).Bool("pre_vote", preVote).Msg("request vote rpc failed")
+				}
+				continue
+			}
+			r.Dispatch(&events.Message{Type: events.VoteReply, Value: result.Reply})
+		}
+	}()
+}
+
+// Send an append entries request to all peers to replicate entries; heartbeats are sent
+// to every peer to assert leadership for the current term even if the flow control of
+// the peer does not allow more entries to be sent.
+//
+// NOTE: This method is not thread-safe and should only be called from the event loop.
+func (r *Replica) broadcastAppendEntries(heartbeat bool) {
+	for _, peer := range r.peers {
+		r.sendAppendEntries(peer, heartbeat)
+	}
+}
+
+// Send an append entries request to the peer containing the entries that follow the
+// next index of the peer (or no entries if the peer is up to date, e.g. a heartbeat).
+// The number of entries that are sent is limited by the flow control of the peer: a
+// probed peer only has a single request in flight and a pipelined peer has at most a
+// window of requests and a budget of bytes in flight. When entries cannot be sent, a
+// heartbeat sends no entries and only checks that the log matches up to the match index.
+// The request is sent on the append entries stream of the peer if streaming is enabled,
+// otherwise with a unary RPC; the reply is dispatched to the event loop when received.
+//
+// NOTE: This method is not thread-safe and should only be called from the event loop.
+func (r *Replica) sendAppendEntries(peer *peers.Peer, heartbeat bool) {
+	pr := r.progress[peer.Name]
+	if pr == nil {
+		pr = &progress{}
+		r.progress[peer.Name] = pr
+	}
+
+	// A probe is sent again on every heartbeat until it is acknowledged; entries are
+	// not sent to a pipelined peer whose window is full, only heartbeats.
+	full := pr.pipeline && pr.full(r.conf.MaxInflight, r.conf.MaxInflightBytes)
+	if !heartbeat && (full || (!pr.pipeline && pr.paused)) {
+		return
+	}
+
+	next := r.nextIndex[peer.Name]
+	prev, err := r.log.Get(next - 1)
+	if errors.Is(err, logstore.ErrCompacted) {
+		if !r.installing[peer.Name] {
+			// The entries the peer needs have been compacted so the snapshot is sent instead.
+			log.Debug().Str("peer", peer.Name).Uint64("next_index", next).Uint64("first_index", r.log.FirstIndex()).Msg("peer is behind the compacted log")
+			pr.probe()
+			r.sendSnapshot(peer)
+			return
+		}
+
+		// Heartbeats are sent while the snapshot is installed so the peer does not time
+		// out and start an election; the peer rejects the heartbeat since it is behind.
+		prev, err = r.log.Get(r.log.LastIndex())
+		full = true
+	}
+
+	if err != nil {
+		// The next index should never be past the end of the log, but if it is, reset
+		// the next index to the end of the log and send a heartbeat.
+		log.Warn().Err(err).Str("peer", peer.Name).Uint64("next_index", next).Msg("invalid next index for peer")
+		r.nextIndex[peer.Name] = r.log.LastIndex() + 1
+		prev, _ = r.log.Get(r.log.LastIndex())
+	}
+
+	var entries []*raft.LogEntry
+	if !full {
+		if entries, err = r.log.After(prev.Index, maxAppendEntries); err != nil {
+			log.Error().Err(err).Str("peer", peer.Name).Uint64("prev_index", prev.Index).Msg("could not read entries for peer")
+			return
+		}
+	}
+
+	// Limit the entries to the bytes remaining in the budget of the peer, always sending
+	// at least one entry so that an entry larger than the budget is not blocked forever.
+	var size int
+	for i, entry := range entries {
+		n := proto.Size(entry)
+		if r.conf.MaxInflightBytes > 0 && i > 0 && pr.bytes+size+n > r.conf.MaxInflightBytes {
+			entries = entries[:i]
+			break
+		}
+		size += n
+	}
+
+	if pr.pipeline && len(entries) == 0 {
+		// There is nothing to replicate to a pipelined peer that is up to date.
+		if !heartbeat {
+			return
+		}
+
+		// Pipelined heartbeats check the entries that are known to be replicated rather
+		// than the entries in flight, which the peer may not have received yet.
+		if !r.installing[peer.Name] {
+			if match, err := r.log.Get(r.matchIndex[peer.Name]); err == nil {
+				prev = match
+			}
+		}
+	}
+
+	req := &raft.AppendRequest{
+		Term:         r.term,
+		Leader:       r.name,
+		PrevLogIndex: prev.Index,
+		PrevLogTerm:  prev.Term,
+		LeaderCommit: r.log.CommitIndex(),
+		Entries:      entries,
+		ClusterID:    r.clusterID,
+	}
+
+	switch {
+	case pr.pipeline && len(entries) > 0:
+		last := entries[len(entries)-1].Index
+		pr.sent(last, size)
+		r.nextIndex[peer.Name] = last + 1
+	case !pr.pipeline && !r.installing[peer.Name]:
+		pr.paused = true
+	}
+
+	handler := func(reply *raft.AppendReply, sent time.Time, err error) {
+		if err != nil {
+			if status.Code(err) == codes.FailedPrecondition {
+				log.Warn().Err(err).Str("peer", peer.Name).Msg("peer rejected append entries")
+			} else {
+				log.Trace().Err(err).Str("peer", peer.Name).Msg("append entries rpc failed")
+			}
+		}
+		r.Dispatch(&events.Message{Type: events.AppendReply, Value: &appendResponse{peer: peer.Name, reply: reply, sent: sent, err: err}})
+	}
+
+	if r.conf.Stream {
+		peer.StreamAppendEntries(req, r.conf.Timeout, handler)
+		return
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), r.conf.Timeout)
+		defer cancel()
+
+		sent := time.Now()
+		reply, err := peer.AppendEntries(ctx, req)
+		handler(reply, sent, err)
+	}()
+}
+
+// An append entries reply along with the time that the request was sent; the peer
+// acknowledged the leadership of the local replica no earlier than the send time.
+type appendResponse struct {
+	peer  string
+	reply *raft.AppendReply
+	sent  time.Time
+	err   error
+}
@@ -34,6 +34,23 @@ func (s *Sequence) Next() uint64 {
 	return s.counter
 }
 
+// Current returns the most recent value of the sequence without incrementing it.
+func (s *Sequence) Current() uint64 {
+	s.Lock()
+	defer s.Unlock()
+	return s.counter
+}
+
+// Advance the sequence to the specified value if it is ahead of the sequence, e.g. to
+// resume a sequence that was persisted; the sequence is never moved backwards.
+func (s *Sequence) Advance(to uint64) {
+	s.Lock()
+	defer s.Unlock()
+	if to > s.counter {
+		s.counter = to
+	}
+}
+
 func (s *Sequence) MarshalBinary() ([]byte, error) {
 	data := make([]byte, binary.MaxVarintLen64)
 	n := binary.PutUvarint(data, s.counter)
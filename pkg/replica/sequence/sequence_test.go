@@ -28,6 +28,18 @@ func TestSequence(t *testing.T) {
 	require.Equal(t, uint64(4097), seq.Next())
 }
 
+func TestAdvance(t *testing.T) {
+	seq := sequence.Start(42)
+	require.Equal(t, uint64(42), seq.Current())
+
+	seq.Advance(100)
+	require.Equal(t, uint64(100), seq.Current())
+
+	// The sequence is never moved backwards.
+	seq.Advance(7)
+	require.Equal(t, uint64(101), seq.Next())
+}
+
 func TestSerialization(t *testing.T) {
 	t.Run("Binary", func(t *testing.T) {
 		for i := 0; i < 256; i++ {
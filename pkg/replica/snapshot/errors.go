@@ -0,0 +1,10 @@
+package snapshot
+
+import "errors"
+
+var (
+	ErrNoSnapshot    = errors.New("no snapshot has been taken")
+	ErrInvalidRetain = errors.New("at least one snapshot must be retained")
+	ErrInvalidMeta   = errors.New("snapshot does not have valid metadata")
+	ErrAlreadyExists = errors.New("a snapshot at the specified index already exists")
+)
@@ -0,0 +1,278 @@
+/*
+Package snapshot stores snapshots of the replicated state machine so that the log can be
+compacted. A snapshot is a data file that is written by the state machine and a meta
+file that describes the last entry included in the snapshot. Snapshots are written to a
+temporary file and renamed into place once they are complete; the meta file is renamed
+last so that a snapshot is only visible when both files are durable. Only a configured
+number of the most recent snapshots are retained.
+*/
+package snapshot
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/bbengfort/otterdb/pkg/replica/raft/v1"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	dataExt    = ".snap"
+	metaExt    = ".meta"
+	tempExt    = ".tmp"
+	nameLength = 20
+)
+
+// Store manages the snapshots in a directory. The Store is thread-safe.
+type Store struct {
+	sync.Mutex
+	dir    string
+	retain int
+}
+
+// Snapshot describes a complete snapshot on disk.
+type Snapshot struct {
+	Meta *raft.LogMeta // The index and term of the last entry included in the snapshot
+	Path string        // The path to the data file written by the state machine
+}
+
+// Open the snapshot store in the specified directory, creating the directory if it
+// does not exist and removing any temporary files from incomplete snapshots.
+func Open(dir string, retain int) (s *Store, err error) {
+	if retain < 1 {
+		return nil, ErrInvalidRetain
+	}
+
+	if err = os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("could not create snapshot directory: %w", err)
+	}
+
+	var temps []string
+	if temps, err = filepath.Glob(filepath.Join(dir, "*"+tempExt)); err != nil {
+		return nil, err
+	}
+
+	for _, temp := range temps {
+		if err = os.Remove(temp); err != nil {
+			return nil, fmt.Errorf("could not remove incomplete snapshot: %w", err)
+		}
+	}
+
+	return &Store{dir: dir, retain: retain}, nil
+}
+
+// Temp returns the path of a new temporary file that the state machine can write a
+// snapshot to; the file does not exist until the state machine creates it. Temporary
+// files are removed when the store is opened if they were never saved.
+func (s *Store) Temp() (path string, err error) {
+	var f *os.File
+	if f, err = os.CreateTemp(s.dir, "snapshot-*"+tempExt); err != nil {
+		return "", err
+	}
+
+	path = f.Name()
+	f.Close()
+
+	if err = os.Remove(path); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// Save the data file at the temporary path as the snapshot described by the meta and
+// remove the oldest snapshots that are no longer retained.
+func (s *Store) Save(temp string, meta *raft.LogMeta) (_ *Snapshot, err error) {
+	s.Lock()
+	defer s.Unlock()
+
+	snap := &Snapshot{Meta: meta, Path: s.path(meta.LastApplied, dataExt)}
+	if _, err = os.Stat(snap.Path); err == nil {
+		return nil, ErrAlreadyExists
+	}
+
+	if meta.Created == nil {
+		meta.Created = timestamppb.Now()
+	}
+	meta.Updated = timestamppb.Now()
+
+	var data []byte
+	if data, err = proto.Marshal(&raft.LogSnapshot{Meta: meta}); err != nil {
+		return nil, err
+	}
+
+	// Ensure the data file is durable before it is renamed into place.
+	if err = syncFile(temp); err != nil {
+		return nil, fmt.Errorf("could not sync snapshot data: %w", err)
+	}
+
+	metaTemp := temp + metaExt + tempExt
+	if err = writeFile(metaTemp, data); err != nil {
+		return nil, fmt.Errorf("could not write snapshot meta: %w", err)
+	}
+
+	if err = os.Rename(temp, snap.Path); err != nil {
+		return nil, err
+	}
+
+	if err = os.Rename(metaTemp, s.path(meta.LastApplied, metaExt)); err != nil {
+		return nil, err
+	}
+
+	if err = syncDir(s.dir); err != nil {
+		return nil, err
+	}
+
+	if err = s.prune(); err != nil {
+		return nil, fmt.Errorf("could not remove old snapshots: %w", err)
+	}
+	return snap, nil
+}
+
+// Latest returns the most recent snapshot or ErrNoSnapshot if there are none.
+func (s *Store) Latest() (_ *Snapshot, err error) {
+	var snaps []*Snapshot
+	if snaps, err = s.List(); err != nil {
+		return nil, err
+	}
+
+	if len(snaps) == 0 {
+		return nil, ErrNoSnapshot
+	}
+	return snaps[0], nil
+}
+
+// Get returns the snapshot whose last entry has the specified index or ErrNoSnapshot if
+// there is no such snapshot.
+func (s *Store) Get(index uint64) (_ *Snapshot, err error) {
+	s.Lock()
+	defer s.Unlock()
+
+	var snap *Snapshot
+	if snap, err = load(s.path(index, metaExt)); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, ErrNoSnapshot
+		}
+		return nil, err
+	}
+	return snap, nil
+}
+
+// List returns all of the complete snapshots in the store, most recent first.
+func (s *Store) List() (snaps []*Snapshot, err error) {
+	s.Lock()
+	defer s.Unlock()
+	return s.list()
+}
+
+func (s *Store) list() (snaps []*Snapshot, err error) {
+	var paths []string
+	if paths, err = filepath.Glob(filepath.Join(s.dir, "*"+metaExt)); err != nil {
+		return nil, err
+	}
+
+	// Snapshot names are zero padded so lexical order is index order.
+	sort.Sort(sort.Reverse(sort.StringSlice(paths)))
+
+	snaps = make([]*Snapshot, 0, len(paths))
+	for _, path := range paths {
+		var snap *Snapshot
+		if snap, err = load(path); err != nil {
+			return nil, err
+		}
+		snaps = append(snaps, snap)
+	}
+	return snaps, nil
+}
+
+// Remove the oldest snapshots so that only the configured number are retained. The
+// meta file is removed first so that a partially removed snapshot is not visible.
+func (s *Store) prune() (err error) {
+	var snaps []*Snapshot
+	if snaps, err = s.list(); err != nil {
+		return err
+	}
+
+	if len(snaps) <= s.retain {
+		return nil
+	}
+
+	for _, snap := range snaps[s.retain:] {
+		if err = os.Remove(s.path(snap.Meta.LastApplied, metaExt)); err != nil {
+			return err
+		}
+
+		if err = os.Remove(snap.Path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return syncDir(s.dir)
+}
+
+// Returns the path of the snapshot file with the specified index and extension.
+func (s *Store) path(index uint64, ext string) string {
+	return filepath.Join(s.dir, fmt.Sprintf("%0*d%s", nameLength, index, ext))
+}
+
+// Load the snapshot from the specified meta file.
+func load(path string) (_ *Snapshot, err error) {
+	name := strings.TrimSuffix(filepath.Base(path), metaExt)
+	if _, err = strconv.ParseUint(name, 10, 64); err != nil {
+		return nil, fmt.Errorf("%w: unexpected file %s", ErrInvalidMeta, filepath.Base(path))
+	}
+
+	var data []byte
+	if data, err = os.ReadFile(path); err != nil {
+		return nil, err
+	}
+
+	snap := &raft.LogSnapshot{}
+	if err = proto.Unmarshal(data, snap); err != nil || snap.Meta == nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidMeta, filepath.Base(path))
+	}
+
+	return &Snapshot{
+		Meta: snap.Meta,
+		Path: strings.TrimSuffix(path, metaExt) + dataExt,
+	}, nil
+}
+
+// Write the data to the file and sync it to disk.
+func writeFile(path string, data []byte) (err error) {
+	var f *os.File
+	if f, err = os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644); err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if _, err = f.Write(data); err != nil {
+		return err
+	}
+	return f.Sync()
+}
+
+// Sync a file that was written by another process or library to disk.
+func syncFile(path string) (err error) {
+	var f *os.File
+	if f, err = os.OpenFile(path, os.O_RDWR, 0); err != nil {
+		return err
+	}
+	defer f.Close()
+	return f.Sync()
+}
+
+// Sync the directory to ensure that created, renamed, or removed files are durable.
+func syncDir(dir string) (err error) {
+	var f *os.File
+	if f, err = os.Open(dir); err != nil {
+		return err
+	}
+	defer f.Close()
+	return f.Sync()
+}
@@ -0,0 +1,75 @@
+package snapshot_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bbengfort/otterdb/pkg/replica/raft/v1"
+	"github.com/bbengfort/otterdb/pkg/replica/snapshot"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore(t *testing.T) {
+	dir := t.TempDir()
+	store, err := snapshot.Open(dir, 2)
+	require.NoError(t, err, "could not open snapshot store")
+
+	_, err = store.Latest()
+	require.ErrorIs(t, err, snapshot.ErrNoSnapshot)
+
+	for _, index := range []uint64{10, 20, 30} {
+		temp, err := store.Temp()
+		require.NoError(t, err, "could not create temp path")
+		require.NoError(t, os.WriteFile(temp, []byte{byte(index)}, 0644))
+
+		snap, err := store.Save(temp, &raft.LogMeta{LastApplied: index, CommitIndex: index, Term: 2})
+		require.NoError(t, err, "could not save snapshot %d", index)
+		require.Equal(t, index, snap.Meta.LastApplied)
+		require.NotNil(t, snap.Meta.Created)
+		require.NoFileExists(t, temp)
+	}
+
+	// Only the two most recent snapshots are retained
+	snaps, err := store.List()
+	require.NoError(t, err, "could not list snapshots")
+	require.Len(t, snaps, 2)
+	require.Equal(t, uint64(30), snaps[0].Meta.LastApplied)
+	require.Equal(t, uint64(20), snaps[1].Meta.LastApplied)
+	require.Equal(t, uint64(2), snaps[0].Meta.Term)
+
+	data, err := os.ReadFile(snaps[0].Path)
+	require.NoError(t, err, "could not read snapshot data")
+	require.Equal(t, []byte{30}, data)
+
+	files, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, files, 4, "expected only the retained snapshot and meta files")
+
+	// Snapshots cannot be overwritten
+	temp, err := store.Temp()
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(temp, []byte{0}, 0644))
+	_, err = store.Save(temp, &raft.LogMeta{LastApplied: 30})
+	require.ErrorIs(t, err, snapshot.ErrAlreadyExists)
+
+	// Incomplete snapshots are removed when the store is reopened
+	store, err = snapshot.Open(dir, 2)
+	require.NoError(t, err, "could not reopen snapshot store")
+	require.NoFileExists(t, temp)
+
+	latest, err := store.Latest()
+	require.NoError(t, err)
+	require.Equal(t, uint64(30), latest.Meta.LastApplied)
+	require.Equal(t, filepath.Join(dir, "00000000000000000030.snap"), latest.Path)
+
+	snap, err := store.Get(20)
+	require.NoError(t, err, "could not get snapshot")
+	require.Equal(t, uint64(20), snap.Meta.LastApplied)
+
+	_, err = store.Get(10)
+	require.ErrorIs(t, err, snapshot.ErrNoSnapshot, "pruned snapshots should not be returned")
+
+	_, err = snapshot.Open(dir, 0)
+	require.ErrorIs(t, err, snapshot.ErrInvalidRetain)
+}
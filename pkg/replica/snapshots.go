@@ -0,0 +1,162 @@
+package replica
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/bbengfort/otterdb/pkg/replica/events"
+	"github.com/bbengfort/otterdb/pkg/replica/raft/v1"
+	"github.com/bbengfort/otterdb/pkg/replica/snapshot"
+
+	"github.com/rs/zerolog/log"
+)
+
+// The name of the directory in the data directory where snapshots are stored.
+const snapshotDir = "snapshots"
+
+// Snapshotter is implemented by state machines that can be snapshotted so that the log
+// can be compacted. Snapshot writes a consistent copy of the state to the path and
+// returns the index of the last entry applied to the copy; it is called concurrently
+// with Apply. Restore replaces the state with the snapshot at the path.
+type Snapshotter interface {
+	Snapshot(path string) (lastApplied uint64, err error)
+	Restore(path string) error
+}
+
+// The outcome of a snapshot taken in the background that is sent to the event loop.
+type snapshotTaken struct {
+	path  string
+	index uint64
+	err   error
+}
+
+// Open the snapshot store if the state machine supports snapshots. The store is opened
+// even if snapshots are not taken locally so that snapshots can be installed from the
+// leader, in which case at least the installed snapshot is retained.
+func (r *Replica) openSnapshots(dir string) (err error) {
+	if _, ok := r.fsm.(Snapshotter); !ok {
+		return nil
+	}
+
+	if r.snapshots, err = snapshot.Open(dir, max(r.conf.Snapshot.Retain, 1)); err != nil {
+		return err
+	}
+
+	var latest *snapshot.Snapshot
+	if latest, err = r.snapshots.Latest(); err != nil {
+		if errors.Is(err, snapshot.ErrNoSnapshot) {
+			return nil
+		}
+		return err
+	}
+
+	r.snapshotIndex = latest.Meta.LastApplied
+	return nil
+}
+
+// Restore the state machine from the latest snapshot; the snapshot must include every
+// entry that was compacted from the log.
+func (r *Replica) restoreSnapshot() (err error) {
+	snapshotter, ok := r.fsm.(Snapshotter)
+	if !ok || r.snapshots == nil {
+		return ErrNoSnapshot
+	}
+
+	var latest *snapshot.Snapshot
+	if latest, err = r.snapshots.Latest(); err != nil {
+		if errors.Is(err, snapshot.ErrNoSnapshot) {
+			return ErrNoSnapshot
+		}
+		return err
+	}
+
+	if latest.Meta.LastApplied+1 < r.log.FirstIndex() {
+		return fmt.Errorf("snapshot at index %d does not include compacted entries before %d: %w", latest.Meta.LastApplied, r.log.FirstIndex(), ErrNoSnapshot)
+	}
+
+	if err = snapshotter.Restore(latest.Path); err != nil {
+		return err
+	}
+
+	log.Info().Uint64("index", latest.Meta.LastApplied).Uint64("term", latest.Meta.Term).Msg("state machine restored from snapshot")
+	return nil
+}
+
+// Start taking a snapshot in the background if enough entries have been applied since
+// the last snapshot. The state machine is snapshotted outside of the event loop since
+// copying the state may take some time; the outcome is dispatched to the event loop.
+//
+// NOTE: This method is not thread-safe and should only be called from the event loop.
+func (r *Replica) maybeSnapshot() {
+	if r.snapshots == nil || r.snapshotting || r.conf.Snapshot.Threshold == 0 {
+		return
+	}
+
+	if r.log.LastApplied()-r.snapshotIndex < r.conf.Snapshot.Threshold {
+		return
+	}
+
+	r.snapshotting = true
+	snapshotter := r.fsm.(Snapshotter)
+
+	go func() {
+		taken := &snapshotTaken{}
+		if taken.path, taken.err = r.snapshots.Temp(); taken.err == nil {
+			taken.index, taken.err = snapshotter.Snapshot(taken.path)
+		}
+		r.Dispatch(&events.Message{Type: events.Snapshot, Value: taken})
+	}()
+}
+
+// Save a snapshot taken in the background with the term of its last entry and compact
+// the log behind the snapshot, keeping the configured number of trailing entries so
+// that followers that are slightly behind can still be sent entries. Failing to take a
+// snapshot does not stop the replica; the snapshot is retried after more entries are
+// applied.
+//
+// NOTE: This method is not thread-safe and should only be called from the event loop.
+func (r *Replica) onSnapshot(e events.Event) (err error) {
+	var taken *snapshotTaken
+	if taken, err = snapshotEvent(e); err != nil {
+		return err
+	}
+
+	r.snapshotting = false
+	if taken.err != nil {
+		log.Error().Err(taken.err).Msg("could not take snapshot")
+		os.Remove(taken.path)
+		return nil
+	}
+
+	var entry *raft.LogEntry
+	if entry, err = r.log.Get(taken.index); err != nil {
+		log.Error().Err(err).Uint64("index", taken.index).Msg("could not find last entry of snapshot")
+		os.Remove(taken.path)
+		return nil
+	}
+
+	// The configuration is stored with the snapshot since it may be compacted.
+	meta := &raft.LogMeta{LastApplied: entry.Index, CommitIndex: entry.Index, Length: entry.Index, Term: entry.Term}
+	if meta.Configuration, _, err = r.configAt(entry.Index); err != nil {
+		log.Error().Err(err).Uint64("index", entry.Index).Msg("could not find configuration of snapshot")
+		os.Remove(taken.path)
+		return nil
+	}
+
+	if _, err = r.snapshots.Save(taken.path, meta); err != nil {
+		log.Error().Err(err).Uint64("index", entry.Index).Msg("could not save snapshot")
+		os.Remove(taken.path)
+		return nil
+	}
+	r.snapshotIndex = entry.Index
+
+	if entry.Index > r.conf.Snapshot.Trailing {
+		if err = r.log.Compact(entry.Index - r.conf.Snapshot.Trailing); err != nil {
+			return err
+		}
+	}
+
+	log.Info().Uint64("index", entry.Index).Uint64("term", entry.Term).Uint64("first_index", r.log.FirstIndex()).Msg("snapshot taken")
+	return nil
+}
@@ -2,6 +2,11 @@ package replica
 
 import (
 	"fmt"
+	"time"
+
+	"github.com/bbengfort/otterdb/pkg/replica/quorum"
+	"github.com/bbengfort/otterdb/pkg/replica/raft/v1"
+	"github.com/rs/zerolog/log"
 )
 
 // Replica states for distributed consensus.
@@ -12,11 +17,14 @@ const (
 	Follower
 	Candidate
 	Leader
+	Learner
+	PreCandidate
 )
 
 // Names of the states for serialization
 var stateStrings = [...]string{
-	"stopped", "initialized", "running", "follower", "candidate", "leader",
+	"stopped", "initialized", "running", "follower", "candidate", "leader", "learner",
+	"precandidate",
 }
 
 //===========================================================================
@@ -47,9 +55,16 @@ func (r *Replica) setState(state State) (err error) {
 	case Initialized:
 		err = r.setInitializedState()
 	case Running:
-		err = r.setRunningState()
-	case Follower:
+		// Running bootstraps the replica directly into the follower state.
+		if err = r.setRunningState(); err == nil {
+			state = r.followerState()
+		}
+	case Follower, Learner:
+		// Learners follow the leader in the learner state so that they do not vote.
+		state = r.followerState()
 		err = r.setFollowerState()
+	case PreCandidate:
+		err = r.setPreCandidateState()
 	case Candidate:
 		err = r.setCandidateState()
 	case Leader:
@@ -59,7 +74,9 @@ func (r *Replica) setState(state State) (err error) {
 	}
 
 	if err == nil {
+		r.mu.Lock()
 		r.state = state
+		r.mu.Unlock()
 	}
 
 	return err
@@ -67,12 +84,17 @@ func (r *Replica) setState(state State) (err error) {
 
 // Stops all timers that might be running.
 func (r *Replica) setStoppedState() error {
+	r.stopHeartbeat()
+	r.stopElectionTimeout()
+	r.dropReads(ErrNotListening)
+	r.endTransfer(ErrNotListening)
 	return nil
 }
 
 // Resets any volatile variables on the local replica and is called when the
 // replica becomes a follower or a candidate.
 func (r *Replica) setInitializedState() error {
+	r.votes = nil
 	return nil
 }
 
@@ -80,17 +102,185 @@ func (r *Replica) setInitializedState() error {
 // starting the leader's heartbeat or starting the election timeout for all
 // other replicas.
 func (r *Replica) setRunningState() error {
-	return nil
+	return r.setFollowerState()
 }
 
+// Followers stop sending heartbeats (e.g. if they were deposed as the leader) and
+// start the election timeout to detect if the leader has failed. A leader that steps
+// down while transferring leadership has completed the transfer.
 func (r *Replica) setFollowerState() error {
+	if r.leader == r.name {
+		r.setLeader("")
+	}
+
+	r.stopHeartbeat()
+	r.resetElectionTimeout()
+	r.votes = nil
+	r.nextIndex = nil
+	r.matchIndex = nil
+	r.progress = nil
+	r.acks = nil
+	r.installing = nil
+	r.dropReads(ErrNotLeader)
+	r.endTransfer(nil)
 	return nil
 }
 
+// Pre-candidates vote for themselves in the next term without changing their term or
+// vote so that they can check that a majority of the quorum would vote for them before
+// they start an election. A replica that cannot win an election, e.g. because it was
+// partitioned from the quorum, does not depose a healthy leader by inflating its term.
+// The election timeout is reset so that a new pre-vote is started if this one fails.
+func (r *Replica) setPreCandidateState() error {
+	r.resetElectionTimeout()
+
+	r.votes = r.quorum.Election()
+	if _, err := r.votes.Vote(r.name); err != nil {
+		return err
+	}
+
+	log.Debug().Uint64("term", r.term+1).Str("candidate", r.name).Msg("starting pre-vote")
+	return nil
+}
+
+// Candidates start a new term and vote for themselves before requesting votes from all
+// of their peers. The election timeout is reset so that if the election is split, a new
+// election will be started when the timeout fires again.
 func (r *Replica) setCandidateState() error {
+	if err := r.setTerm(r.term + 1); err != nil {
+		return err
+	}
+
+	if err := r.setVote(r.name); err != nil {
+		return err
+	}
+	r.resetElectionTimeout()
+
+	r.votes = r.quorum.Election()
+	if _, err := r.votes.Vote(r.name); err != nil {
+		return err
+	}
+
+	log.Info().Uint64("term", r.term).Str("candidate", r.name).Msg("starting leader election")
 	return nil
 }
 
+// Leaders stop the election timeout, start sending heartbeats to all followers, and
+// initialize the replication progress of each follower. A no-op entry is appended to
+// the log and immediately broadcast to assert leadership for the new term and so that
+// entries from previous terms are committed as soon as possible.
 func (r *Replica) setLeaderState() error {
+	r.stopElectionTimeout()
+	r.setLeader(r.name)
+	r.votes = nil
+
+	r.nextIndex = make(map[string]uint64, len(r.peers))
+	r.matchIndex = make(map[string]uint64, len(r.peers))
+	r.progress = make(map[string]*progress, len(r.peers))
+	for _, peer := range r.peers {
+		r.nextIndex[peer.Name] = r.log.LastIndex() + 1
+		r.matchIndex[peer.Name] = 0
+		r.progress[peer.Name] = &progress{}
+	}
+
+	// Leadership has not been acknowledged by any peer in the new term.
+	r.elected = time.Now()
+	r.acks = make(map[string]time.Time, len(r.peers))
+	r.installing = make(map[string]bool, len(r.peers))
+
+	noop := &raft.LogEntry{Index: r.log.LastIndex() + 1, Term: r.term, Name: NoOp}
+	if err := r.log.Append(noop); err != nil {
+		return err
+	}
+	r.termStart = noop.Index
+
+	// Complete a membership change if the previous leader committed the joint
+	// configuration but did not append the final configuration.
+	if len(r.config.Next) > 0 && r.configIndex <= r.log.CommitIndex() {
+		if err := r.leaveJoint(); err != nil {
+			return err
+		}
+	}
+
+	log.Info().Uint64("term", r.term).Str("leader", r.name).Msg("elected leader")
+	r.resetHeartbeat()
+	r.broadcastAppendEntries(true)
+	return r.updateCommitIndex()
+}
+
+// Returns the state that the replica follows the leader in, which is the learner state
+// if the local replica is a learner in the latest configuration.
+func (r *Replica) followerState() State {
+	if r.quorum == nil || r.quorum.Contains(r.name) {
+		return Follower
+	}
+
+	for _, members := range [][]*raft.Member{r.config.Current, r.config.Next} {
+		for _, member := range members {
+			if member.Name == r.name && member.Learner {
+				return Learner
+			}
+		}
+	}
+	return Follower
+}
+
+//===========================================================================
+// Term Management
+//===========================================================================
+
+// Set the term of the replica; when a new term starts the vote and the leader of the
+// previous term are no longer valid. The new term is durably saved to the metadata store
+// before the replica acts in the new term.
+//
+// NOTE: This method is not thread-safe and should only be called from the event loop.
+func (r *Replica) setTerm(term uint64) (err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if term > r.term {
+		if err = r.saveMeta(term, ""); err != nil {
+			return err
+		}
+
+		r.term = term
+		r.votedFor = ""
+		r.leader = ""
+	}
 	return nil
 }
+
+// Set the vote of the replica in the current term; the vote is durably saved to the
+// metadata store before it is granted so that the replica cannot vote twice in the same
+// term.
+//
+// NOTE: This method is not thread-safe and should only be called from the event loop.
+func (r *Replica) setVote(candidate string) (err error) {
+	if err = r.saveMeta(r.term, candidate); err != nil {
+		return err
+	}
+	r.votedFor = candidate
+	return nil
+}
+
+// Durably save the term and vote along with the quorum id sequence to the metadata
+// store; this must complete before the replica replies to the RPC that changed them.
+//
+// NOTE: This method is not thread-safe and should only be called from the event loop.
+func (r *Replica) saveMeta(term uint64, votedFor string) error {
+	if r.meta == nil {
+		return nil
+	}
+
+	state := r.meta.State()
+	state.Term, state.VotedFor, state.QuorumID = term, votedFor, quorum.LastID()
+	return r.meta.Save(state)
+}
+
+// Set the leader of the current term.
+//
+// NOTE: This method is not thread-safe and should only be called from the event loop.
+func (r *Replica) setLeader(leader string) {
+	r.mu.Lock()
+	r.leader = leader
+	r.mu.Unlock()
+}
@@ -5,10 +5,16 @@ import (
 	"encoding/binary"
 	"math"
 	mrand "math/rand"
+	"sync"
 )
 
-// Random source used to generate pseudo-random numbers for the ticker.
-var rand *mrand.Rand
+// Random source used to generate pseudo-random numbers for the ticker. The source is
+// not safe for concurrent use so the mutex must be held to generate random numbers
+// since multiple tickers may be running in different go routines.
+var (
+	rand *mrand.Rand
+	mu   sync.Mutex
+)
 
 // Max attempts for non-negative random number generation.
 var maxAttempts = 4
@@ -20,6 +26,8 @@ func init() {
 // SetSource allows the user to specify a new pseudo-random source for random number
 // generation in this package; e.g. for deterministic unit testing.
 func SetSource(s mrand.Source) {
+	mu.Lock()
+	defer mu.Unlock()
 	rand = mrand.New(s)
 }
 
@@ -29,6 +37,9 @@ func ResetSource() {
 	if _, err := crand.Read(b[:]); err != nil {
 		panic("cryptographically random number generator required to seed source")
 	}
+
+	mu.Lock()
+	defer mu.Unlock()
 	rand = mrand.New(mrand.NewSource(int64(binary.LittleEndian.Uint64(b[:]))))
 }
 
@@ -50,6 +61,9 @@ func randRange(min, max int64) int64 {
 	if min == max {
 		return min
 	}
+
+	mu.Lock()
+	defer mu.Unlock()
 	return rand.Int63n(max-min) + min
 }
 
@@ -59,6 +73,9 @@ func randRange(min, max int64) int64 {
 // normal distribution is not returned, particularly if the mean is close to zero with
 // respect to the standard deviation.
 func randNormal(mean int64, sdev float64) int64 {
+	mu.Lock()
+	defer mu.Unlock()
+
 	for i := 0; i < maxAttempts; i++ {
 		sample := rand.NormFloat64()*sdev + float64(mean)
 		if sample > 0.0 {
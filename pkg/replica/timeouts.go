@@ -0,0 +1,129 @@
+package replica
+
+import (
+	"time"
+
+	"github.com/bbengfort/otterdb/pkg/replica/events"
+	"github.com/bbengfort/otterdb/pkg/replica/ticker"
+)
+
+// Election timeouts are a multiple of the heartbeat tick, jittered by a scaling factor
+// so that replicas are unlikely to time out at the same time and split the vote.
+const (
+	electionTicks  = 4
+	electionJitter = 0.25
+)
+
+// The leader lease is shorter than the minimum election timeout by this factor to bound
+// the effect of clock drift between the leader and its followers.
+const leaseDrift = 0.1
+
+// Returns the interval between heartbeats sent by the leader.
+func (r *Replica) heartbeatInterval() ticker.Interval {
+	return ticker.Fixed(r.conf.Tick)
+}
+
+// Returns the randomized interval of the election timeout. If elections honor the
+// precedence of replicas, a heartbeat tick is added to the timeout for every voting
+// member that takes precedence over the local replica so that replicas with lower PIDs
+// time out and are elected first. The minimum election timeout is not shortened so the
+// leader lease is not affected by the precedence of the replicas.
+func (r *Replica) electionInterval() ticker.Interval {
+	timeout := r.electionTimeout()
+	if r.conf.Priority {
+		timeout += time.Duration(r.rank) * r.conf.Tick
+	}
+	return ticker.Jitter(timeout, electionJitter)
+}
+
+// Returns the duration of the leader lease. With CheckQuorum, voters refuse to vote in a
+// later term until the minimum election timeout has passed since they last heard from
+// the leader, unless leadership is being transferred to the candidate. A leader that has
+// been acknowledged by a quorum therefore cannot be replaced before the lease expires,
+// assuming bounded clock drift. Without CheckQuorum, voters may elect a new leader at any
+// time so the lease is not used; see leaseValid.
+func (r *Replica) leaseTimeout() time.Duration {
+	minElection := float64(r.electionTimeout()) * (1 - electionJitter)
+	return time.Duration(minElection * (1 - leaseDrift))
+}
+
+// Returns the election timeout before it is jittered; with CheckQuorum the leader steps
+// down if a majority of the quorum has not acknowledged it within this timeout.
+func (r *Replica) electionTimeout() time.Duration {
+	return electionTicks * r.conf.Tick
+}
+
+// Returns true if the replica has heard from the leader of the current term within the
+// minimum election timeout, or if the local replica is the leader. Replicas in the lease
+// of a leader ignore pre-votes in a later term and, with CheckQuorum, votes as well so
+// that a replica that rejoins the quorum does not depose a healthy leader.
+//
+// NOTE: This method is not thread-safe and should only be called from the event loop.
+func (r *Replica) inLease() bool {
+	if r.state == Leader {
+		return true
+	}
+
+	minElection := float64(r.electionTimeout()) * (1 - electionJitter)
+	return r.leader != "" && time.Since(r.contact) < time.Duration(minElection)
+}
+
+// Returns the time the leader waits for a leadership transfer to complete before it is
+// aborted; the target should be elected within the maximum election timeout of when
+// it is told to start an election.
+func (r *Replica) transferTimeout() time.Duration {
+	return time.Duration(float64(r.electionTimeout()) * (1 + electionJitter))
+}
+
+// Starts the heartbeat ticker if it is not running, otherwise resets the ticker so the
+// next heartbeat is sent after a complete interval.
+//
+// NOTE: This method is not thread-safe and should only be called from the event loop.
+func (r *Replica) resetHeartbeat() {
+	if r.heartbeat == nil {
+		r.heartbeat = ticker.NewHeartbeatTicker(r.heartbeatInterval())
+		go r.forward(r.heartbeat.C)
+		return
+	}
+	r.heartbeat.Interrupt()
+}
+
+// Stops the heartbeat ticker if it is running.
+//
+// NOTE: This method is not thread-safe and should only be called from the event loop.
+func (r *Replica) stopHeartbeat() {
+	if r.heartbeat != nil {
+		r.heartbeat.Stop()
+		r.heartbeat = nil
+	}
+}
+
+// Starts the election timeout if it is not running, otherwise resets the timeout with
+// a new random delay; e.g. when a heartbeat is received from the leader.
+//
+// NOTE: This method is not thread-safe and should only be called from the event loop.
+func (r *Replica) resetElectionTimeout() {
+	if r.election == nil {
+		r.election = ticker.NewElectionTicker(r.electionInterval())
+		go r.forward(r.election.C)
+		return
+	}
+	r.election.Interrupt()
+}
+
+// Stops the election timeout if it is running.
+//
+// NOTE: This method is not thread-safe and should only be called from the event loop.
+func (r *Replica) stopElectionTimeout() {
+	if r.election != nil {
+		r.election.Stop()
+		r.election = nil
+	}
+}
+
+// Forwards events from a ticker to the event loop until the ticker is stopped.
+func (r *Replica) forward(c <-chan events.Event) {
+	for e := range c {
+		r.Dispatch(e)
+	}
+}
@@ -0,0 +1,203 @@
+package replica
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/bbengfort/otterdb/pkg/replica/peers"
+	"github.com/bbengfort/otterdb/pkg/replica/raft/v1"
+
+	"github.com/rs/zerolog/log"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// The prefix of the full method names of the Raft service RPCs.
+var raftService = "/" + raft.Raft_ServiceDesc.ServiceName + "/"
+
+// Load the certificate of the replica and the certificate authorities that sign the
+// certificates of its peers if mutual TLS is configured.
+func (r *Replica) loadTLS() (err error) {
+	if !r.conf.TLS.Enabled() {
+		return nil
+	}
+
+	var cert tls.Certificate
+	if cert, err = tls.LoadX509KeyPair(r.conf.TLS.CertPath, r.conf.TLS.KeyPath); err != nil {
+		return fmt.Errorf("could not load certificate: %w", err)
+	}
+
+	var pem []byte
+	if pem, err = os.ReadFile(r.conf.TLS.CAPath); err != nil {
+		return fmt.Errorf("could not read certificate authorities: %w", err)
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return ErrNoCertificates
+	}
+
+	r.tls = &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		RootCAs:      pool,
+		ClientCAs:    pool,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		MinVersion:   tls.VersionTLS12,
+	}
+	return nil
+}
+
+// Returns the gRPC server options that require peers to present a certificate signed by
+// the certificate authorities of the replica and that refuse Raft RPCs from clients
+// whose certificates do not identify a peer.
+func (r *Replica) serverOptions() []grpc.ServerOption {
+	if r.tls == nil {
+		return nil
+	}
+
+	return []grpc.ServerOption{
+		grpc.Creds(credentials.NewTLS(r.tls)),
+		grpc.ChainUnaryInterceptor(r.authenticateUnary),
+		grpc.ChainStreamInterceptor(r.authenticateStream),
+	}
+}
+
+// Returns the dial options used to connect to the peer. If mutual TLS is configured,
+// the certificate of the peer must be valid for the name of the peer in the quorum.
+func (r *Replica) peerDialOptions(peer *peers.Peer) []grpc.DialOption {
+	var opts []grpc.DialOption
+	if r.dialOptions != nil {
+		opts = r.dialOptions(peer)
+	}
+
+	if r.tls != nil {
+		conf := r.tls.Clone()
+		conf.ServerName = peer.Name
+		opts = append(opts, grpc.WithTransportCredentials(credentials.NewTLS(conf)))
+	}
+	return opts
+}
+
+// The key of the authenticated name of the remote peer in the context of an RPC.
+type peerKey struct{}
+
+// Refuse unary Raft RPCs from clients whose certificates do not identify a peer.
+func (r *Replica) authenticateUnary(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if !strings.HasPrefix(info.FullMethod, raftService) {
+		return handler(ctx, req)
+	}
+
+	names, err := r.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return handler(context.WithValue(ctx, peerKey{}, names), req)
+}
+
+// Refuse streaming Raft RPCs from clients whose certificates do not identify a peer.
+func (r *Replica) authenticateStream(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if !strings.HasPrefix(info.FullMethod, raftService) {
+		return handler(srv, stream)
+	}
+
+	names, err := r.authenticate(stream.Context())
+	if err != nil {
+		return err
+	}
+	return handler(srv, &authenticatedStream{ServerStream: stream, ctx: context.WithValue(stream.Context(), peerKey{}, names)})
+}
+
+// Returns the names in the verified certificate of the client if any of them is the name
+// of a peer of the replica, otherwise an error is returned.
+func (r *Replica) authenticate(ctx context.Context) ([]string, error) {
+	names := certificateNames(ctx)
+	for _, name := range names {
+		if r.isPeer(name) {
+			return names, nil
+		}
+	}
+
+	log.Warn().Strs("names", names).Msg("refused raft rpc from client that is not a peer")
+	return nil, status.Error(codes.PermissionDenied, ErrNotPeer.Error())
+}
+
+// Returns a PermissionDenied error if the remote replica named in a request does not
+// match the certificate of the client that sent it, e.g. if a peer impersonates the
+// leader. If mutual TLS is not configured, requests are not authenticated.
+func (r *Replica) checkRemote(ctx context.Context, remote string) error {
+	if r.tls == nil {
+		return nil
+	}
+
+	names, _ := ctx.Value(peerKey{}).([]string)
+	for _, name := range names {
+		if name == remote {
+			return nil
+		}
+	}
+
+	log.Warn().Str("remote", remote).Strs("names", names).Msg("refused raft rpc on behalf of another replica")
+	return status.Error(codes.PermissionDenied, fmt.Sprintf("%s: certificate is not valid for %s", ErrNotPeer, remote))
+}
+
+// Returns true if the name is a remote peer in the latest configuration or the peers
+// file, so that a replica that is joining the quorum accepts RPCs from the leader.
+func (r *Replica) isPeer(name string) bool {
+	if name == "" || name == r.name {
+		return false
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if _, err := r.peers.Get(name); err == nil {
+		return true
+	}
+
+	if r.initial != nil {
+		for _, member := range r.initial.Current {
+			if member.Name == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Returns the DNS names and common name of the verified client certificate of the RPC.
+func certificateNames(ctx context.Context) []string {
+	remote, ok := peer.FromContext(ctx)
+	if !ok {
+		return nil
+	}
+
+	info, ok := remote.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(info.State.VerifiedChains) == 0 || len(info.State.VerifiedChains[0]) == 0 {
+		return nil
+	}
+
+	cert := info.State.VerifiedChains[0][0]
+	names := make([]string, 0, len(cert.DNSNames)+1)
+	names = append(names, cert.DNSNames...)
+	if cert.Subject.CommonName != "" {
+		names = append(names, cert.Subject.CommonName)
+	}
+	return names
+}
+
+// Wraps a server stream so that handlers receive the authenticated context.
+type authenticatedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authenticatedStream) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,311 @@
+package replica
+
+import (
+	"context"
+	"time"
+
+	"github.com/bbengfort/otterdb/pkg/replica/events"
+	"github.com/bbengfort/otterdb/pkg/replica/peers"
+	"github.com/bbengfort/otterdb/pkg/replica/raft/v1"
+
+	"github.com/rs/zerolog/log"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// TransferLeadership hands off leadership of the quorum to the target replica, e.g.
+// before the leader is restarted for planned maintenance. The leader stops accepting
+// proposals, brings the target up to date, and then tells the target to start an
+// election immediately so that it is elected before any other replica times out. If
+// the target is empty, leadership is transferred to the voting member with the lowest
+// PID. Blocks until the leader has stepped down; if the leader does not step down within
+// an election timeout the transfer is aborted, the leader accepts proposals again, and
+// ErrTransferTimeout is returned.
+func (r *Replica) TransferLeadership(ctx context.Context, target string) (err error) {
+	reply := make(chan error, 1)
+	if err = r.Dispatch(&events.Message{Type: events.TransferLeadership, Source: reply, Value: target}); err != nil {
+		return err
+	}
+
+	select {
+	case err = <-reply:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// TimeoutNow is called by the leader when it transfers leadership to the local replica.
+// The request is dispatched to the event loop and the handler blocks until the event
+// loop replies or the request is canceled by the remote.
+func (r *Replica) TimeoutNow(ctx context.Context, in *raft.TimeoutNowRequest) (out *raft.TimeoutNowReply, err error) {
+	if err = r.checkCluster(in.Leader, in.ClusterID); err != nil {
+		return nil, err
+	}
+
+	if err = r.checkRemote(ctx, in.Leader); err != nil {
+		return nil, err
+	}
+
+	reply := make(chan *raft.TimeoutNowReply, 1)
+	if err = r.Dispatch(&events.Message{Type: events.TimeoutNow, Source: reply, Value: in}); err != nil {
+		return nil, status.Error(codes.Unavailable, err.Error())
+	}
+
+	select {
+	case out = <-reply:
+		return out, nil
+	case <-ctx.Done():
+		return nil, status.FromContextError(ctx.Err()).Err()
+	}
+}
+
+// A leadership transfer in progress on the leader; the reply is sent once the leader
+// steps down or the transfer is aborted.
+type transfer struct {
+	target string       // The name of the replica that leadership is transferred to
+	reply  chan<- error // The caller waiting for the transfer to complete
+	timer  *time.Timer  // Aborts the transfer if it does not complete in time
+	sent   bool         // True once the target has been told to start an election
+}
+
+// Start transferring leadership to the target if the local replica is the leader and no
+// other transfer is in progress. Proposals are rejected until the transfer completes.
+//
+// NOTE: This method is not thread-safe and should only be called from the event loop.
+func (r *Replica) onTransferLeadership(e events.Event) (err error) {
+	var (
+		target string
+		reply  chan<- error
+	)
+
+	if target, reply, err = transferEvent(e); err != nil {
+		return err
+	}
+
+	if r.state != Leader {
+		reply <- ErrNotLeader
+		return nil
+	}
+
+	if r.transfer != nil {
+		reply <- ErrTransferring
+		return nil
+	}
+
+	// By default leadership is transferred to the voting member with the lowest PID.
+	if target == "" {
+		voters := make(peers.Peers, 0, len(r.peers))
+		for _, peer := range r.peers {
+			if r.quorum.Contains(peer.Name) {
+				voters = append(voters, peer)
+			}
+		}
+
+		if target = voters.Presiding(); target == "" {
+			reply <- ErrNoTransferTarget
+			return nil
+		}
+	}
+
+	if target == r.name {
+		reply <- nil
+		return nil
+	}
+
+	// Learners and replicas outside of the quorum cannot be elected.
+	if _, err = r.peers.Get(target); err != nil || !r.quorum.Contains(target) {
+		reply <- ErrNotMember
+		return nil
+	}
+
+	r.startTransfer(target, reply)
+	return nil
+}
+
+// Stop accepting proposals and start bringing the target up to date; the transfer is
+// aborted if the leader has not stepped down before the transfer timeout.
+//
+// NOTE: This method is not thread-safe and should only be called from the event loop.
+func (r *Replica) startTransfer(target string, reply chan<- error) {
+	t := &transfer{target: target, reply: reply}
+	t.timer = time.AfterFunc(r.transferTimeout(), func() {
+		r.Dispatch(&events.Message{Type: events.TransferTimeout, Value: t})
+	})
+	r.transfer = t
+
+	log.Info().Uint64("term", r.term).Str("target", target).Msg("transferring leadership")
+	r.advanceTransfer()
+}
+
+// Abort the transfer if it is still in progress when its timeout fires.
+//
+// NOTE: This method is not thread-safe and should only be called from the event loop.
+func (r *Replica) onTransferTimeout(e events.Event) (err error) {
+	var t *transfer
+	if t, err = transferTimeoutEvent(e); err != nil {
+		return err
+	}
+
+	// Ignore timeouts of transfers that have already completed.
+	if r.transfer != t {
+		return nil
+	}
+
+	log.Warn().Uint64("term", r.term).Str("target", t.target).Msg("leadership transfer timed out")
+	r.transferFailed = time.Now()
+	r.endTransfer(ErrTransferTimeout)
+	return nil
+}
+
+// Start an election immediately if the leader of the current term transfers leadership
+// to the local replica, without waiting for the election timeout.
+//
+// NOTE: This method is not thread-safe and should only be called from the event loop.
+func (r *Replica) onTimeoutNow(e events.Event) (err error) {
+	var (
+		req   *raft.TimeoutNowRequest
+		reply chan<- *raft.TimeoutNowReply
+	)
+
+	if req, reply, err = timeoutNowEvent(e); err != nil {
+		return err
+	}
+
+	// If the leader is in a later term, step down and move into that term.
+	if req.Term > r.term {
+		if err = r.setTerm(req.Term); err != nil {
+			return err
+		}
+		if err = r.setState(Follower); err != nil {
+			return err
+		}
+	}
+
+	out := &raft.TimeoutNowReply{Remote: r.name, Term: r.term}
+	if req.Term < r.term || r.state == Leader || !r.quorum.Contains(r.name) {
+		reply <- out
+		return nil
+	}
+
+	log.Info().Uint64("term", r.term).Str("leader", req.Leader).Msg("leadership transferred to replica")
+	out.Accepted = true
+	reply <- out
+	return r.campaign(true)
+}
+
+// Once the target has replicated the entire log of the leader it is told to start an
+// election, otherwise the missing entries are sent to the target.
+//
+// NOTE: This method is not thread-safe and should only be called from the event loop.
+func (r *Replica) advanceTransfer() {
+	t := r.transfer
+	if t == nil || t.sent {
+		return
+	}
+
+	peer, err := r.peers.Get(t.target)
+	if err != nil {
+		// The target was removed from the quorum during the transfer.
+		r.endTransfer(ErrNotMember)
+		return
+	}
+
+	if r.matchIndex[t.target] < r.log.LastIndex() {
+		r.sendAppendEntries(peer, false)
+		return
+	}
+
+	t.sent = true
+	req := &raft.TimeoutNowRequest{Term: r.term, Leader: r.name, ClusterID: r.clusterID}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), r.conf.Timeout)
+		defer cancel()
+
+		reply, err := peer.TimeoutNow(ctx, req)
+		if err != nil {
+			log.Warn().Err(err).Str("peer", peer.Name).Msg("timeout now rpc failed")
+			return
+		}
+
+		if !reply.Accepted {
+			log.Warn().Str("peer", peer.Name).Uint64("term", reply.Term).Msg("transfer target did not start an election")
+		}
+	}()
+}
+
+// Complete the transfer in progress, if any, replying to the caller with the error. If
+// the target was told to start an election, it may have been granted votes within the
+// lease of the leader, so the lease is fenced until leadership is acknowledged again.
+//
+// NOTE: This method is not thread-safe and should only be called from the event loop.
+func (r *Replica) endTransfer(err error) {
+	if r.transfer == nil {
+		return
+	}
+
+	if r.transfer.sent {
+		r.leaseFence = time.Now()
+	}
+
+	r.transfer.timer.Stop()
+	r.transfer.reply <- err
+	r.transfer = nil
+}
+
+func transferEvent(e events.Event) (target string, reply chan<- error, err error) {
+	var (
+		msg *events.Message
+		ok  bool
+	)
+
+	if msg, ok = e.(*events.Message); !ok {
+		return "", nil, ErrEventTypeError
+	}
+
+	if target, ok = msg.Value.(string); !ok {
+		return "", nil, ErrEventTypeError
+	}
+
+	if reply, ok = msg.Source.(chan error); !ok {
+		return "", nil, ErrEventSourceError
+	}
+	return target, reply, nil
+}
+
+func transferTimeoutEvent(e events.Event) (t *transfer, err error) {
+	var (
+		msg *events.Message
+		ok  bool
+	)
+
+	if msg, ok = e.(*events.Message); !ok {
+		return nil, ErrEventTypeError
+	}
+
+	if t, ok = msg.Value.(*transfer); !ok {
+		return nil, ErrEventTypeError
+	}
+	return t, nil
+}
+
+func timeoutNowEvent(e events.Event) (req *raft.TimeoutNowRequest, reply chan<- *raft.TimeoutNowReply, err error) {
+	var (
+		msg *events.Message
+		ok  bool
+	)
+
+	if msg, ok = e.(*events.Message); !ok {
+		return nil, nil, ErrEventTypeError
+	}
+
+	if req, ok = msg.Value.(*raft.TimeoutNowRequest); !ok {
+		return nil, nil, ErrEventTypeError
+	}
+
+	if reply, ok = msg.Source.(chan *raft.TimeoutNowReply); !ok {
+		return nil, nil, ErrEventSourceError
+	}
+	return req, reply, nil
+}
@@ -0,0 +1,8 @@
+package wal
+
+import "errors"
+
+var (
+	ErrCorrupt            = errors.New("write-ahead log is corrupt")
+	ErrInvalidSegmentSize = errors.New("segment size must be greater than zero")
+)
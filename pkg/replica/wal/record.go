@@ -0,0 +1,111 @@
+package wal
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+
+	"github.com/bbengfort/otterdb/pkg/replica/logstore"
+)
+
+// Record types that are stored in the log.
+const (
+	recordUnknown recordType = iota
+	recordEntry
+	recordState
+)
+
+// Every record is prefixed with a header that contains the length of the record data
+// and a checksum of the record type and data.
+const (
+	headerSize    = 8
+	maxRecordSize = 64 * 1024 * 1024
+)
+
+var (
+	crcTable      = crc32.MakeTable(crc32.Castagnoli)
+	errTornRecord = errors.New("incomplete or corrupt record at end of segment")
+)
+
+type recordType uint8
+
+// Encode a record as a header containing the length and checksum of the data followed
+// by the record type and the data itself.
+func encodeRecord(rtype recordType, data []byte) []byte {
+	record := make([]byte, headerSize+1+len(data))
+	record[headerSize] = byte(rtype)
+	copy(record[headerSize+1:], data)
+
+	binary.LittleEndian.PutUint32(record[0:4], uint32(len(data)+1))
+	binary.LittleEndian.PutUint32(record[4:8], crc32.Checksum(record[headerSize:], crcTable))
+	return record
+}
+
+// Encode the log metadata and the first index of the log as a state record.
+func encodeState(meta logstore.LogMeta, first uint64) []byte {
+	data := make([]byte, 2*binary.MaxVarintLen64)
+	n := binary.PutUvarint(data, first)
+	n += binary.PutUvarint(data[n:], meta.LastApplied)
+	return data[:n]
+}
+
+// Decode the log metadata and the first index of the log from a state record.
+func decodeState(data []byte) (meta logstore.LogMeta, first uint64, err error) {
+	var n int
+	for _, field := range []*uint64{&first, &meta.LastApplied} {
+		var m int
+		if *field, m = binary.Uvarint(data[n:]); m <= 0 {
+			return meta, 0, fmt.Errorf("could not decode state record: %w", ErrCorrupt)
+		}
+		n += m
+	}
+
+	if n != len(data) {
+		return meta, 0, fmt.Errorf("could not decode state record: %w", ErrCorrupt)
+	}
+	return meta, first, nil
+}
+
+// Reads records sequentially from a segment, tracking the offset of the next record.
+type reader struct {
+	buf    *bufio.Reader
+	offset int64
+}
+
+func newReader(r io.Reader) *reader {
+	return &reader{buf: bufio.NewReader(r)}
+}
+
+// Read the next record from the segment; returns io.EOF if there are no more records
+// and errTornRecord if the record is incomplete or does not match its checksum.
+func (r *reader) next() (rtype recordType, data []byte, err error) {
+	header := make([]byte, headerSize)
+	var n int
+	if n, err = io.ReadFull(r.buf, header); err != nil {
+		if errors.Is(err, io.EOF) && n == 0 {
+			return recordUnknown, nil, io.EOF
+		}
+		return recordUnknown, nil, errTornRecord
+	}
+
+	length := binary.LittleEndian.Uint32(header[0:4])
+	checksum := binary.LittleEndian.Uint32(header[4:8])
+	if length == 0 || length > maxRecordSize {
+		return recordUnknown, nil, errTornRecord
+	}
+
+	record := make([]byte, length)
+	if _, err = io.ReadFull(r.buf, record); err != nil {
+		return recordUnknown, nil, errTornRecord
+	}
+
+	if crc32.Checksum(record, crcTable) != checksum {
+		return recordUnknown, nil, errTornRecord
+	}
+
+	r.offset += int64(headerSize + len(record))
+	return recordType(record[0]), record[1:], nil
+}
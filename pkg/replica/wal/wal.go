@@ -0,0 +1,606 @@
+/*
+Package wal implements a durable, segmented write-ahead log for raft log entries. Entries
+are written as length-prefixed, checksummed records to segment files in a directory; a
+new segment is started when the active segment grows past the configured size. Every
+write is synced to disk before it is acknowledged so that a replica does not lose
+entries that it has acknowledged to its peers if it crashes.
+
+The log metadata (the last applied index of the replica and the first index of the log)
+is also persisted in the log as state records; the most recent state record in the
+log is the current state. Every segment begins with a state record so that the state is
+not lost when older segments are removed by compaction. When the log is opened, every
+segment is read and verified; a torn write at the end of the last segment (e.g. from a
+crash in the middle of an append) is truncated so that the log can be appended to again.
+*/
+package wal
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/bbengfort/otterdb/pkg/replica/logstore"
+	"github.com/bbengfort/otterdb/pkg/replica/raft/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	DefaultSegmentSize = 64 * 1024 * 1024 // Start a new segment after 64MiB
+	segmentExt         = ".wal"
+	segmentNameLength  = 20
+)
+
+// WAL is a durable log of raft entries that is stored in segment files on disk. All of
+// the entries are also cached in memory for fast access. The WAL is thread-safe.
+type WAL struct {
+	sync.RWMutex
+	dir         string
+	segmentSize int64
+	segments    []*segment       // all segments in order, the last segment is active
+	active      *os.File         // the file handle of the active segment for appending
+	first       uint64           // the index of the first entry in the log
+	entries     []*raft.LogEntry // cached entries in the log starting at first
+	positions   []position       // the location of each entry on disk
+	meta        logstore.LogMeta // the metadata from the latest state record
+}
+
+var _ logstore.LogStore = &WAL{}
+
+// Describes a segment file on disk, identified by the index of its first entry.
+type segment struct {
+	index uint64
+	path  string
+	size  int64
+}
+
+// The location of an entry on disk, used to truncate the log.
+type position struct {
+	segment *segment
+	offset  int64
+}
+
+// Option configures the WAL when it is opened.
+type Option func(w *WAL)
+
+// WithSegmentSize sets the size in bytes that a segment can grow to before the log
+// starts writing to a new segment. Segments may be slightly larger than this size
+// since records are never split between segments.
+func WithSegmentSize(size int64) Option {
+	return func(w *WAL) {
+		w.segmentSize = size
+	}
+}
+
+// Open the write-ahead log in the specified directory, creating the directory if it
+// does not exist. All segments are read and verified and a torn write at the end of
+// the log is truncated. The first entry in a new log will have index 1.
+func Open(dir string, opts ...Option) (w *WAL, err error) {
+	w = &WAL{dir: dir, segmentSize: DefaultSegmentSize, first: 1}
+	for _, opt := range opts {
+		opt(w)
+	}
+
+	if w.segmentSize <= 0 {
+		return nil, ErrInvalidSegmentSize
+	}
+
+	if err = os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("could not create wal directory: %w", err)
+	}
+
+	if err = w.recover(); err != nil {
+		return nil, err
+	}
+
+	// If there are no segments in the log, create the first segment.
+	if len(w.segments) == 0 {
+		if err = w.createSegment(w.first); err != nil {
+			return nil, err
+		}
+		return w, nil
+	}
+
+	// Open the last segment for appending.
+	if w.active, err = os.OpenFile(w.activeSegment().path, os.O_WRONLY|os.O_APPEND, 0644); err != nil {
+		return nil, fmt.Errorf("could not open active segment: %w", err)
+	}
+	return w, nil
+}
+
+// Close the active segment; the WAL cannot be used after it is closed.
+func (w *WAL) Close() (err error) {
+	w.Lock()
+	defer w.Unlock()
+
+	if w.active == nil {
+		return logstore.ErrClosed
+	}
+
+	if err = w.active.Sync(); err != nil {
+		return err
+	}
+
+	err = w.active.Close()
+	w.active = nil
+	return err
+}
+
+//===========================================================================
+// Accessors
+//===========================================================================
+
+// FirstIndex returns the index of the first entry in the log.
+func (w *WAL) FirstIndex() uint64 {
+	w.RLock()
+	defer w.RUnlock()
+	return w.first
+}
+
+// LastIndex returns the index of the last entry in the log or the index before the
+// first entry if the log is empty.
+func (w *WAL) LastIndex() uint64 {
+	w.RLock()
+	defer w.RUnlock()
+	return w.lastIndex()
+}
+
+func (w *WAL) lastIndex() uint64 {
+	return w.first + uint64(len(w.entries)) - 1
+}
+
+// LastTerm returns the term of the last entry in the log or zero if the log is empty.
+func (w *WAL) LastTerm() uint64 {
+	w.RLock()
+	defer w.RUnlock()
+
+	if len(w.entries) == 0 {
+		return 0
+	}
+	return w.entries[len(w.entries)-1].Term
+}
+
+// Get the entry at the specified index; returns ErrNotFound if the entry is not in
+// the log or ErrCompacted if the entry was removed by compaction.
+func (w *WAL) Get(index uint64) (*raft.LogEntry, error) {
+	w.RLock()
+	defer w.RUnlock()
+
+	if index < w.first {
+		return nil, logstore.ErrCompacted
+	}
+
+	if index > w.lastIndex() {
+		return nil, logstore.ErrNotFound
+	}
+	return w.entries[index-w.first], nil
+}
+
+// Entries returns the entries in the range [lo, hi) from the log.
+func (w *WAL) Entries(lo, hi uint64) ([]*raft.LogEntry, error) {
+	w.RLock()
+	defer w.RUnlock()
+	return logstore.Slice(w.entries, w.first, lo, hi)
+}
+
+// Meta returns the most recently saved log metadata.
+func (w *WAL) Meta() logstore.LogMeta {
+	w.RLock()
+	defer w.RUnlock()
+	return w.meta
+}
+
+//===========================================================================
+// Log Modification
+//===========================================================================
+
+// Append entries to the log, syncing them to disk before returning. The entries must
+// directly follow the last entry in the log.
+func (w *WAL) Append(entries ...*raft.LogEntry) (err error) {
+	w.Lock()
+	defer w.Unlock()
+
+	if w.active == nil {
+		return logstore.ErrClosed
+	}
+
+	for _, entry := range entries {
+		if entry.Index != w.lastIndex()+1 {
+			return fmt.Errorf("cannot append entry %d after %d: %w", entry.Index, w.lastIndex(), logstore.ErrOutOfOrder)
+		}
+
+		// Rotate the segment before writing if the active segment is full.
+		if w.activeSegment().size >= w.segmentSize {
+			if err = w.rotate(entry.Index); err != nil {
+				return err
+			}
+		}
+
+		var data []byte
+		if data, err = proto.Marshal(entry); err != nil {
+			return fmt.Errorf("could not marshal entry %d: %w", entry.Index, err)
+		}
+
+		var offset int64
+		if offset, err = w.write(recordEntry, data); err != nil {
+			return err
+		}
+
+		w.entries = append(w.entries, entry)
+		w.positions = append(w.positions, position{segment: w.activeSegment(), offset: offset})
+	}
+
+	return w.active.Sync()
+}
+
+// SaveMeta persists the log metadata, syncing it to disk before returning.
+func (w *WAL) SaveMeta(meta logstore.LogMeta) (err error) {
+	w.Lock()
+	defer w.Unlock()
+
+	if w.active == nil {
+		return logstore.ErrClosed
+	}
+
+	if _, err = w.write(recordState, encodeState(meta, w.first)); err != nil {
+		return err
+	}
+
+	if err = w.active.Sync(); err != nil {
+		return err
+	}
+
+	w.meta = meta
+	return nil
+}
+
+// TruncateSuffix removes all entries after the specified index from the log, e.g. when
+// entries conflict with the entries of a new leader. The truncation is synced to disk
+// before returning.
+func (w *WAL) TruncateSuffix(index uint64) (err error) {
+	w.Lock()
+	defer w.Unlock()
+
+	if w.active == nil {
+		return logstore.ErrClosed
+	}
+
+	if index >= w.lastIndex() {
+		return nil
+	}
+
+	if index+1 < w.first {
+		return fmt.Errorf("cannot truncate before first index %d: %w", w.first, logstore.ErrCompacted)
+	}
+
+	// Find the position of the first entry to remove.
+	pos := w.positions[index+1-w.first]
+
+	// Close the active segment and remove all segments after the truncated segment.
+	if err = w.active.Close(); err != nil {
+		return err
+	}
+	w.active = nil
+
+	var keep int
+	for keep = len(w.segments) - 1; w.segments[keep] != pos.segment; keep-- {
+		if err = os.Remove(w.segments[keep].path); err != nil {
+			return fmt.Errorf("could not remove segment: %w", err)
+		}
+		w.segments[keep] = nil
+	}
+	w.segments = w.segments[:keep+1]
+
+	// Truncate the segment at the offset of the removed entry and reopen it.
+	if err = os.Truncate(pos.segment.path, pos.offset); err != nil {
+		return fmt.Errorf("could not truncate segment: %w", err)
+	}
+	pos.segment.size = pos.offset
+
+	if w.active, err = os.OpenFile(pos.segment.path, os.O_WRONLY|os.O_APPEND, 0644); err != nil {
+		return fmt.Errorf("could not open active segment: %w", err)
+	}
+
+	// Remove the entries from the cache
+	n := index + 1 - w.first
+	for i := n; i < uint64(len(w.entries)); i++ {
+		w.entries[i] = nil
+	}
+	w.entries = w.entries[:n]
+	w.positions = w.positions[:n]
+
+	// The truncated records may have included the latest state so rewrite it.
+	if _, err = w.write(recordState, encodeState(w.meta, w.first)); err != nil {
+		return err
+	}
+
+	if err = w.active.Sync(); err != nil {
+		return err
+	}
+	return syncDir(w.dir)
+}
+
+// TruncatePrefix removes all entries before the specified index from the log, e.g.
+// after the entries have been compacted into a snapshot. Segments that only contain
+// removed entries are deleted; the new first index is stored in a state record so that
+// entries that remain in the first segment are not recovered.
+func (w *WAL) TruncatePrefix(index uint64) (err error) {
+	w.Lock()
+	defer w.Unlock()
+
+	if w.active == nil {
+		return logstore.ErrClosed
+	}
+
+	if index <= w.first {
+		return nil
+	}
+
+	// If all entries are removed then start over with an empty segment at the index.
+	if index > w.lastIndex() {
+		if err = w.active.Close(); err != nil {
+			return err
+		}
+		w.active = nil
+
+		// Create the new segment before removing the old ones so the state is never lost.
+		old := w.segments
+		w.segments, w.entries, w.positions, w.first = nil, nil, nil, index
+		if err = w.createSegment(index); err != nil {
+			return err
+		}
+
+		for _, seg := range old {
+			if seg.path == w.activeSegment().path {
+				continue
+			}
+
+			if err = os.Remove(seg.path); err != nil {
+				return fmt.Errorf("could not remove segment: %w", err)
+			}
+		}
+		return syncDir(w.dir)
+	}
+
+	// Write the new first index to the active segment before removing any segments.
+	if _, err = w.write(recordState, encodeState(w.meta, index)); err != nil {
+		return err
+	}
+
+	if err = w.active.Sync(); err != nil {
+		return err
+	}
+
+	// Remove all segments whose entries are all before the new first index.
+	pos := w.positions[index-w.first]
+	var remove int
+	for remove = 0; w.segments[remove] != pos.segment; remove++ {
+		if err = os.Remove(w.segments[remove].path); err != nil {
+			return fmt.Errorf("could not remove segment: %w", err)
+		}
+	}
+
+	// Copy the remaining segments, entries, and positions so the removed ones can be
+	// garbage collected.
+	w.segments = append([]*segment(nil), w.segments[remove:]...)
+	w.entries = append([]*raft.LogEntry(nil), w.entries[index-w.first:]...)
+	w.positions = append([]position(nil), w.positions[index-w.first:]...)
+	w.first = index
+	return syncDir(w.dir)
+}
+
+//===========================================================================
+// Segment Management
+//===========================================================================
+
+// Returns the segment that is currently being appended to.
+func (w *WAL) activeSegment() *segment {
+	return w.segments[len(w.segments)-1]
+}
+
+// Write a record to the active segment returning the offset it was written at.
+func (w *WAL) write(rtype recordType, data []byte) (offset int64, err error) {
+	seg := w.activeSegment()
+	offset = seg.size
+
+	record := encodeRecord(rtype, data)
+	if _, err = w.active.Write(record); err != nil {
+		return 0, fmt.Errorf("could not write record: %w", err)
+	}
+
+	seg.size += int64(len(record))
+	return offset, nil
+}
+
+// Close the active segment and start a new segment beginning at the specified index.
+func (w *WAL) rotate(index uint64) (err error) {
+	if err = w.active.Sync(); err != nil {
+		return err
+	}
+
+	if err = w.active.Close(); err != nil {
+		return err
+	}
+	w.active = nil
+
+	return w.createSegment(index)
+}
+
+// Create a new segment file whose first entry will be the specified index, making it
+// the active segment. The current state is written at the start of every segment so
+// that it is not lost when older segments are removed.
+func (w *WAL) createSegment(index uint64) (err error) {
+	seg := &segment{index: index, path: filepath.Join(w.dir, segmentName(index))}
+
+	// If the segment already exists it can only contain entries that were removed by
+	// prefix truncation, so it is safe to overwrite it.
+	if w.active, err = os.OpenFile(seg.path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC|os.O_APPEND, 0644); err != nil {
+		return fmt.Errorf("could not create segment: %w", err)
+	}
+	w.segments = append(w.segments, seg)
+
+	if _, err = w.write(recordState, encodeState(w.meta, w.first)); err != nil {
+		return err
+	}
+
+	if err = w.active.Sync(); err != nil {
+		return err
+	}
+	return syncDir(w.dir)
+}
+
+// Read all segments from disk, verifying every record and loading the entries and the
+// latest state into memory. A torn or corrupt record at the end of the last segment is
+// truncated, corruption in any other segment returns an error.
+func (w *WAL) recover() (err error) {
+	var paths []string
+	if paths, err = listSegments(w.dir); err != nil {
+		return err
+	}
+
+	first := w.first
+	for i, path := range paths {
+		seg := &segment{path: path}
+		if seg.index, err = parseSegmentName(filepath.Base(path)); err != nil {
+			return err
+		}
+
+		// The first segment determines the first index of the log.
+		switch {
+		case i == 0:
+			w.first = seg.index
+		case seg.index > w.lastIndex()+1:
+			// A gap in the log is only created when a crash interrupts a prefix truncation
+			// past the end of the log so the segments before the gap can be removed.
+			for _, stale := range w.segments {
+				if err = os.Remove(stale.path); err != nil {
+					return fmt.Errorf("could not remove segment: %w", err)
+				}
+			}
+			w.segments, w.entries, w.positions, w.first = nil, nil, nil, seg.index
+		case seg.index < w.lastIndex()+1:
+			return fmt.Errorf("segment %s does not follow index %d: %w", filepath.Base(path), w.lastIndex(), ErrCorrupt)
+		}
+
+		w.segments = append(w.segments, seg)
+		isLast := i == len(paths)-1
+		if first, err = w.recoverSegment(seg, isLast, first); err != nil {
+			return err
+		}
+	}
+
+	// Remove any entries that were before the first index recorded in the latest state.
+	if first > w.first {
+		if first > w.lastIndex()+1 {
+			return fmt.Errorf("first index %d is after the last entry %d: %w", first, w.lastIndex(), ErrCorrupt)
+		}
+
+		w.entries = w.entries[first-w.first:]
+		w.positions = w.positions[first-w.first:]
+		w.first = first
+	}
+	return nil
+}
+
+// Read the records in the segment, returning the first index from the last state
+// record in the segment (or the previous first index if there is no state record).
+func (w *WAL) recoverSegment(seg *segment, isLast bool, first uint64) (_ uint64, err error) {
+	var f *os.File
+	if f, err = os.Open(seg.path); err != nil {
+		return 0, fmt.Errorf("could not open segment: %w", err)
+	}
+	defer f.Close()
+
+	reader := newReader(f)
+	for {
+		var (
+			rtype  recordType
+			data   []byte
+			offset = reader.offset
+		)
+
+		if rtype, data, err = reader.next(); err != nil {
+			if errors.Is(err, io.EOF) {
+				seg.size = reader.offset
+				return first, nil
+			}
+
+			if isLast && errors.Is(err, errTornRecord) {
+				// Truncate the torn write so that appends can continue after the last
+				// complete record in the log.
+				if err = os.Truncate(seg.path, offset); err != nil {
+					return 0, fmt.Errorf("could not truncate torn write: %w", err)
+				}
+				seg.size = offset
+				return first, nil
+			}
+			return 0, fmt.Errorf("could not read segment %s at offset %d: %w", filepath.Base(seg.path), offset, ErrCorrupt)
+		}
+
+		switch rtype {
+		case recordEntry:
+			entry := &raft.LogEntry{}
+			if err = proto.Unmarshal(data, entry); err != nil {
+				return 0, fmt.Errorf("could not unmarshal entry: %w", ErrCorrupt)
+			}
+
+			if entry.Index != w.lastIndex()+1 {
+				return 0, fmt.Errorf("entry %d does not follow index %d: %w", entry.Index, w.lastIndex(), ErrCorrupt)
+			}
+
+			w.entries = append(w.entries, entry)
+			w.positions = append(w.positions, position{segment: seg, offset: offset})
+		case recordState:
+			if w.meta, first, err = decodeState(data); err != nil {
+				return 0, err
+			}
+		default:
+			return 0, fmt.Errorf("unknown record type %d: %w", rtype, ErrCorrupt)
+		}
+	}
+}
+
+func segmentName(index uint64) string {
+	return fmt.Sprintf("%0*d%s", segmentNameLength, index, segmentExt)
+}
+
+func parseSegmentName(name string) (uint64, error) {
+	index, err := strconv.ParseUint(strings.TrimSuffix(name, segmentExt), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("could not parse segment name %q: %w", name, ErrCorrupt)
+	}
+	return index, nil
+}
+
+// Returns the paths of all segments in the directory sorted by index.
+func listSegments(dir string) (paths []string, err error) {
+	var entries []os.DirEntry
+	if entries, err = os.ReadDir(dir); err != nil {
+		return nil, err
+	}
+
+	for _, entry := range entries {
+		if !entry.IsDir() && filepath.Ext(entry.Name()) == segmentExt {
+			paths = append(paths, filepath.Join(dir, entry.Name()))
+		}
+	}
+
+	// Segment names are zero padded so lexical order is index order.
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// Sync the directory to ensure that created, renamed, or removed files are durable.
+func syncDir(dir string) (err error) {
+	var f *os.File
+	if f, err = os.Open(dir); err != nil {
+		return err
+	}
+	defer f.Close()
+	return f.Sync()
+}
@@ -0,0 +1,223 @@
+package wal_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bbengfort/otterdb/pkg/replica/logstore"
+	"github.com/bbengfort/otterdb/pkg/replica/raft/v1"
+	"github.com/bbengfort/otterdb/pkg/replica/wal"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWAL(t *testing.T) {
+	dir := t.TempDir()
+	log, err := wal.Open(dir)
+	require.NoError(t, err, "could not open empty wal")
+
+	require.Equal(t, uint64(1), log.FirstIndex())
+	require.Equal(t, uint64(0), log.LastIndex())
+
+	_, err = log.Get(1)
+	require.ErrorIs(t, err, logstore.ErrNotFound)
+
+	require.NoError(t, log.Append(entries(1, 10, 1)...))
+	require.Equal(t, uint64(10), log.LastIndex())
+
+	entry, err := log.Get(4)
+	require.NoError(t, err)
+	require.Equal(t, uint64(4), entry.Index)
+
+	// Cannot append entries out of order
+	require.ErrorIs(t, log.Append(entries(12, 12, 1)...), logstore.ErrOutOfOrder)
+
+	require.NoError(t, log.SaveMeta(logstore.LogMeta{LastApplied: 3}))
+	require.NoError(t, log.Append(entries(11, 20, 3)...))
+	require.NoError(t, log.Close())
+	require.ErrorIs(t, log.Append(entries(21, 21, 3)...), logstore.ErrClosed)
+
+	// Reopen the log and ensure everything has been recovered
+	log, err = wal.Open(dir)
+	require.NoError(t, err, "could not reopen wal")
+	defer log.Close()
+
+	require.Equal(t, uint64(20), log.LastIndex())
+	requireEntries(t, log, 1, 20)
+
+	require.Equal(t, logstore.LogMeta{LastApplied: 3}, log.Meta())
+}
+
+func TestTruncateSuffix(t *testing.T) {
+	dir := t.TempDir()
+	log, err := wal.Open(dir, wal.WithSegmentSize(256))
+	require.NoError(t, err, "could not open empty wal")
+
+	require.NoError(t, log.Append(entries(1, 50, 1)...))
+	require.NoError(t, log.SaveMeta(logstore.LogMeta{LastApplied: 2}))
+	require.Greater(t, countSegments(t, dir), 2, "expected the log to rotate segments")
+
+	// Truncating after the last index is a no-op
+	require.NoError(t, log.TruncateSuffix(50))
+	require.Equal(t, uint64(50), log.LastIndex())
+
+	// Truncate into an earlier segment and append new entries from a later term
+	require.NoError(t, log.TruncateSuffix(12))
+	require.Equal(t, uint64(12), log.LastIndex())
+	require.NoError(t, log.Append(entries(13, 30, 2)...))
+	require.NoError(t, log.Close())
+
+	log, err = wal.Open(dir, wal.WithSegmentSize(256))
+	require.NoError(t, err, "could not reopen wal")
+	defer log.Close()
+
+	require.Equal(t, uint64(30), log.LastIndex())
+	requireEntries(t, log, 1, 30)
+
+	entry, err := log.Get(13)
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), entry.Term)
+
+	// The state should survive the truncation of the records it was written after.
+	require.Equal(t, logstore.LogMeta{LastApplied: 2}, log.Meta())
+}
+
+func TestTruncatePrefix(t *testing.T) {
+	dir := t.TempDir()
+	log, err := wal.Open(dir, wal.WithSegmentSize(256))
+	require.NoError(t, err, "could not open empty wal")
+
+	require.NoError(t, log.SaveMeta(logstore.LogMeta{LastApplied: 4}))
+	require.NoError(t, log.Append(entries(1, 50, 4)...))
+	segments := countSegments(t, dir)
+
+	// Compacting the log should remove segments that only contain earlier entries
+	require.NoError(t, log.TruncatePrefix(30))
+	require.Less(t, countSegments(t, dir), segments, "expected segments to be removed")
+	require.Equal(t, uint64(30), log.FirstIndex())
+	require.NoError(t, log.Close())
+
+	// Entries before the first index in the remaining segments should not be recovered
+	log, err = wal.Open(dir, wal.WithSegmentSize(256))
+	require.NoError(t, err, "could not reopen wal")
+	require.Equal(t, uint64(30), log.FirstIndex())
+	requireEntries(t, log, 30, 50)
+	require.Equal(t, logstore.LogMeta{LastApplied: 4}, log.Meta())
+
+	// Compacting past the end of the log should leave an empty log at the index
+	require.NoError(t, log.TruncatePrefix(101))
+	require.Equal(t, 1, countSegments(t, dir))
+	require.NoError(t, log.Close())
+
+	log, err = wal.Open(dir, wal.WithSegmentSize(256))
+	require.NoError(t, err, "could not reopen wal")
+	defer log.Close()
+
+	require.Equal(t, uint64(101), log.FirstIndex())
+	require.Equal(t, uint64(100), log.LastIndex())
+	require.NoError(t, log.Append(entries(101, 110, 5)...))
+	require.Equal(t, logstore.LogMeta{LastApplied: 4}, log.Meta())
+}
+
+func TestRotation(t *testing.T) {
+	dir := t.TempDir()
+	log, err := wal.Open(dir, wal.WithSegmentSize(128))
+	require.NoError(t, err, "could not open empty wal")
+
+	require.NoError(t, log.SaveMeta(logstore.LogMeta{LastApplied: 8}))
+	for i := uint64(1); i <= 100; i++ {
+		require.NoError(t, log.Append(entries(i, i, 8)...))
+	}
+	require.NoError(t, log.Close())
+	require.Greater(t, countSegments(t, dir), 10, "expected the log to rotate segments")
+
+	log, err = wal.Open(dir, wal.WithSegmentSize(128))
+	require.NoError(t, err, "could not reopen wal")
+	defer log.Close()
+
+	requireEntries(t, log, 1, 100)
+	require.Equal(t, logstore.LogMeta{LastApplied: 8}, log.Meta())
+}
+
+func TestTornWrite(t *testing.T) {
+	dir := t.TempDir()
+	log, err := wal.Open(dir)
+	require.NoError(t, err, "could not open empty wal")
+	require.NoError(t, log.Append(entries(1, 10, 1)...))
+	require.NoError(t, log.Close())
+
+	// Simulate a crash in the middle of writing a record by truncating the last bytes
+	paths, err := filepath.Glob(filepath.Join(dir, "*.wal"))
+	require.NoError(t, err)
+	require.Len(t, paths, 1)
+
+	info, err := os.Stat(paths[0])
+	require.NoError(t, err)
+	require.NoError(t, os.Truncate(paths[0], info.Size()-3))
+
+	log, err = wal.Open(dir)
+	require.NoError(t, err, "could not recover torn write")
+	require.Equal(t, uint64(9), log.LastIndex())
+	requireEntries(t, log, 1, 9)
+
+	// The log should be appendable after the torn write is removed
+	require.NoError(t, log.Append(entries(10, 15, 1)...))
+	require.NoError(t, log.Close())
+
+	// Append garbage to the end of the segment to simulate a partially written header
+	f, err := os.OpenFile(paths[0], os.O_WRONLY|os.O_APPEND, 0644)
+	require.NoError(t, err)
+	_, err = f.Write([]byte{0x2a, 0x00, 0x00, 0x00, 0xde, 0xad, 0xbe, 0xef, 0x01})
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	log, err = wal.Open(dir)
+	require.NoError(t, err, "could not recover torn write")
+	defer log.Close()
+	requireEntries(t, log, 1, 15)
+}
+
+func TestCorruptSegment(t *testing.T) {
+	dir := t.TempDir()
+	log, err := wal.Open(dir, wal.WithSegmentSize(128))
+	require.NoError(t, err, "could not open empty wal")
+	require.NoError(t, log.Append(entries(1, 20, 1)...))
+	require.NoError(t, log.Close())
+
+	paths, err := filepath.Glob(filepath.Join(dir, "*.wal"))
+	require.NoError(t, err)
+	require.Greater(t, len(paths), 1)
+
+	// Corrupting a sealed segment cannot be recovered from
+	data, err := os.ReadFile(paths[0])
+	require.NoError(t, err)
+	data[len(data)-1] ^= 0xff
+	require.NoError(t, os.WriteFile(paths[0], data, 0644))
+
+	_, err = wal.Open(dir, wal.WithSegmentSize(128))
+	require.ErrorIs(t, err, wal.ErrCorrupt)
+}
+
+// Create entries from start to end inclusive in the specified term.
+func entries(start, end, term uint64) []*raft.LogEntry {
+	out := make([]*raft.LogEntry, 0, end-start+1)
+	for i := start; i <= end; i++ {
+		out = append(out, &raft.LogEntry{Index: i, Term: term, Name: "put", Value: []byte("otters are cute")})
+	}
+	return out
+}
+
+func requireEntries(t *testing.T, log *wal.WAL, start, end uint64) {
+	all, err := log.Entries(start, end+1)
+	require.NoError(t, err)
+	require.Len(t, all, int(end-start+1))
+	for i, entry := range all {
+		require.Equal(t, start+uint64(i), entry.Index)
+	}
+}
+
+func countSegments(t *testing.T, dir string) int {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.wal"))
+	require.NoError(t, err)
+	return len(paths)
+}
@@ -19,7 +19,13 @@ import (
 const _ = grpc.SupportPackageIsVersion8
 
 const (
-	Otter_Status_FullMethodName = "/otter.v1.Otter/Status"
+	Otter_Status_FullMethodName      = "/otter.v1.Otter/Status"
+	Otter_Exec_FullMethodName        = "/otter.v1.Otter/Exec"
+	Otter_Transaction_FullMethodName = "/otter.v1.Otter/Transaction"
+	Otter_Query_FullMethodName       = "/otter.v1.Otter/Query"
+	Otter_AddPeer_FullMethodName     = "/otter.v1.Otter/AddPeer"
+	Otter_RemovePeer_FullMethodName  = "/otter.v1.Otter/RemovePeer"
+	Otter_PromotePeer_FullMethodName = "/otter.v1.Otter/PromotePeer"
 )
 
 // OtterClient is the client API for Otter service.
@@ -28,6 +34,34 @@ const (
 type OtterClient interface {
 	// Implements a client-side heartbeat that can also be used by monitoring tools.
 	Status(ctx context.Context, in *HealthCheck, opts ...grpc.CallOption) (*ServiceState, error)
+	// Exec executes a statement that modifies the database (e.g. INSERT, UPDATE, DELETE,
+	// or DDL). The statement is replicated to the quorum and the RPC returns once it has
+	// been committed and applied to the database of the leader. If the replica is not
+	// the leader, the statement is forwarded to the leader unless the client requests a
+	// redirect, in which case a FAILED_PRECONDITION status with Redirect details is
+	// returned.
+	Exec(ctx context.Context, in *ExecRequest, opts ...grpc.CallOption) (*ExecResult, error)
+	// Transaction executes an ordered list of statements atomically. The statements are
+	// replicated as a single log entry and applied in a single transaction; if any
+	// statement fails, none of the statements are applied. Transactions are forwarded or
+	// redirected to the leader in the same way as Exec.
+	Transaction(ctx context.Context, in *TransactionRequest, opts ...grpc.CallOption) (*TransactionResult, error)
+	// Query executes a read-only statement against the local database of the replica
+	// once the requested read consistency has been satisfied.
+	Query(ctx context.Context, in *QueryRequest, opts ...grpc.CallOption) (*QueryResult, error)
+	// AddPeer adds a replica to the quorum with joint consensus and returns once the
+	// change has been committed, e.g. to replace a replica that has failed. Membership
+	// changes are only made by the leader; if the replica is not the leader a
+	// FAILED_PRECONDITION status with Redirect details is returned. If roles are
+	// configured, membership changes require the admin permission.
+	AddPeer(ctx context.Context, in *Peer, opts ...grpc.CallOption) (*MembershipResult, error)
+	// RemovePeer removes a replica from the quorum and returns once the change has been
+	// committed. Membership changes are authorized and redirected as for AddPeer.
+	RemovePeer(ctx context.Context, in *PeerRequest, opts ...grpc.CallOption) (*MembershipResult, error)
+	// PromotePeer changes a learner into a voting member of the quorum once it has
+	// caught up with the leader. Membership changes are authorized and redirected as for
+	// AddPeer.
+	PromotePeer(ctx context.Context, in *PeerRequest, opts ...grpc.CallOption) (*MembershipResult, error)
 }
 
 type otterClient struct {
@@ -48,12 +82,100 @@ func (c *otterClient) Status(ctx context.Context, in *HealthCheck, opts ...grpc.
 	return out, nil
 }
 
+func (c *otterClient) Exec(ctx context.Context, in *ExecRequest, opts ...grpc.CallOption) (*ExecResult, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ExecResult)
+	err := c.cc.Invoke(ctx, Otter_Exec_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *otterClient) Transaction(ctx context.Context, in *TransactionRequest, opts ...grpc.CallOption) (*TransactionResult, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(TransactionResult)
+	err := c.cc.Invoke(ctx, Otter_Transaction_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *otterClient) Query(ctx context.Context, in *QueryRequest, opts ...grpc.CallOption) (*QueryResult, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(QueryResult)
+	err := c.cc.Invoke(ctx, Otter_Query_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *otterClient) AddPeer(ctx context.Context, in *Peer, opts ...grpc.CallOption) (*MembershipResult, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(MembershipResult)
+	err := c.cc.Invoke(ctx, Otter_AddPeer_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *otterClient) RemovePeer(ctx context.Context, in *PeerRequest, opts ...grpc.CallOption) (*MembershipResult, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(MembershipResult)
+	err := c.cc.Invoke(ctx, Otter_RemovePeer_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *otterClient) PromotePeer(ctx context.Context, in *PeerRequest, opts ...grpc.CallOption) (*MembershipResult, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(MembershipResult)
+	err := c.cc.Invoke(ctx, Otter_PromotePeer_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // OtterServer is the server API for Otter service.
 // All implementations must embed UnimplementedOtterServer
 // for forward compatibility
 type OtterServer interface {
 	// Implements a client-side heartbeat that can also be used by monitoring tools.
 	Status(context.Context, *HealthCheck) (*ServiceState, error)
+	// Exec executes a statement that modifies the database (e.g. INSERT, UPDATE, DELETE,
+	// or DDL). The statement is replicated to the quorum and the RPC returns once it has
+	// been committed and applied to the database of the leader. If the replica is not
+	// the leader, the statement is forwarded to the leader unless the client requests a
+	// redirect, in which case a FAILED_PRECONDITION status with Redirect details is
+	// returned.
+	Exec(context.Context, *ExecRequest) (*ExecResult, error)
+	// Transaction executes an ordered list of statements atomically. The statements are
+	// replicated as a single log entry and applied in a single transaction; if any
+	// statement fails, none of the statements are applied. Transactions are forwarded or
+	// redirected to the leader in the same way as Exec.
+	Transaction(context.Context, *TransactionRequest) (*TransactionResult, error)
+	// Query executes a read-only statement against the local database of the replica
+	// once the requested read consistency has been satisfied.
+	Query(context.Context, *QueryRequest) (*QueryResult, error)
+	// AddPeer adds a replica to the quorum with joint consensus and returns once the
+	// change has been committed, e.g. to replace a replica that has failed. Membership
+	// changes are only made by the leader; if the replica is not the leader a
+	// FAILED_PRECONDITION status with Redirect details is returned. If roles are
+	// configured, membership changes require the admin permission.
+	AddPeer(context.Context, *Peer) (*MembershipResult, error)
+	// RemovePeer removes a replica from the quorum and returns once the change has been
+	// committed. Membership changes are authorized and redirected as for AddPeer.
+	RemovePeer(context.Context, *PeerRequest) (*MembershipResult, error)
+	// PromotePeer changes a learner into a voting member of the quorum once it has
+	// caught up with the leader. Membership changes are authorized and redirected as for
+	// AddPeer.
+	PromotePeer(context.Context, *PeerRequest) (*MembershipResult, error)
 	mustEmbedUnimplementedOtterServer()
 }
 
@@ -64,6 +186,24 @@ type UnimplementedOtterServer struct {
 func (UnimplementedOtterServer) Status(context.Context, *HealthCheck) (*ServiceState, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Status not implemented")
 }
+func (UnimplementedOtterServer) Exec(context.Context, *ExecRequest) (*ExecResult, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Exec not implemented")
+}
+func (UnimplementedOtterServer) Transaction(context.Context, *TransactionRequest) (*TransactionResult, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Transaction not implemented")
+}
+func (UnimplementedOtterServer) Query(context.Context, *QueryRequest) (*QueryResult, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Query not implemented")
+}
+func (UnimplementedOtterServer) AddPeer(context.Context, *Peer) (*MembershipResult, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AddPeer not implemented")
+}
+func (UnimplementedOtterServer) RemovePeer(context.Context, *PeerRequest) (*MembershipResult, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RemovePeer not implemented")
+}
+func (UnimplementedOtterServer) PromotePeer(context.Context, *PeerRequest) (*MembershipResult, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PromotePeer not implemented")
+}
 func (UnimplementedOtterServer) mustEmbedUnimplementedOtterServer() {}
 
 // UnsafeOtterServer may be embedded to opt out of forward compatibility for this service.
@@ -95,6 +235,114 @@ func _Otter_Status_Handler(srv interface{}, ctx context.Context, dec func(interf
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Otter_Exec_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ExecRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OtterServer).Exec(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Otter_Exec_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OtterServer).Exec(ctx, req.(*ExecRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Otter_Transaction_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TransactionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OtterServer).Transaction(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Otter_Transaction_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OtterServer).Transaction(ctx, req.(*TransactionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Otter_Query_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(QueryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OtterServer).Query(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Otter_Query_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OtterServer).Query(ctx, req.(*QueryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Otter_AddPeer_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Peer)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OtterServer).AddPeer(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Otter_AddPeer_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OtterServer).AddPeer(ctx, req.(*Peer))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Otter_RemovePeer_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PeerRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OtterServer).RemovePeer(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Otter_RemovePeer_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OtterServer).RemovePeer(ctx, req.(*PeerRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Otter_PromotePeer_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PeerRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OtterServer).PromotePeer(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Otter_PromotePeer_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OtterServer).PromotePeer(ctx, req.(*PeerRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // Otter_ServiceDesc is the grpc.ServiceDesc for Otter service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -106,6 +354,30 @@ var Otter_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "Status",
 			Handler:    _Otter_Status_Handler,
 		},
+		{
+			MethodName: "Exec",
+			Handler:    _Otter_Exec_Handler,
+		},
+		{
+			MethodName: "Transaction",
+			Handler:    _Otter_Transaction_Handler,
+		},
+		{
+			MethodName: "Query",
+			Handler:    _Otter_Query_Handler,
+		},
+		{
+			MethodName: "AddPeer",
+			Handler:    _Otter_AddPeer_Handler,
+		},
+		{
+			MethodName: "RemovePeer",
+			Handler:    _Otter_RemovePeer_Handler,
+		},
+		{
+			MethodName: "PromotePeer",
+			Handler:    _Otter_PromotePeer_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "otter/v1/otter.proto",
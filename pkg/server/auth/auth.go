@@ -0,0 +1,148 @@
+/*
+Package auth authenticates clients of the database server. Clients present either a
+static api key in the x-api-key metadata of the request or a JWT bearer token in the
+authorization metadata that is signed by a key in a local JSON web key set. Clients of
+the web ui present the same credentials in the headers of their requests.
+*/
+package auth
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/bbengfort/otterdb/pkg/config"
+
+	"google.golang.org/grpc/metadata"
+)
+
+const (
+	// The metadata keys that carry the credentials of the client.
+	APIKeyHeader        = "x-api-key"
+	AuthorizationHeader = "authorization"
+
+	bearer = "bearer "
+)
+
+// Methods that a principal can authenticate with.
+const (
+	MethodAPIKey = "apikey"
+	MethodJWT    = "jwt"
+)
+
+// Principal is an authenticated client of the database server.
+type Principal struct {
+	Name   string   // The name of the api key or the subject of the bearer token
+	Method string   // The method the client authenticated with
+	Roles  []string // The roles assigned to the api key or in the claims of the token
+	Claims *Claims  // The claims of the bearer token if the client presented one
+}
+
+// Authenticator verifies the credentials of clients against the configured api keys and
+// the JSON web key set.
+type Authenticator struct {
+	keys     *Keys
+	jwks     *KeySet
+	issuer   string
+	audience string
+}
+
+// New loads the api keys and the JSON web key set specified by the configuration. If
+// authentication is not enabled, a nil authenticator is returned.
+func New(conf config.AuthConfig) (a *Authenticator, err error) {
+	if !conf.Enabled() {
+		return nil, nil
+	}
+
+	a = &Authenticator{issuer: conf.Issuer, audience: conf.Audience}
+	if conf.KeysPath != "" {
+		if a.keys, err = LoadKeys(conf.KeysPath); err != nil {
+			return nil, err
+		}
+	}
+
+	if conf.JWKSPath != "" {
+		if a.jwks, err = LoadKeySet(conf.JWKSPath); err != nil {
+			return nil, err
+		}
+	}
+	return a, nil
+}
+
+// Authenticate the client using the credentials in the incoming metadata of the request.
+func (a *Authenticator) Authenticate(ctx context.Context) (*Principal, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	return a.authenticate(md.Get(APIKeyHeader), md.Get(AuthorizationHeader))
+}
+
+// AuthenticateRequest authenticates the client of an HTTP request, e.g. to the web ui,
+// using the credentials in the headers of the request.
+func (a *Authenticator) AuthenticateRequest(r *http.Request) (*Principal, error) {
+	return a.authenticate(r.Header.Values(APIKeyHeader), r.Header.Values(AuthorizationHeader))
+}
+
+func (a *Authenticator) authenticate(apikeys, authorization []string) (*Principal, error) {
+	token := bearerToken(authorization)
+	switch {
+	case len(apikeys) > 0 && token != "":
+		return nil, ErrMultipleAuthTypes
+	case len(apikeys) > 0:
+		return a.authenticateKey(apikeys[0])
+	case token != "":
+		return a.authenticateToken(token, time.Now())
+	default:
+		return nil, ErrNoCredentials
+	}
+}
+
+func (a *Authenticator) authenticateKey(key string) (*Principal, error) {
+	if a.keys == nil {
+		return nil, ErrInvalidAPIKey
+	}
+
+	principal, ok := a.keys.Verify(key)
+	if !ok {
+		return nil, ErrInvalidAPIKey
+	}
+	return principal, nil
+}
+
+func (a *Authenticator) authenticateToken(token string, now time.Time) (_ *Principal, err error) {
+	if a.jwks == nil {
+		return nil, ErrNoKeySet
+	}
+
+	var claims *Claims
+	if claims, err = a.jwks.Verify(token); err != nil {
+		return nil, err
+	}
+
+	if err = claims.Validate(now, a.issuer, a.audience); err != nil {
+		return nil, err
+	}
+	return &Principal{Name: claims.Subject, Method: MethodJWT, Roles: claims.Roles, Claims: claims}, nil
+}
+
+// Returns the token of the first bearer authorization header, if any.
+func bearerToken(headers []string) string {
+	for _, header := range headers {
+		if len(header) > len(bearer) && strings.EqualFold(header[:len(bearer)], bearer) {
+			return strings.TrimSpace(header[len(bearer):])
+		}
+	}
+	return ""
+}
+
+type principalKey struct{}
+
+// NewContext returns a copy of the context that carries the principal.
+func NewContext(ctx context.Context, principal *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// FromContext returns the authenticated principal of the request, if any.
+func FromContext(ctx context.Context) (*Principal, bool) {
+	principal, ok := ctx.Value(principalKey{}).(*Principal)
+	return principal, ok
+}
@@ -0,0 +1,429 @@
+package auth_test
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bbengfort/otterdb/pkg/config"
+	"github.com/bbengfort/otterdb/pkg/fsm"
+	"github.com/bbengfort/otterdb/pkg/replica/raft/v1"
+	"github.com/bbengfort/otterdb/pkg/server/api/v1"
+	"github.com/bbengfort/otterdb/pkg/server/auth"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestAPIKeys(t *testing.T) {
+	keys, err := auth.NewKeys(auth.APIKey{Name: "analytics", Key: "s3cr3t", Roles: []string{"reader"}}, auth.APIKey{Name: "ingest", Key: "hunter2"})
+	require.NoError(t, err)
+
+	principal, ok := keys.Verify("hunter2")
+	require.True(t, ok)
+	require.Equal(t, &auth.Principal{Name: "ingest", Method: auth.MethodAPIKey}, principal)
+
+	principal, ok = keys.Verify("s3cr3t")
+	require.True(t, ok)
+	require.Equal(t, []string{"reader"}, principal.Roles)
+
+	_, ok = keys.Verify("hunter3")
+	require.False(t, ok)
+
+	_, ok = keys.Verify("")
+	require.False(t, ok)
+
+	_, err = auth.NewKeys()
+	require.ErrorIs(t, err, auth.ErrNoKeys)
+
+	_, err = auth.NewKeys(auth.APIKey{Name: "analytics"})
+	require.ErrorIs(t, err, auth.ErrMissingKeyOrName)
+
+	_, err = auth.NewKeys(auth.APIKey{Name: "analytics", Key: "a"}, auth.APIKey{Name: "analytics", Key: "b"})
+	require.ErrorIs(t, err, auth.ErrDuplicateKeyName)
+}
+
+func TestKeySet(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	edPub, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	jwks, err := auth.ParseKeySet(keySet(
+		map[string]interface{}{"kty": "RSA", "kid": "rsa", "alg": "RS256", "use": "sig", "n": b64(rsaKey.N.Bytes()), "e": b64(big.NewInt(int64(rsaKey.E)).Bytes())},
+		map[string]interface{}{"kty": "EC", "kid": "ec", "crv": "P-256", "x": b64(ecKey.X.FillBytes(make([]byte, 32))), "y": b64(ecKey.Y.FillBytes(make([]byte, 32)))},
+		map[string]interface{}{"kty": "OKP", "kid": "ed", "crv": "Ed25519", "x": b64(edPub)},
+		map[string]interface{}{"kty": "RSA", "kid": "enc", "use": "enc", "n": "invalid", "e": "AQAB"},
+	))
+	require.NoError(t, err, "could not parse key set")
+
+	claims := map[string]interface{}{"sub": "jade", "roles": []string{"reader"}, "exp": time.Now().Add(time.Hour).Unix()}
+
+	t.Run("Valid", func(t *testing.T) {
+		for _, token := range []string{
+			sign(t, "RS256", "rsa", rsaKey, claims),
+			sign(t, "ES256", "ec", ecKey, claims),
+			sign(t, "EdDSA", "ed", edKey, claims),
+		} {
+			out, err := jwks.Verify(token)
+			require.NoError(t, err)
+			require.Equal(t, "jade", out.Subject)
+			require.Equal(t, []string{"reader"}, out.Roles)
+		}
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		testCases := []struct {
+			token string
+			err   error
+		}{
+			{"", auth.ErrMalformedToken},
+			{"a.b", auth.ErrMalformedToken},
+			{"!!.e30.", auth.ErrMalformedToken},
+			{sign(t, "ES256", "ec", otherKey, claims), auth.ErrInvalidSignature},
+			{sign(t, "ES256", "unknown", ecKey, claims), auth.ErrUnknownKey},
+			{sign(t, "ES256", "", ecKey, claims), auth.ErrUnknownKey},
+			{sign(t, "ES256", "enc", ecKey, claims), auth.ErrUnknownKey},
+			{sign(t, "ES256", "ed", ecKey, claims), auth.ErrUnsupportedAlg},
+			{sign(t, "RS512", "rsa", rsaKey, claims), auth.ErrUnsupportedAlg},
+			{sign(t, "none", "ec", nil, claims), auth.ErrMalformedToken},
+
+			// Algorithm substitution: tokens must be signed with an algorithm of the key.
+			{sign(t, "HS256", "rsa", hmacKey(rsaKey), claims), auth.ErrMalformedToken},
+			{sign(t, "HS256", "ec", hmacKey(ecKey), claims), auth.ErrMalformedToken},
+			{sign(t, "ES384", "ec", ecKey, claims), auth.ErrUnsupportedAlg},
+			{sign(t, "PS256", "rsa", rsaKey, claims), auth.ErrUnsupportedAlg},
+			{sign(t, "RS256", "ec", rsaKey, claims), auth.ErrUnsupportedAlg},
+			{sign(t, "EdDSA", "ec", edKey, claims), auth.ErrUnsupportedAlg},
+		}
+
+		for i, tc := range testCases {
+			_, err := jwks.Verify(tc.token)
+			require.ErrorIs(t, err, tc.err, "test case %d", i)
+		}
+	})
+
+	t.Run("Parse", func(t *testing.T) {
+		_, err := auth.ParseKeySet(keySet())
+		require.ErrorIs(t, err, auth.ErrInvalidKey)
+
+		_, err = auth.ParseKeySet(keySet(map[string]interface{}{"kty": "oct", "k": "c2VjcmV0"}))
+		require.ErrorIs(t, err, auth.ErrUnsupportedKey)
+
+		_, err = auth.ParseKeySet(keySet(map[string]interface{}{"kty": "EC", "crv": "P-256", "x": b64([]byte{1}), "y": b64([]byte{2})}))
+		require.ErrorIs(t, err, auth.ErrInvalidKey, "point is not on the curve")
+
+		_, err = auth.ParseKeySet(keySet(map[string]interface{}{"kty": "OKP", "crv": "Ed25519", "x": b64([]byte{1, 2, 3})}))
+		require.ErrorIs(t, err, auth.ErrInvalidKey, "ed25519 key is too short")
+
+		// RSA keys with moduli smaller than 2048 bits are rejected.
+		_, err = auth.ParseKeySet(keySet(map[string]interface{}{"kty": "RSA", "n": b64(bytes.Repeat([]byte{0xc5}, 64)), "e": "AQAB"}))
+		require.ErrorIs(t, err, auth.ErrWeakKey, "512 bit rsa key")
+
+		weakKey, err := rsa.GenerateKey(rand.Reader, 1024)
+		require.NoError(t, err)
+		_, err = auth.ParseKeySet(keySet(map[string]interface{}{"kty": "RSA", "n": b64(weakKey.N.Bytes()), "e": "AQAB"}))
+		require.ErrorIs(t, err, auth.ErrWeakKey, "1024 bit rsa key")
+
+		// Keys cannot be restricted to symmetric algorithms.
+		_, err = auth.ParseKeySet(keySet(map[string]interface{}{"kty": "EC", "alg": "HS256", "crv": "P-256", "x": b64(ecKey.X.FillBytes(make([]byte, 32))), "y": b64(ecKey.Y.FillBytes(make([]byte, 32)))}))
+		require.ErrorIs(t, err, auth.ErrUnsupportedAlg)
+	})
+}
+
+func TestClaims(t *testing.T) {
+	now := time.Now()
+
+	parse := func(claims map[string]interface{}) *auth.Claims {
+		data, err := json.Marshal(claims)
+		require.NoError(t, err)
+
+		out := &auth.Claims{}
+		require.NoError(t, json.Unmarshal(data, out))
+		return out
+	}
+
+	claims := parse(map[string]interface{}{"iss": "https://auth.example.com", "aud": []string{"otterdb", "web"}, "exp": now.Add(time.Minute).Unix(), "nbf": now.Unix()})
+	require.NoError(t, claims.Validate(now, "https://auth.example.com", "otterdb"))
+	require.NoError(t, claims.Validate(now, "", ""))
+	require.ErrorIs(t, claims.Validate(now, "https://other.example.com", ""), auth.ErrInvalidIssuer)
+	require.ErrorIs(t, claims.Validate(now, "", "api"), auth.ErrInvalidAudience)
+	require.ErrorIs(t, claims.Validate(now.Add(2*time.Minute), "", ""), auth.ErrTokenExpired)
+	require.ErrorIs(t, claims.Validate(now.Add(-time.Minute), "", ""), auth.ErrTokenNotValidYet)
+
+	// Clock skew is allowed when checking the expiration.
+	require.NoError(t, claims.Validate(now.Add(75*time.Second), "", ""))
+
+	claims = parse(map[string]interface{}{"aud": "otterdb", "exp": float64(now.Add(time.Minute).Unix()) + 0.5})
+	require.NoError(t, claims.Validate(now, "", "otterdb"))
+
+	claims = parse(map[string]interface{}{"sub": "jade"})
+	require.ErrorIs(t, claims.Validate(now, "", ""), auth.ErrNoExpiration)
+}
+
+func TestAuthenticator(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	writeJSON(t, filepath.Join(dir, "keys.json"), []auth.APIKey{{Name: "analytics", Key: "s3cr3t"}})
+	writeJSON(t, filepath.Join(dir, "jwks.json"), json.RawMessage(keySet(
+		map[string]interface{}{"kty": "EC", "kid": "ec", "alg": "ES256", "crv": "P-256", "x": b64(key.X.FillBytes(make([]byte, 32))), "y": b64(key.Y.FillBytes(make([]byte, 32)))},
+	)))
+
+	authn, err := auth.New(config.AuthConfig{})
+	require.NoError(t, err)
+	require.Nil(t, authn, "authentication should not be enabled")
+
+	authn, err = auth.New(config.AuthConfig{KeysPath: filepath.Join(dir, "keys.json"), JWKSPath: filepath.Join(dir, "jwks.json"), Audience: "otterdb"})
+	require.NoError(t, err)
+
+	incoming := func(kv ...string) context.Context {
+		return metadata.NewIncomingContext(context.Background(), metadata.Pairs(kv...))
+	}
+
+	principal, err := authn.Authenticate(incoming(auth.APIKeyHeader, "s3cr3t"))
+	require.NoError(t, err)
+	require.Equal(t, &auth.Principal{Name: "analytics", Method: auth.MethodAPIKey}, principal)
+
+	token := sign(t, "ES256", "ec", key, map[string]interface{}{"sub": "jade", "aud": "otterdb", "roles": []string{"admin"}, "exp": time.Now().Add(time.Hour).Unix()})
+	principal, err = authn.Authenticate(incoming(auth.AuthorizationHeader, "Bearer "+token))
+	require.NoError(t, err)
+	require.Equal(t, "jade", principal.Name)
+	require.Equal(t, auth.MethodJWT, principal.Method)
+	require.Equal(t, []string{"admin"}, principal.Roles)
+	require.NotNil(t, principal.Claims)
+
+	ctx := auth.NewContext(context.Background(), principal)
+	actual, ok := auth.FromContext(ctx)
+	require.True(t, ok)
+	require.Same(t, principal, actual)
+
+	_, ok = auth.FromContext(context.Background())
+	require.False(t, ok)
+
+	_, err = authn.Authenticate(context.Background())
+	require.ErrorIs(t, err, auth.ErrNoCredentials)
+
+	_, err = authn.Authenticate(incoming(auth.AuthorizationHeader, "Basic czNjcjN0"))
+	require.ErrorIs(t, err, auth.ErrNoCredentials)
+
+	_, err = authn.Authenticate(incoming(auth.APIKeyHeader, "wrong"))
+	require.ErrorIs(t, err, auth.ErrInvalidAPIKey)
+
+	_, err = authn.Authenticate(incoming(auth.APIKeyHeader, "s3cr3t", auth.AuthorizationHeader, "Bearer "+token))
+	require.ErrorIs(t, err, auth.ErrMultipleAuthTypes)
+
+	// Clients of the web ui present the same credentials in the request headers.
+	req := httptest.NewRequest(http.MethodPost, "/v1/leader/transfer", nil)
+	req.Header.Set("X-API-Key", "s3cr3t")
+	principal, err = authn.AuthenticateRequest(req)
+	require.NoError(t, err)
+	require.Equal(t, "analytics", principal.Name)
+
+	req.Header.Set("Authorization", "Bearer "+token)
+	_, err = authn.AuthenticateRequest(req)
+	require.ErrorIs(t, err, auth.ErrMultipleAuthTypes)
+
+	_, err = authn.AuthenticateRequest(httptest.NewRequest(http.MethodPost, "/v1/leader/transfer", nil))
+	require.ErrorIs(t, err, auth.ErrNoCredentials)
+
+	token = sign(t, "ES256", "ec", key, map[string]interface{}{"sub": "jade", "aud": "web", "exp": time.Now().Add(time.Hour).Unix()})
+	_, err = authn.Authenticate(incoming(auth.AuthorizationHeader, "bearer "+token))
+	require.ErrorIs(t, err, auth.ErrInvalidAudience)
+
+	// Bearer tokens are not accepted if no key set is configured.
+	authn, err = auth.New(config.AuthConfig{KeysPath: filepath.Join(dir, "keys.json")})
+	require.NoError(t, err)
+
+	_, err = authn.Authenticate(incoming(auth.AuthorizationHeader, "Bearer "+token))
+	require.ErrorIs(t, err, auth.ErrNoKeySet)
+}
+
+func TestRoles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "roles.json")
+	writeJSON(t, path, map[string]auth.Role{
+		"analytics": {Permissions: []string{"read"}, Tables: []string{"Otters", "accounts"}},
+		"service":   {Permissions: []string{"read", "write"}, Tables: []string{"otters"}},
+		"audit":     {Permissions: []string{"write"}, Tables: []string{"log"}},
+		"admin":     {Permissions: []string{"read", "write", "ddl", "pragma"}, Tables: []string{auth.Wildcard}},
+	})
+
+	roles, err := auth.LoadRoles(path)
+	require.NoError(t, err, "could not load roles")
+
+	// Statements are classified by preparing them against the schema of the database.
+	db, err := fsm.Open(filepath.Join(t.TempDir(), "otter.db"))
+	require.NoError(t, err, "could not open database")
+	defer db.Close()
+
+	for i, table := range []string{"otters", "accounts", "beavers", "log"} {
+		value, err := proto.Marshal(&api.Statement{Sql: fmt.Sprintf("CREATE TABLE %s (id INTEGER PRIMARY KEY)", table)})
+		require.NoError(t, err)
+		_, err = db.Apply(&raft.LogEntry{Index: uint64(i + 1), Term: 1, Name: fsm.Exec, Value: value})
+		require.NoError(t, err, "could not create table")
+	}
+
+	authorize := func(query string, names ...string) error {
+		stmt, err := db.Classify(context.Background(), query)
+		require.NoError(t, err, "could not classify %q", query)
+		return roles.Authorize(&auth.Principal{Name: "jade", Roles: names}, stmt)
+	}
+
+	testCases := []struct {
+		query   string
+		roles   []string
+		allowed bool
+	}{
+		{"SELECT * FROM otters JOIN accounts USING (id)", []string{"analytics"}, true},
+		{"SELECT 1", []string{"analytics"}, true},
+		{"SELECT * FROM beavers", []string{"analytics"}, false},
+		{"INSERT INTO otters VALUES (1)", []string{"analytics"}, false},
+		{"INSERT INTO otters VALUES (1)", []string{"service"}, true},
+		{"INSERT INTO otters SELECT * FROM accounts", []string{"service"}, false},
+		{"INSERT INTO otters SELECT * FROM accounts", []string{"service", "analytics"}, true},
+		{"INSERT INTO log SELECT * FROM otters", []string{"audit", "analytics"}, true},
+		{"INSERT INTO log SELECT * FROM otters", []string{"audit"}, false},
+		{"DROP TABLE otters", []string{"service"}, false},
+		{"CREATE TABLE minks (id INTEGER)", []string{"service"}, false},
+		{"CREATE TABLE minks (id INTEGER)", []string{"admin"}, true},
+		{"PRAGMA table_info(otters)", []string{"analytics"}, false},
+		{"PRAGMA table_info(otters)", []string{"admin"}, true},
+		{"SELECT * FROM (beavers)", []string{"analytics"}, false},
+		{"SELECT * FROM otters, (beavers)", []string{"analytics"}, false},
+		{"SELECT * FROM otters NATURAL JOIN (beavers)", []string{"analytics"}, false},
+		{"INSERT INTO otters SELECT * FROM (beavers)", []string{"service", "analytics"}, false},
+		{"SELECT * FROM ((otters) JOIN (accounts))", []string{"analytics"}, true},
+		{"SELECT * FROM pragma_table_info('beavers')", []string{"analytics"}, false},
+		{"SELECT * FROM pragma_table_info('beavers')", []string{"admin"}, true},
+		{"INSERT INTO otters SELECT * FROM (SELECT * FROM (beavers))", []string{"service", "analytics"}, false},
+		{"SELECT * FROM sqlite_schema", []string{"admin"}, true},
+		{"SELECT * FROM otters", []string{"unknown"}, false},
+		{"SELECT * FROM otters", nil, false},
+	}
+
+	for _, tc := range testCases {
+		err := authorize(tc.query, tc.roles...)
+		if tc.allowed {
+			require.NoError(t, err, "expected %v to be allowed to %q", tc.roles, tc.query)
+		} else {
+			require.ErrorIs(t, err, auth.ErrPermissionDenied, "expected %v to be denied %q", tc.roles, tc.query)
+		}
+	}
+
+	// Unscoped statements require a role that allows all tables.
+	scoped, err := auth.NewRoles(map[string]auth.Role{"ddl": {Permissions: []string{"ddl"}, Tables: []string{"otters"}}})
+	require.NoError(t, err)
+
+	err = scoped.Authorize(&auth.Principal{Roles: []string{"ddl"}}, &fsm.Classification{Operation: fsm.OpDDL, Unscoped: true})
+	require.ErrorIs(t, err, auth.ErrPermissionDenied)
+
+	// Only roles with the admin permission can manage the cluster, which does not permit
+	// any statements.
+	admins, err := auth.NewRoles(map[string]auth.Role{
+		"operator": {Permissions: []string{"admin"}},
+		"ddl":      {Permissions: []string{"read", "write", "ddl", "pragma"}, Tables: []string{auth.Wildcard}},
+	})
+	require.NoError(t, err)
+	require.NoError(t, admins.AuthorizeAdmin(&auth.Principal{Name: "jade", Roles: []string{"ddl", "operator"}}))
+	require.ErrorIs(t, admins.AuthorizeAdmin(&auth.Principal{Name: "kira", Roles: []string{"ddl"}}), auth.ErrPermissionDenied)
+	require.ErrorIs(t, admins.AuthorizeAdmin(&auth.Principal{Name: "opal", Roles: []string{"admin"}}), auth.ErrPermissionDenied)
+
+	err = admins.Authorize(&auth.Principal{Name: "jade", Roles: []string{"operator"}}, &fsm.Classification{Operation: fsm.OpRead, Reads: []string{"otters"}})
+	require.ErrorIs(t, err, auth.ErrPermissionDenied)
+
+	_, err = auth.NewRoles(nil)
+	require.ErrorIs(t, err, auth.ErrNoRoles)
+
+	_, err = auth.NewRoles(map[string]auth.Role{"admin": {Permissions: []string{"superuser"}}})
+	require.ErrorIs(t, err, fsm.ErrUnknownOperation)
+}
+
+func keySet(keys ...map[string]interface{}) []byte {
+	if keys == nil {
+		keys = []map[string]interface{}{}
+	}
+
+	data, _ := json.Marshal(map[string]interface{}{"keys": keys})
+	return data
+}
+
+func writeJSON(t *testing.T, path string, v interface{}) {
+	data, err := json.Marshal(v)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path, data, 0600))
+}
+
+func b64(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// Returns the encoded public key of the private key, which an attacker would use as the
+// secret of an HMAC to substitute a symmetric algorithm for the algorithm of the key.
+func hmacKey(key crypto.Signer) []byte {
+	pub, _ := x509.MarshalPKIXPublicKey(key.Public())
+	return pub
+}
+
+// Sign the claims with the key to create a compact serialized JWT.
+func sign(t *testing.T, alg, kid string, key crypto.PrivateKey, claims map[string]interface{}) string {
+	header := map[string]interface{}{"alg": alg, "typ": "JWT"}
+	if kid != "" {
+		header["kid"] = kid
+	}
+
+	hdr, err := json.Marshal(header)
+	require.NoError(t, err)
+
+	payload, err := json.Marshal(claims)
+	require.NoError(t, err)
+
+	input := b64(hdr) + "." + b64(payload)
+	digest := sha256.Sum256([]byte(input))
+
+	var sig []byte
+	switch alg {
+	case "RS256", "RS512":
+		sig, err = rsa.SignPKCS1v15(rand.Reader, key.(*rsa.PrivateKey), crypto.SHA256, digest[:])
+		require.NoError(t, err)
+	case "ES256":
+		r, s, err := ecdsa.Sign(rand.Reader, key.(*ecdsa.PrivateKey), digest[:])
+		require.NoError(t, err)
+		sig = append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+	case "EdDSA":
+		sig = ed25519.Sign(key.(ed25519.PrivateKey), []byte(input))
+	case "HS256":
+		mac := hmac.New(sha256.New, key.([]byte))
+		mac.Write([]byte(input))
+		sig = mac.Sum(nil)
+	}
+
+	return strings.Join([]string{b64(hdr), b64(payload), b64(sig)}, ".")
+}
@@ -0,0 +1,27 @@
+package auth
+
+import "errors"
+
+var (
+	ErrNoCredentials     = errors.New("no api key or bearer token was provided")
+	ErrInvalidAPIKey     = errors.New("api key is not valid")
+	ErrNoKeys            = errors.New("no api keys found in the keys file")
+	ErrNoKeySet          = errors.New("bearer tokens are not accepted by this server")
+	ErrMalformedToken    = errors.New("bearer token is not a valid jwt")
+	ErrUnknownKey        = errors.New("bearer token is not signed by a key in the key set")
+	ErrUnsupportedAlg    = errors.New("bearer token is signed with an unsupported algorithm")
+	ErrInvalidSignature  = errors.New("bearer token signature is not valid")
+	ErrTokenExpired      = errors.New("bearer token has expired")
+	ErrTokenNotValidYet  = errors.New("bearer token is not valid yet")
+	ErrNoExpiration      = errors.New("bearer token does not have an expiration")
+	ErrInvalidIssuer     = errors.New("bearer token was not issued by the expected issuer")
+	ErrInvalidAudience   = errors.New("bearer token is not intended for this audience")
+	ErrUnsupportedKey    = errors.New("json web key type or curve is not supported")
+	ErrInvalidKey        = errors.New("json web key is not valid")
+	ErrWeakKey           = errors.New("json web key is too weak to verify signatures")
+	ErrDuplicateKeyName  = errors.New("api key names must be unique")
+	ErrMissingKeyOrName  = errors.New("api keys must have a name and a key")
+	ErrMultipleAuthTypes = errors.New("provide either an api key or a bearer token, not both")
+	ErrNoRoles           = errors.New("no roles found in the roles file")
+	ErrPermissionDenied  = errors.New("permission denied")
+)
@@ -0,0 +1,262 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/go-jose/go-jose/v4"
+	"github.com/go-jose/go-jose/v4/jwt"
+)
+
+// Tokens are accepted this long before they are valid and after they expire to allow for
+// clock skew between the issuer and the server.
+const clockSkew = 30 * time.Second
+
+// RSA keys with smaller moduli are rejected when the key set is loaded.
+const minRSABits = 2048
+
+// The asymmetric signature algorithms that bearer tokens may be signed with; symmetric
+// algorithms are never accepted since the key set only contains public keys.
+var algorithms = []jose.SignatureAlgorithm{
+	jose.RS256, jose.RS384, jose.RS512,
+	jose.PS256, jose.PS384, jose.PS512,
+	jose.ES256, jose.ES384, jose.ES512,
+	jose.EdDSA,
+}
+
+// KeySet is a JSON web key set (RFC 7517) used to verify the signatures of bearer tokens.
+type KeySet struct {
+	keys []*webKey
+}
+
+// A public key from the key set and the algorithm it is restricted to, if any.
+type webKey struct {
+	id  string
+	alg string
+	key interface{}
+}
+
+// LoadKeySet loads a JSON web key set from disk. Keys that are not used for signatures
+// are ignored.
+func LoadKeySet(path string) (_ *KeySet, err error) {
+	var data []byte
+	if data, err = os.ReadFile(path); err != nil {
+		return nil, err
+	}
+	return ParseKeySet(data)
+}
+
+// ParseKeySet parses a JSON web key set. Only RSA keys of at least 2048 bits, EC keys on
+// the P-256, P-384, and P-521 curves, and Ed25519 keys are accepted.
+func ParseKeySet(data []byte) (_ *KeySet, err error) {
+	var jwks struct {
+		Keys []json.RawMessage `json:"keys"`
+	}
+
+	if err = json.Unmarshal(data, &jwks); err != nil {
+		return nil, err
+	}
+
+	set := &KeySet{keys: make([]*webKey, 0, len(jwks.Keys))}
+	for i, raw := range jwks.Keys {
+		var key *webKey
+		if key, err = parseKey(raw); err != nil {
+			return nil, fmt.Errorf("key %d: %w", i, err)
+		}
+
+		if key != nil {
+			set.keys = append(set.keys, key)
+		}
+	}
+
+	if len(set.keys) == 0 {
+		return nil, fmt.Errorf("%w: no signing keys in the key set", ErrInvalidKey)
+	}
+	return set, nil
+}
+
+// Parse a signing key from the key set, returning nil if the key is not for signatures.
+func parseKey(raw json.RawMessage) (_ *webKey, err error) {
+	var params struct {
+		Kty string `json:"kty"`
+		Use string `json:"use"`
+		X   string `json:"x"`
+	}
+
+	if err = json.Unmarshal(raw, &params); err != nil {
+		return nil, err
+	}
+
+	if params.Use != "" && params.Use != "sig" {
+		return nil, nil
+	}
+
+	switch params.Kty {
+	case "RSA", "EC":
+	case "OKP":
+		// Short Ed25519 keys are padded when they are parsed so the length is checked first.
+		if x, err := base64.RawURLEncoding.DecodeString(params.X); err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, ErrInvalidKey
+		}
+	default:
+		return nil, ErrUnsupportedKey
+	}
+
+	var jwk jose.JSONWebKey
+	if err = jwk.UnmarshalJSON(raw); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidKey, err)
+	}
+
+	if !jwk.Valid() {
+		return nil, ErrInvalidKey
+	}
+
+	// Only the public key is used to verify signatures.
+	if !jwk.IsPublic() {
+		jwk = jwk.Public()
+	}
+
+	switch pub := jwk.Key.(type) {
+	case *rsa.PublicKey:
+		if pub.N.BitLen() < minRSABits {
+			return nil, fmt.Errorf("%w: rsa keys must be at least %d bits", ErrWeakKey, minRSABits)
+		}
+	case *ecdsa.PublicKey, ed25519.PublicKey:
+	default:
+		return nil, ErrUnsupportedKey
+	}
+
+	if jwk.Algorithm != "" && !supported(jwk.Algorithm) {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedAlg, jwk.Algorithm)
+	}
+	return &webKey{id: jwk.KeyID, alg: jwk.Algorithm, key: jwk.Key}, nil
+}
+
+// Verify the signature of a compact serialized JWT and return its claims. The claims
+// are not validated; use Claims.Validate to check the expiration, issuer, and audience.
+func (s *KeySet) Verify(token string) (_ *Claims, err error) {
+	var tok *jwt.JSONWebToken
+	if tok, err = jwt.ParseSigned(token, algorithms); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrMalformedToken, err)
+	}
+
+	if len(tok.Headers) != 1 {
+		return nil, ErrMalformedToken
+	}
+
+	var key *webKey
+	if key, err = s.lookup(tok.Headers[0].KeyID, tok.Headers[0].Algorithm); err != nil {
+		return nil, err
+	}
+
+	claims := &Claims{}
+	if err = tok.Claims(key.key, claims); err != nil {
+		if errors.Is(err, jose.ErrCryptoFailure) {
+			return nil, ErrInvalidSignature
+		}
+		return nil, fmt.Errorf("%w: %s", ErrMalformedToken, err)
+	}
+	return claims, nil
+}
+
+// Returns the key with the key id that can verify the algorithm. If the token does not
+// have a key id then the key set must contain exactly one key.
+func (s *KeySet) lookup(kid, alg string) (*webKey, error) {
+	var key *webKey
+	switch {
+	case kid != "":
+		for _, k := range s.keys {
+			if k.id == kid {
+				key = k
+				break
+			}
+		}
+	case len(s.keys) == 1:
+		key = s.keys[0]
+	}
+
+	if key == nil {
+		return nil, ErrUnknownKey
+	}
+
+	// The algorithm is checked against the key to prevent algorithm substitution.
+	if (key.alg != "" && key.alg != alg) || !verifies(alg, key.key) {
+		return nil, ErrUnsupportedAlg
+	}
+	return key, nil
+}
+
+func supported(alg string) bool {
+	for _, a := range algorithms {
+		if string(a) == alg {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns true if the public key is of the type and curve required by the algorithm.
+func verifies(alg string, key interface{}) bool {
+	switch pub := key.(type) {
+	case *rsa.PublicKey:
+		switch jose.SignatureAlgorithm(alg) {
+		case jose.RS256, jose.RS384, jose.RS512, jose.PS256, jose.PS384, jose.PS512:
+			return true
+		}
+	case *ecdsa.PublicKey:
+		switch jose.SignatureAlgorithm(alg) {
+		case jose.ES256:
+			return pub.Curve == elliptic.P256()
+		case jose.ES384:
+			return pub.Curve == elliptic.P384()
+		case jose.ES512:
+			return pub.Curve == elliptic.P521()
+		}
+	case ed25519.PublicKey:
+		return jose.SignatureAlgorithm(alg) == jose.EdDSA
+	}
+	return false
+}
+
+// Claims are the registered claims of a bearer token that the server validates along
+// with the roles of the subject, which are used to authorize its statements.
+type Claims struct {
+	jwt.Claims
+	Roles []string `json:"roles,omitempty"`
+}
+
+// Validate that the token is valid at the specified time and, if they are not empty,
+// that it was issued by the issuer and is intended for the audience. Tokens must expire.
+func (c *Claims) Validate(now time.Time, issuer, audience string) error {
+	if c.Expiry == nil {
+		return ErrNoExpiration
+	}
+
+	expected := jwt.Expected{Issuer: issuer, Time: now}
+	if audience != "" {
+		expected.AnyAudience = jwt.Audience{audience}
+	}
+
+	switch err := c.ValidateWithLeeway(expected, clockSkew); {
+	case err == nil:
+		return nil
+	case errors.Is(err, jwt.ErrExpired):
+		return ErrTokenExpired
+	case errors.Is(err, jwt.ErrNotValidYet), errors.Is(err, jwt.ErrIssuedInTheFuture):
+		return ErrTokenNotValidYet
+	case errors.Is(err, jwt.ErrInvalidIssuer):
+		return ErrInvalidIssuer
+	case errors.Is(err, jwt.ErrInvalidAudience):
+		return ErrInvalidAudience
+	default:
+		return err
+	}
+}
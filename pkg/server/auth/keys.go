@@ -0,0 +1,72 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/json"
+	"os"
+)
+
+// APIKey is a static credential that identifies a client of the database server.
+type APIKey struct {
+	Name  string   `json:"name"`
+	Key   string   `json:"key"`
+	Roles []string `json:"roles,omitempty"`
+}
+
+// Keys verifies static api keys. Only the digests of the keys are kept in memory.
+type Keys struct {
+	principals []*Principal
+	digests    [][sha256.Size]byte
+}
+
+// LoadKeys loads the api keys from a JSON file that contains a list of api keys.
+func LoadKeys(path string) (_ *Keys, err error) {
+	var f *os.File
+	if f, err = os.Open(path); err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var apikeys []APIKey
+	if err = json.NewDecoder(f).Decode(&apikeys); err != nil {
+		return nil, err
+	}
+	return NewKeys(apikeys...)
+}
+
+// NewKeys creates a verifier for the api keys; every key must have a unique name.
+func NewKeys(apikeys ...APIKey) (*Keys, error) {
+	if len(apikeys) == 0 {
+		return nil, ErrNoKeys
+	}
+
+	keys := &Keys{principals: make([]*Principal, 0, len(apikeys)), digests: make([][sha256.Size]byte, 0, len(apikeys))}
+	seen := make(map[string]struct{}, len(apikeys))
+	for _, apikey := range apikeys {
+		if apikey.Name == "" || apikey.Key == "" {
+			return nil, ErrMissingKeyOrName
+		}
+
+		if _, ok := seen[apikey.Name]; ok {
+			return nil, ErrDuplicateKeyName
+		}
+		seen[apikey.Name] = struct{}{}
+
+		keys.principals = append(keys.principals, &Principal{Name: apikey.Name, Method: MethodAPIKey, Roles: apikey.Roles})
+		keys.digests = append(keys.digests, sha256.Sum256([]byte(apikey.Key)))
+	}
+	return keys, nil
+}
+
+// Verify returns the principal identified by the api key if it is valid. Every key is
+// compared in constant time so that the time taken does not reveal which key matched.
+func (k *Keys) Verify(key string) (principal *Principal, ok bool) {
+	digest := sha256.Sum256([]byte(key))
+	for i := range k.digests {
+		if subtle.ConstantTimeCompare(digest[:], k.digests[i][:]) == 1 {
+			principal, ok = k.principals[i], true
+		}
+	}
+	return principal, ok
+}
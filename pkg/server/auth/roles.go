@@ -0,0 +1,163 @@
+package auth
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/bbengfort/otterdb/pkg/fsm"
+)
+
+// Wildcard is a table allow-list entry that allows all tables, including statements
+// whose tables cannot be determined such as PRAGMA or VACUUM.
+const Wildcard = "*"
+
+// Admin is the permission that allows a role to manage the cluster, e.g. to change the
+// members of the quorum; it does not permit any statements.
+const Admin = "admin"
+
+// Role permits the operations on the tables in its allow-list, e.g. a read-only role
+// for analytics or a role that can write to the tables of a service. The permissions
+// are the names of the operations: read, write, ddl, or pragma, or admin to allow the
+// role to manage the cluster.
+type Role struct {
+	Permissions []string `json:"permissions"`
+	Tables      []string `json:"tables"`
+}
+
+// Roles authorizes the statements of principals by the roles assigned to them.
+type Roles struct {
+	roles map[string]*role
+}
+
+type role struct {
+	operations map[fsm.Operation]struct{}
+	tables     map[string]struct{}
+	all        bool
+	admin      bool
+}
+
+// LoadRoles loads the roles from a JSON file that maps role names to roles.
+func LoadRoles(path string) (_ *Roles, err error) {
+	var f *os.File
+	if f, err = os.Open(path); err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var roles map[string]Role
+	if err = json.NewDecoder(f).Decode(&roles); err != nil {
+		return nil, err
+	}
+	return NewRoles(roles)
+}
+
+// NewRoles creates an authorizer for the named roles.
+func NewRoles(roles map[string]Role) (*Roles, error) {
+	if len(roles) == 0 {
+		return nil, ErrNoRoles
+	}
+
+	r := &Roles{roles: make(map[string]*role, len(roles))}
+	for name, conf := range roles {
+		rl := &role{
+			operations: make(map[fsm.Operation]struct{}, len(conf.Permissions)),
+			tables:     make(map[string]struct{}, len(conf.Tables)),
+		}
+
+		for _, perm := range conf.Permissions {
+			if strings.EqualFold(strings.TrimSpace(perm), Admin) {
+				rl.admin = true
+				continue
+			}
+
+			op, err := fsm.ParseOperation(perm)
+			if err != nil {
+				return nil, fmt.Errorf("role %q: %w", name, err)
+			}
+			rl.operations[op] = struct{}{}
+		}
+
+		for _, table := range conf.Tables {
+			if table == Wildcard {
+				rl.all = true
+				continue
+			}
+			rl.tables[strings.ToLower(table)] = struct{}{}
+		}
+
+		r.roles[name] = rl
+	}
+	return r, nil
+}
+
+// AuthorizeAdmin returns an error if none of the roles of the principal permit it to
+// manage the cluster.
+func (r *Roles) AuthorizeAdmin(principal *Principal) error {
+	for _, name := range principal.Roles {
+		if rl, ok := r.roles[name]; ok && rl.admin {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s is not an admin", ErrPermissionDenied, principal.Name)
+}
+
+// Authorize returns an error if the roles of the principal do not permit the statement.
+// One of the roles must permit the operation of the statement; every table that the
+// statement modifies must be allowed by a role that permits the operation and every
+// table that it reads from must be allowed by a role that permits reads. Roles that are
+// not defined are ignored.
+func (r *Roles) Authorize(principal *Principal, stmt *fsm.Classification) error {
+	roles := make([]*role, 0, len(principal.Roles))
+	for _, name := range principal.Roles {
+		if rl, ok := r.roles[name]; ok {
+			roles = append(roles, rl)
+		}
+	}
+
+	if len(roles) == 0 {
+		return fmt.Errorf("%w: %s has no roles", ErrPermissionDenied, principal.Name)
+	}
+
+	if !permits(roles, stmt.Operation, "") {
+		return fmt.Errorf("%w: %s", ErrPermissionDenied, stmt.Operation)
+	}
+
+	if stmt.Unscoped && !permits(roles, stmt.Operation, Wildcard) {
+		return fmt.Errorf("%w: %s on all tables", ErrPermissionDenied, stmt.Operation)
+	}
+
+	for _, table := range stmt.Targets {
+		if !permits(roles, stmt.Operation, table) {
+			return fmt.Errorf("%w: %s on %s", ErrPermissionDenied, stmt.Operation, table)
+		}
+	}
+
+	for _, table := range stmt.Reads {
+		if !permits(roles, fsm.OpRead, table) {
+			return fmt.Errorf("%w: %s on %s", ErrPermissionDenied, fsm.OpRead, table)
+		}
+	}
+	return nil
+}
+
+// Returns true if any of the roles permits the operation on the table. If the table is
+// empty then only the operation is checked; the wildcard requires a role that allows
+// all tables.
+func permits(roles []*role, op fsm.Operation, table string) bool {
+	for _, rl := range roles {
+		if _, ok := rl.operations[op]; !ok {
+			continue
+		}
+
+		if table == "" || rl.all {
+			return true
+		}
+
+		if _, ok := rl.tables[table]; ok {
+			return true
+		}
+	}
+	return false
+}
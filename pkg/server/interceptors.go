@@ -0,0 +1,187 @@
+package server
+
+import (
+	"context"
+	"strings"
+
+	"github.com/bbengfort/otterdb/pkg/grpc/health/v1"
+	"github.com/bbengfort/otterdb/pkg/server/api/v1"
+	"github.com/bbengfort/otterdb/pkg/server/auth"
+
+	"github.com/rs/zerolog/log"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// RPCs that clients may call without authenticating, e.g. so that load balancers can
+// check the health of the server. All other RPCs require authentication if enabled.
+var public = map[string]struct{}{
+	health.Health_Check_FullMethodName: {},
+	health.Health_Watch_FullMethodName: {},
+	api.Otter_Status_FullMethodName:    {},
+}
+
+// RPCs that manage the cluster rather than execute statements; if roles are configured
+// the principal must have a role with the admin permission.
+var admin = map[string]struct{}{
+	api.Otter_AddPeer_FullMethodName:     {},
+	api.Otter_RemovePeer_FullMethodName:  {},
+	api.Otter_PromotePeer_FullMethodName: {},
+}
+
+// UnaryInterceptors returns the interceptors that are chained on every unary RPC.
+func (s *Server) UnaryInterceptors() []grpc.UnaryServerInterceptor {
+	interceptors := make([]grpc.UnaryServerInterceptor, 0, 2)
+	if s.auth != nil {
+		interceptors = append(interceptors, s.authenticateUnary)
+	}
+
+	if s.roles != nil {
+		interceptors = append(interceptors, s.authorizeUnary)
+	}
+	return interceptors
+}
+
+// StreamInterceptors returns the interceptors that are chained on every streaming RPC.
+func (s *Server) StreamInterceptors() []grpc.StreamServerInterceptor {
+	interceptors := make([]grpc.StreamServerInterceptor, 0, 2)
+	if s.auth != nil {
+		interceptors = append(interceptors, s.authenticateStream)
+	}
+
+	if s.roles != nil {
+		interceptors = append(interceptors, s.authorizeStream)
+	}
+	return interceptors
+}
+
+// Authenticate the client of unary RPCs and add the principal to the request context.
+func (s *Server) authenticateUnary(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if _, ok := public[info.FullMethod]; ok {
+		return handler(ctx, req)
+	}
+
+	principal, err := s.authenticate(ctx, info.FullMethod)
+	if err != nil {
+		return nil, err
+	}
+	return handler(auth.NewContext(ctx, principal), req)
+}
+
+// Authenticate the client of streaming RPCs and add the principal to the stream context.
+func (s *Server) authenticateStream(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if _, ok := public[info.FullMethod]; ok {
+		return handler(srv, stream)
+	}
+
+	principal, err := s.authenticate(stream.Context(), info.FullMethod)
+	if err != nil {
+		return err
+	}
+	return handler(srv, &authenticatedStream{ServerStream: stream, ctx: auth.NewContext(stream.Context(), principal)})
+}
+
+func (s *Server) authenticate(ctx context.Context, method string) (*auth.Principal, error) {
+	principal, err := s.auth.Authenticate(ctx)
+	if err != nil {
+		log.Debug().Err(err).Str("method", method).Msg("refused unauthenticated request")
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+	return principal, nil
+}
+
+// Authorize the statements of the request by the roles of the authenticated principal.
+// Statements are classified by preparing them against the local database, so every
+// statement of a transaction must be valid before the transaction is applied, e.g. a
+// statement cannot use a table that is created earlier in the same transaction.
+// Requests to admin RPCs require the admin permission and requests to other RPCs that
+// do not have statements are refused so that new RPCs must be explicitly authorized.
+func (s *Server) authorizeUnary(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if _, ok := public[info.FullMethod]; ok {
+		return handler(ctx, req)
+	}
+
+	principal, ok := auth.FromContext(ctx)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "request is not authenticated")
+	}
+
+	if _, ok := admin[info.FullMethod]; ok {
+		if err := s.roles.AuthorizeAdmin(principal); err != nil {
+			log.Debug().Err(err).Str("method", info.FullMethod).Str("principal", principal.Name).Msg("refused unauthorized admin request")
+			return nil, status.Error(codes.PermissionDenied, err.Error())
+		}
+		return handler(ctx, req)
+	}
+
+	stmts, ok := statements(req)
+	if !ok {
+		log.Debug().Str("method", info.FullMethod).Str("principal", principal.Name).Msg("refused request without statements to authorize")
+		return nil, status.Error(codes.PermissionDenied, auth.ErrPermissionDenied.Error())
+	}
+
+	if s.db == nil {
+		return nil, status.Error(codes.Unavailable, "no database is configured on this replica")
+	}
+
+	for _, stmt := range stmts {
+		// Empty statements are rejected by the handler.
+		if strings.TrimSpace(stmt.GetSql()) == "" {
+			continue
+		}
+
+		classification, err := s.db.Classify(ctx, stmt.Sql)
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+
+		if err = s.roles.Authorize(principal, classification); err != nil {
+			log.Debug().Err(err).Str("method", info.FullMethod).Str("principal", principal.Name).Msg("refused unauthorized statement")
+			return nil, status.Error(codes.PermissionDenied, err.Error())
+		}
+	}
+	return handler(ctx, req)
+}
+
+// Authorize streaming RPCs by the roles of the authenticated principal. Streams do not
+// have statements that can be authorized so only public streams are allowed; new
+// streaming RPCs must be explicitly authorized.
+func (s *Server) authorizeStream(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if _, ok := public[info.FullMethod]; ok {
+		return handler(srv, stream)
+	}
+
+	principal, ok := auth.FromContext(stream.Context())
+	if !ok {
+		return status.Error(codes.Unauthenticated, "request is not authenticated")
+	}
+
+	log.Debug().Str("method", info.FullMethod).Str("principal", principal.Name).Msg("refused stream without statements to authorize")
+	return status.Error(codes.PermissionDenied, auth.ErrPermissionDenied.Error())
+}
+
+// Returns the statements of the request, or false if the request does not have
+// statements that can be authorized.
+func statements(req interface{}) ([]*api.Statement, bool) {
+	switch in := req.(type) {
+	case *api.ExecRequest:
+		return []*api.Statement{in.GetStatement()}, true
+	case *api.TransactionRequest:
+		return in.GetStatements(), true
+	case *api.QueryRequest:
+		return []*api.Statement{in.GetStatement()}, true
+	default:
+		return nil, false
+	}
+}
+
+// Wraps a server stream so that handlers receive the authenticated context.
+type authenticatedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authenticatedStream) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,88 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"math"
+
+	"github.com/bbengfort/otterdb/pkg/replica"
+	"github.com/bbengfort/otterdb/pkg/replica/peers"
+	"github.com/bbengfort/otterdb/pkg/server/api/v1"
+
+	"github.com/rs/zerolog/log"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// AddPeer adds a replica to the quorum and returns the voting members once the change
+// has been committed. The new replica should be started with the peers of the current
+// quorum so that it waits to be added before it joins elections. If the local replica
+// is not the leader, a redirect to the leader is returned.
+func (s *Server) AddPeer(ctx context.Context, in *api.Peer) (_ *api.MembershipResult, err error) {
+	if in.Name == "" || in.Addr == "" {
+		return nil, status.Error(codes.InvalidArgument, "the name and address of the peer are required")
+	}
+
+	if in.Pid > math.MaxUint16 {
+		return nil, status.Error(codes.InvalidArgument, "the pid of the peer is out of range")
+	}
+
+	peer := &peers.Peer{
+		PID:        uint16(in.Pid),
+		Name:       in.Name,
+		Addr:       in.Addr,
+		ClientAddr: in.ClientAddr,
+		Region:     in.Region,
+		Learner:    in.Learner,
+	}
+	return s.changeMembership(s.replica.AddPeer(ctx, peer))
+}
+
+// RemovePeer removes a replica from the quorum, e.g. to replace a replica that has
+// failed, and returns the voting members once the change has been committed.
+func (s *Server) RemovePeer(ctx context.Context, in *api.PeerRequest) (_ *api.MembershipResult, err error) {
+	if in.Name == "" {
+		return nil, status.Error(codes.InvalidArgument, "the name of the peer is required")
+	}
+	return s.changeMembership(s.replica.RemovePeer(ctx, in.Name))
+}
+
+// PromotePeer changes a learner into a voting member of the quorum and returns the
+// voting members once the change has been committed.
+func (s *Server) PromotePeer(ctx context.Context, in *api.PeerRequest) (_ *api.MembershipResult, err error) {
+	if in.Name == "" {
+		return nil, status.Error(codes.InvalidArgument, "the name of the peer is required")
+	}
+	return s.changeMembership(s.replica.PromotePeer(ctx, in.Name))
+}
+
+// Returns the voting members of the quorum if the membership change was committed,
+// otherwise a redirect to the leader or the gRPC status of the error.
+func (s *Server) changeMembership(err error) (*api.MembershipResult, error) {
+	if err != nil {
+		if errors.Is(err, replica.ErrNotLeader) {
+			return nil, s.redirect()
+		}
+		return nil, membershipError(err)
+	}
+	return &api.MembershipResult{Voters: s.replica.Quorum().Hosts()}, nil
+}
+
+// Convert an error from changing the members of the quorum into a gRPC status error.
+func membershipError(err error) error {
+	switch {
+	case errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded):
+		return status.FromContextError(err).Err()
+	case errors.Is(err, replica.ErrMemberExists):
+		return status.Error(codes.AlreadyExists, err.Error())
+	case errors.Is(err, replica.ErrNotMember):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, replica.ErrConfigChange), errors.Is(err, replica.ErrNotLearner), errors.Is(err, replica.ErrNotCaughtUp), errors.Is(err, replica.ErrEmptyQuorum):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, replica.ErrNotListening), errors.Is(err, replica.ErrNoLeader), errors.Is(err, replica.ErrTransferring):
+		return status.Error(codes.Unavailable, err.Error())
+	default:
+		log.Error().Err(err).Msg("could not change the members of the quorum")
+		return status.Error(codes.Internal, "could not change the members of the quorum")
+	}
+}
@@ -4,16 +4,21 @@ Package server implements the query server for making database queries to otterd
 package server
 
 import (
+	"crypto/tls"
 	"fmt"
 	"net"
 	"time"
 
 	"github.com/bbengfort/otterdb/pkg/config"
+	"github.com/bbengfort/otterdb/pkg/fsm"
 	"github.com/bbengfort/otterdb/pkg/grpc/health/v1"
+	"github.com/bbengfort/otterdb/pkg/replica"
 	"github.com/bbengfort/otterdb/pkg/server/api/v1"
+	"github.com/bbengfort/otterdb/pkg/server/auth"
 
 	"github.com/rs/zerolog/log"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 )
 
 type Server struct {
@@ -22,21 +27,47 @@ type Server struct {
 
 	conf    config.ServerConfig
 	srv     *grpc.Server
+	replica *replica.Replica
+	db      *fsm.FSM
+	auth    *auth.Authenticator
+	roles   *auth.Roles
 	started time.Time
 }
 
-func New(conf config.ServerConfig) (s *Server, err error) {
+// New creates the database server; statements are replicated and applied to the
+// database by the replica and queries are executed directly against the database.
+func New(conf config.ServerConfig, replica *replica.Replica, db *fsm.FSM) (s *Server, err error) {
 	// Must supply a valid configuration.
 	if err = conf.Validate(); err != nil {
 		return nil, err
 	}
 
-	s = &Server{conf: conf}
+	s = &Server{conf: conf, replica: replica, db: db}
+
+	// Load the api keys and key set used to authenticate clients, if configured
+	if s.auth, err = auth.New(conf.Auth); err != nil {
+		return nil, fmt.Errorf("could not configure authentication: %w", err)
+	}
+
+	// Load the roles used to authorize the statements of clients, if configured
+	if conf.Auth.RolesPath != "" {
+		if s.roles, err = auth.LoadRoles(conf.Auth.RolesPath); err != nil {
+			return nil, fmt.Errorf("could not load roles: %w", err)
+		}
+	}
 
 	// Prepare to receive gRPC requests and configure RPCs
 	opts := make([]grpc.ServerOption, 0, 4)
-	// opts = append(opts, grpc.ChainUnaryInterceptor(s.UnaryInterceptors()...))
-	// opts = append(opts, grpc.ChainStreamInterceptor(s.StreamInterceptors()...))
+	if conf.TLS.Enabled() {
+		var creds credentials.TransportCredentials
+		if creds, err = serverCredentials(conf.TLS); err != nil {
+			return nil, err
+		}
+		opts = append(opts, grpc.Creds(creds))
+	}
+
+	opts = append(opts, grpc.ChainUnaryInterceptor(s.UnaryInterceptors()...))
+	opts = append(opts, grpc.ChainStreamInterceptor(s.StreamInterceptors()...))
 	s.srv = grpc.NewServer(opts...)
 
 	// Initialize the gRPC services
@@ -49,6 +80,19 @@ func New(conf config.ServerConfig) (s *Server, err error) {
 	return s, nil
 }
 
+// Load the certificate that the server uses to secure connections from clients.
+func serverCredentials(conf config.ServerTLSConfig) (_ credentials.TransportCredentials, err error) {
+	var cert tls.Certificate
+	if cert, err = tls.LoadX509KeyPair(conf.CertPath, conf.KeyPath); err != nil {
+		return nil, fmt.Errorf("could not load certificate: %w", err)
+	}
+
+	return credentials.NewTLS(&tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}), nil
+}
+
 func (s *Server) Serve(errc chan<- error) (err error) {
 	if !s.conf.Enabled {
 		log.Warn().Bool("enabled", s.conf.Enabled).Msg("otterdb database server is disabled")
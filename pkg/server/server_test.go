@@ -0,0 +1,513 @@
+package server_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bbengfort/otterdb/pkg/bufconn"
+	"github.com/bbengfort/otterdb/pkg/config"
+	"github.com/bbengfort/otterdb/pkg/fsm"
+	"github.com/bbengfort/otterdb/pkg/grpc/health/v1"
+	"github.com/bbengfort/otterdb/pkg/logger"
+	"github.com/bbengfort/otterdb/pkg/replica"
+	"github.com/bbengfort/otterdb/pkg/server"
+	"github.com/bbengfort/otterdb/pkg/server/api/v1"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+func TestMain(m *testing.M) {
+	logger.Discard()
+	exitVal := m.Run()
+	logger.ResetLogger()
+	os.Exit(exitVal)
+}
+
+func TestExecQuery(t *testing.T) {
+	client := newClient(t)
+	ctx := context.Background()
+
+	_, err := client.Exec(ctx, &api.ExecRequest{Statement: &api.Statement{Sql: "CREATE TABLE otters (id INTEGER PRIMARY KEY, name TEXT NOT NULL UNIQUE, age INTEGER, weight REAL, photo BLOB)"}})
+	require.NoError(t, err, "could not create table")
+
+	rep, err := client.Exec(ctx, &api.ExecRequest{Statement: &api.Statement{
+		Sql: "INSERT INTO otters (name, age, weight, photo) VALUES (:name, :age, :weight, :photo)",
+		Params: []*api.Parameter{
+			{Name: "name", Value: &api.Value{Value: &api.Value_Text{Text: "jade"}}},
+			{Name: ":age", Value: &api.Value{Value: &api.Value_Integer{Integer: 4}}},
+			{Name: "@weight", Value: &api.Value{Value: &api.Value_Real{Real: 9.8}}},
+			{Name: "$photo", Value: &api.Value{Value: &api.Value_Blob{Blob: []byte{0xca, 0xfe}}}},
+		},
+	}})
+	require.NoError(t, err, "could not insert row")
+	require.Equal(t, int64(1), rep.LastInsertId)
+	require.Equal(t, int64(1), rep.RowsAffected)
+	require.Equal(t, uint64(2), rep.Index)
+
+	_, err = client.Exec(ctx, &api.ExecRequest{Statement: &api.Statement{
+		Sql: "INSERT INTO otters (name, age) VALUES (?, ?)",
+		Params: []*api.Parameter{
+			{Value: &api.Value{Value: &api.Value_Text{Text: "kira"}}},
+			{Value: &api.Value{Value: &api.Value_Null{Null: true}}},
+		},
+	}})
+	require.NoError(t, err, "could not insert row")
+
+	// Constraint violations are returned to the client
+	_, err = client.Exec(ctx, &api.ExecRequest{Statement: &api.Statement{Sql: "INSERT INTO otters (name) VALUES ('jade')"}})
+	requireStatus(t, err, codes.InvalidArgument)
+
+	// Parameters must have values
+	_, err = client.Exec(ctx, &api.ExecRequest{Statement: &api.Statement{Sql: "INSERT INTO otters (name) VALUES (?)", Params: []*api.Parameter{{}}}})
+	requireStatus(t, err, codes.InvalidArgument)
+
+	_, err = client.Exec(ctx, &api.ExecRequest{})
+	requireStatus(t, err, codes.InvalidArgument)
+
+	out, err := client.Query(ctx, &api.QueryRequest{Statement: &api.Statement{Sql: "SELECT id, name, age, weight, photo, age * 2 FROM otters ORDER BY id"}})
+	require.NoError(t, err, "could not query table")
+	require.Len(t, out.Columns, 6)
+	require.Equal(t, "name", out.Columns[1].Name)
+	require.Equal(t, "TEXT", out.Columns[1].Type)
+	require.Len(t, out.Rows, 2)
+
+	jade := out.Rows[0].Values
+	require.Equal(t, int64(1), jade[0].GetInteger())
+	require.Equal(t, "jade", jade[1].GetText())
+	require.Equal(t, int64(4), jade[2].GetInteger())
+	require.Equal(t, 9.8, jade[3].GetReal())
+	require.Equal(t, []byte{0xca, 0xfe}, jade[4].GetBlob())
+	require.Equal(t, int64(8), jade[5].GetInteger())
+
+	kira := out.Rows[1].Values
+	require.Equal(t, "kira", kira[1].GetText())
+	require.True(t, kira[2].GetNull())
+	require.True(t, kira[4].GetNull())
+
+	out, err = client.Query(ctx, &api.QueryRequest{
+		Statement: &api.Statement{
+			Sql:    "SELECT count(*) FROM otters WHERE name=?",
+			Params: []*api.Parameter{{Value: &api.Value{Value: &api.Value_Text{Text: "kira"}}}},
+		},
+		Consistency: api.QueryRequest_LINEARIZABLE,
+	})
+	require.NoError(t, err, "could not query table")
+	require.Equal(t, int64(1), out.Rows[0].Values[0].GetInteger())
+
+	// Queries cannot modify the database
+	_, err = client.Query(ctx, &api.QueryRequest{Statement: &api.Statement{Sql: "DELETE FROM otters"}})
+	requireStatus(t, err, codes.InvalidArgument)
+
+	_, err = client.Query(ctx, &api.QueryRequest{Statement: &api.Statement{Sql: "SELECT * FROM beavers"}})
+	requireStatus(t, err, codes.InvalidArgument)
+
+	_, err = client.Query(ctx, &api.QueryRequest{})
+	requireStatus(t, err, codes.InvalidArgument)
+
+	// A single node is never stale and is always the leader
+	out, err = client.Query(ctx, &api.QueryRequest{
+		Statement:    &api.Statement{Sql: "SELECT count(*) FROM otters"},
+		Consistency:  api.QueryRequest_LEASE,
+		MaxStaleness: durationpb.New(time.Millisecond),
+	})
+	require.NoError(t, err, "could not query table")
+	require.Equal(t, int64(2), out.Rows[0].Values[0].GetInteger())
+}
+
+func TestAuthentication(t *testing.T) {
+	edPub, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err, "could not generate signing key")
+
+	dir := t.TempDir()
+	certPath, keyPath := writeCertificate(t, dir, "localhost")
+
+	keysPath := filepath.Join(dir, "keys.json")
+	require.NoError(t, os.WriteFile(keysPath, []byte(`[{"name": "analytics", "key": "s3cr3t"}]`), 0600))
+
+	jwksPath := filepath.Join(dir, "jwks.json")
+	jwks := fmt.Sprintf(`{"keys": [{"kty": "OKP", "crv": "Ed25519", "alg": "EdDSA", "x": %q}]}`, base64.RawURLEncoding.EncodeToString(edPub))
+	require.NoError(t, os.WriteFile(jwksPath, []byte(jwks), 0600))
+
+	sock := newServer(t, config.ServerConfig{
+		Enabled:  true,
+		BindAddr: bufconn.Endpoint,
+		TLS:      config.ServerTLSConfig{CertPath: certPath, KeyPath: keyPath},
+		Auth:     config.AuthConfig{KeysPath: keysPath, JWKSPath: jwksPath, Audience: "otterdb"},
+	})
+
+	// Clients must connect with TLS
+	insecureConn, err := sock.Connect(context.Background(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err, "could not create client")
+	defer insecureConn.Close()
+
+	_, err = api.NewOtterClient(insecureConn).Query(context.Background(), &api.QueryRequest{Statement: &api.Statement{Sql: "SELECT 1"}})
+	requireStatus(t, err, codes.Unavailable)
+
+	data, err := os.ReadFile(certPath)
+	require.NoError(t, err, "could not read certificate")
+
+	pool := x509.NewCertPool()
+	require.True(t, pool.AppendCertsFromPEM(data))
+
+	cc, err := sock.Connect(context.Background(), grpc.WithTransportCredentials(credentials.NewTLS(&tls.Config{ServerName: "localhost", RootCAs: pool})))
+	require.NoError(t, err, "could not connect to server")
+	defer cc.Close()
+
+	client := api.NewOtterClient(cc)
+	query := &api.QueryRequest{Statement: &api.Statement{Sql: "SELECT 1"}}
+
+	// Health checks do not require authentication
+	rep, err := health.NewHealthClient(cc).Check(context.Background(), &health.HealthCheckRequest{})
+	require.NoError(t, err, "health checks should not require authentication")
+	require.NotNil(t, rep)
+
+	_, err = client.Query(context.Background(), query)
+	requireStatus(t, err, codes.Unauthenticated)
+
+	_, err = client.Query(metadata.AppendToOutgoingContext(context.Background(), "x-api-key", "wrong"), query)
+	requireStatus(t, err, codes.Unauthenticated)
+
+	_, err = client.Query(metadata.AppendToOutgoingContext(context.Background(), "x-api-key", "s3cr3t"), query)
+	require.NoError(t, err, "could not query with api key")
+
+	token := func(aud string) string {
+		header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"EdDSA","typ":"JWT"}`))
+		claims := base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf(`{"sub":"jade","aud":%q,"exp":%d}`, aud, time.Now().Add(time.Hour).Unix())))
+		sig := ed25519.Sign(edKey, []byte(header+"."+claims))
+		return "Bearer " + header + "." + claims + "." + base64.RawURLEncoding.EncodeToString(sig)
+	}
+
+	_, err = client.Query(metadata.AppendToOutgoingContext(context.Background(), "authorization", token("otterdb")), query)
+	require.NoError(t, err, "could not query with bearer token")
+
+	_, err = client.Query(metadata.AppendToOutgoingContext(context.Background(), "authorization", token("web")), query)
+	requireStatus(t, err, codes.Unauthenticated)
+}
+
+func TestAuthorization(t *testing.T) {
+	dir := t.TempDir()
+	keysPath := filepath.Join(dir, "keys.json")
+	keys := `[
+		{"name": "admin", "key": "admin-key", "roles": ["admin"]},
+		{"name": "service", "key": "service-key", "roles": ["service"]},
+		{"name": "analytics", "key": "analytics-key", "roles": ["analytics"]}
+	]`
+	require.NoError(t, os.WriteFile(keysPath, []byte(keys), 0600))
+
+	rolesPath := filepath.Join(dir, "roles.json")
+	roles := `{
+		"admin": {"permissions": ["read", "write", "ddl", "pragma", "admin"], "tables": ["*"]},
+		"service": {"permissions": ["read", "write"], "tables": ["otters"]},
+		"analytics": {"permissions": ["read"], "tables": ["otters"]}
+	}`
+	require.NoError(t, os.WriteFile(rolesPath, []byte(roles), 0600))
+
+	sock := newServer(t, config.ServerConfig{
+		Enabled:  true,
+		BindAddr: bufconn.Endpoint,
+		Auth:     config.AuthConfig{KeysPath: keysPath, RolesPath: rolesPath},
+	})
+
+	cc, err := sock.Connect(context.Background(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err, "could not connect to server")
+	defer cc.Close()
+
+	client := api.NewOtterClient(cc)
+	as := func(key string) context.Context {
+		return metadata.AppendToOutgoingContext(context.Background(), "x-api-key", key)
+	}
+
+	exec := func(key, sql string) error {
+		_, err := client.Exec(as(key), &api.ExecRequest{Statement: &api.Statement{Sql: sql}})
+		return err
+	}
+
+	query := func(key, sql string) error {
+		_, err := client.Query(as(key), &api.QueryRequest{Statement: &api.Statement{Sql: sql}})
+		return err
+	}
+
+	// Only the admin can modify the schema
+	requireStatus(t, exec("service-key", "CREATE TABLE otters (id INTEGER PRIMARY KEY, name TEXT)"), codes.PermissionDenied)
+	require.NoError(t, exec("admin-key", "CREATE TABLE otters (id INTEGER PRIMARY KEY, name TEXT)"))
+	require.NoError(t, exec("admin-key", "CREATE TABLE accounts (id INTEGER PRIMARY KEY, balance INTEGER)"))
+
+	// Services can write to the tables in their allow-list
+	require.NoError(t, exec("service-key", "INSERT INTO otters (name) VALUES ('jade')"))
+	requireStatus(t, exec("service-key", "INSERT INTO accounts (balance) VALUES (100)"), codes.PermissionDenied)
+	requireStatus(t, exec("service-key", "DELETE FROM otters; DROP TABLE accounts"), codes.InvalidArgument)
+	requireStatus(t, exec("service-key", "INSERT INTO otters SELECT * FROM accounts"), codes.PermissionDenied)
+
+	_, err = client.Transaction(as("service-key"), &api.TransactionRequest{Statements: []*api.Statement{
+		{Sql: "INSERT INTO otters (name) VALUES ('kira')"},
+		{Sql: "UPDATE accounts SET balance = 0"},
+	}})
+	requireStatus(t, err, codes.PermissionDenied)
+
+	// Analytics can only read from the tables in their allow-list
+	require.NoError(t, query("analytics-key", "SELECT count(*) FROM otters"))
+	requireStatus(t, query("analytics-key", "SELECT * FROM accounts"), codes.PermissionDenied)
+	requireStatus(t, query("analytics-key", "PRAGMA table_info(otters)"), codes.PermissionDenied)
+	requireStatus(t, exec("analytics-key", "INSERT INTO otters (name) VALUES ('opal')"), codes.PermissionDenied)
+	require.NoError(t, exec("admin-key", "PRAGMA table_info(otters)"))
+	requireStatus(t, query("admin-key", "PRAGMA table_info(otters)"), codes.InvalidArgument)
+
+	// Statements that cannot be classified are refused
+	requireStatus(t, exec("admin-key", "BEGIN TRANSACTION"), codes.InvalidArgument)
+
+	out, err := client.Query(as("admin-key"), &api.QueryRequest{Statement: &api.Statement{Sql: "SELECT count(*) FROM otters"}})
+	require.NoError(t, err)
+	require.Equal(t, int64(1), out.Rows[0].Values[0].GetInteger(), "unauthorized statements should not be applied")
+
+	// Only admins can change the members of the quorum; the change reaches the replica,
+	// which is not replicating in this test.
+	peer := &api.Peer{Pid: 4, Name: "ruby", Addr: "ruby:2204"}
+	_, err = client.AddPeer(as("service-key"), peer)
+	requireStatus(t, err, codes.PermissionDenied)
+
+	_, err = client.RemovePeer(as("analytics-key"), &api.PeerRequest{Name: "ruby"})
+	requireStatus(t, err, codes.PermissionDenied)
+
+	_, err = client.PromotePeer(context.Background(), &api.PeerRequest{Name: "ruby"})
+	requireStatus(t, err, codes.Unauthenticated)
+
+	_, err = client.AddPeer(as("admin-key"), peer)
+	requireStatus(t, err, codes.Unavailable)
+
+	_, err = client.AddPeer(as("admin-key"), &api.Peer{Name: "ruby"})
+	requireStatus(t, err, codes.InvalidArgument)
+
+	_, err = client.RemovePeer(as("admin-key"), &api.PeerRequest{})
+	requireStatus(t, err, codes.InvalidArgument)
+}
+
+func TestAuthorizeStream(t *testing.T) {
+	dir := t.TempDir()
+	keysPath := filepath.Join(dir, "keys.json")
+	require.NoError(t, os.WriteFile(keysPath, []byte(`[{"name": "admin", "key": "admin-key", "roles": ["admin"]}]`), 0600))
+
+	rolesPath := filepath.Join(dir, "roles.json")
+	require.NoError(t, os.WriteFile(rolesPath, []byte(`{"admin": {"permissions": ["read", "write", "ddl", "pragma"], "tables": ["*"]}}`), 0600))
+
+	db, err := fsm.Open(filepath.Join(dir, "otter.db"))
+	require.NoError(t, err, "could not open database")
+	defer db.Close()
+
+	repl, err := replica.New(config.ReplicaConfig{Enabled: false}, replica.WithStateMachine(db))
+	require.NoError(t, err, "could not create replica")
+
+	srv, err := server.New(config.ServerConfig{Enabled: true, BindAddr: bufconn.Endpoint, Auth: config.AuthConfig{KeysPath: keysPath, RolesPath: rolesPath}}, repl, db)
+	require.NoError(t, err, "could not create server")
+
+	interceptors := srv.StreamInterceptors()
+	require.Len(t, interceptors, 2, "expected authentication and authorization interceptors")
+
+	// Run the chain of interceptors and return an error if the handler is not called.
+	stream := func(method string, md ...string) error {
+		var handle grpc.StreamHandler
+		called := false
+		handle = func(interface{}, grpc.ServerStream) error {
+			called = true
+			return nil
+		}
+
+		for i := len(interceptors) - 1; i >= 0; i-- {
+			interceptor, next := interceptors[i], handle
+			handle = func(srv interface{}, ss grpc.ServerStream) error {
+				return interceptor(srv, ss, &grpc.StreamServerInfo{FullMethod: method, IsServerStream: true}, next)
+			}
+		}
+
+		ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(md...))
+		if err := handle(nil, &serverStream{ctx: ctx}); err != nil {
+			return err
+		}
+		require.True(t, called, "expected the handler to be called")
+		return nil
+	}
+
+	// Public streams do not require authentication or authorization
+	require.NoError(t, stream(health.Health_Watch_FullMethodName))
+
+	// All other streams are refused, even for principals that are allowed all statements
+	requireStatus(t, stream("/otterdb.v1.Otter/Subscribe"), codes.Unauthenticated)
+	requireStatus(t, stream("/otterdb.v1.Otter/Subscribe", "x-api-key", "admin-key"), codes.PermissionDenied)
+}
+
+func TestExecUnsafeStatements(t *testing.T) {
+	client := newClient(t)
+	ctx := context.Background()
+
+	_, err := client.Exec(ctx, &api.ExecRequest{Statement: &api.Statement{Sql: "CREATE TABLE otters (id INTEGER PRIMARY KEY, name TEXT)"}})
+	require.NoError(t, err, "could not create table")
+
+	// Statements that would end the transaction that applies the entry or that would
+	// diverge between replicas are rejected before they are replicated.
+	for _, query := range []string{
+		"INSERT INTO otters (name) VALUES ('jade'); COMMIT",
+		"INSERT INTO otters (name) VALUES ('jade'); INSERT INTO otters (name) VALUES ('kira')",
+		"COMMIT",
+		"ROLLBACK",
+		"INSERT INTO otters (name) VALUES (random())",
+		"INSERT INTO otters (name) VALUES (datetime('now'))",
+	} {
+		_, err = client.Exec(ctx, &api.ExecRequest{Statement: &api.Statement{Sql: query}})
+		requireStatus(t, err, codes.InvalidArgument)
+	}
+
+	_, err = client.Transaction(ctx, &api.TransactionRequest{
+		Statements: []*api.Statement{
+			{Sql: "INSERT INTO otters (name) VALUES ('jade')"},
+			{Sql: "COMMIT; INSERT INTO otters (name) VALUES ('kira')"},
+		},
+	})
+	requireStatus(t, err, codes.InvalidArgument)
+
+	// The rejected statements were not replicated and the database can still be written.
+	rep, err := client.Exec(ctx, &api.ExecRequest{Statement: &api.Statement{Sql: "INSERT INTO otters (name) VALUES ('jade')"}})
+	require.NoError(t, err, "could not insert row")
+	require.Equal(t, uint64(2), rep.Index)
+
+	out, err := client.Query(ctx, &api.QueryRequest{Statement: &api.Statement{Sql: "SELECT count(*) FROM otters"}})
+	require.NoError(t, err, "could not query table")
+	require.Equal(t, int64(1), out.Rows[0].Values[0].GetInteger())
+}
+
+func TestTransaction(t *testing.T) {
+	client := newClient(t)
+	ctx := context.Background()
+
+	_, err := client.Exec(ctx, &api.ExecRequest{Statement: &api.Statement{Sql: "CREATE TABLE accounts (name TEXT PRIMARY KEY, balance INTEGER NOT NULL CHECK (balance >= 0))"}})
+	require.NoError(t, err, "could not create table")
+
+	rep, err := client.Transaction(ctx, &api.TransactionRequest{
+		Statements: []*api.Statement{
+			{Sql: "INSERT INTO accounts VALUES ('jade', 100)"},
+			{Sql: "INSERT INTO accounts VALUES ('kira', 0)"},
+		},
+	})
+	require.NoError(t, err, "could not execute transaction")
+	require.Equal(t, uint64(2), rep.Index)
+	require.Len(t, rep.Results, 2)
+	require.Equal(t, int64(2), rep.Results[1].LastInsertId)
+
+	// Transfers that would overdraw an account are rolled back
+	_, err = client.Transaction(ctx, &api.TransactionRequest{
+		Statements: []*api.Statement{
+			{Sql: "UPDATE accounts SET balance=balance+? WHERE name=?", Params: []*api.Parameter{{Value: &api.Value{Value: &api.Value_Integer{Integer: 150}}}, {Value: &api.Value{Value: &api.Value_Text{Text: "kira"}}}}},
+			{Sql: "UPDATE accounts SET balance=balance-? WHERE name=?", Params: []*api.Parameter{{Value: &api.Value{Value: &api.Value_Integer{Integer: 150}}}, {Value: &api.Value{Value: &api.Value_Text{Text: "jade"}}}}},
+		},
+	})
+	requireStatus(t, err, codes.InvalidArgument)
+
+	_, err = client.Transaction(ctx, &api.TransactionRequest{})
+	requireStatus(t, err, codes.InvalidArgument)
+
+	_, err = client.Transaction(ctx, &api.TransactionRequest{Statements: []*api.Statement{{Sql: "DELETE FROM accounts"}, {}}})
+	requireStatus(t, err, codes.InvalidArgument)
+
+	out, err := client.Query(ctx, &api.QueryRequest{Statement: &api.Statement{Sql: "SELECT sum(balance), max(balance) FROM accounts"}})
+	require.NoError(t, err, "could not query table")
+	require.Equal(t, int64(100), out.Rows[0].Values[0].GetInteger())
+	require.Equal(t, int64(100), out.Rows[0].Values[1].GetInteger())
+}
+
+// Create a single node server with replication disabled and return a client to it.
+func newClient(t *testing.T) api.OtterClient {
+	sock := newServer(t, config.ServerConfig{Enabled: true, BindAddr: bufconn.Endpoint})
+	cc, err := sock.Connect(context.Background(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err, "could not connect to server")
+
+	t.Cleanup(func() { cc.Close() })
+	return api.NewOtterClient(cc)
+}
+
+// Create a single node server with replication disabled that serves on the returned
+// bufconn listener.
+func newServer(t *testing.T, conf config.ServerConfig) *bufconn.Listener {
+	db, err := fsm.Open(filepath.Join(t.TempDir(), "otter.db"))
+	require.NoError(t, err, "could not open database")
+
+	repl, err := replica.New(config.ReplicaConfig{Enabled: false}, replica.WithStateMachine(db))
+	require.NoError(t, err, "could not create replica")
+
+	srv, err := server.New(conf, repl, db)
+	require.NoError(t, err, "could not create server")
+
+	sock := bufconn.New()
+	errc := make(chan error, 1)
+	go srv.Run(errc, sock.Sock())
+
+	t.Cleanup(func() {
+		srv.Shutdown()
+		db.Close()
+	})
+	return sock
+}
+
+// A server stream that only has a context for testing stream interceptors.
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
+
+func requireStatus(t *testing.T, err error, code codes.Code) {
+	require.Error(t, err)
+	serr, ok := status.FromError(err)
+	require.True(t, ok, "expected a grpc status error")
+	require.Equal(t, code, serr.Code(), serr.Message())
+}
+
+// Write a self-signed certificate and key for the host to the directory.
+func writeCertificate(t *testing.T, dir, host string) (certPath, keyPath string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err, "could not generate key")
+
+	cert := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: host},
+		DNSNames:              []string{host},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, cert, cert, &key.PublicKey, key)
+	require.NoError(t, err, "could not create certificate")
+
+	pkcs8, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err, "could not marshal key")
+
+	certPath, keyPath = filepath.Join(dir, host+".crt"), filepath.Join(dir, host+".key")
+	require.NoError(t, os.WriteFile(certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600))
+	require.NoError(t, os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: pkcs8}), 0600))
+	return certPath, keyPath
+}
@@ -0,0 +1,195 @@
+package server
+
+import (
+	"context"
+	"errors"
+
+	"github.com/bbengfort/otterdb/pkg/fsm"
+	"github.com/bbengfort/otterdb/pkg/replica"
+	"github.com/bbengfort/otterdb/pkg/server/api/v1"
+
+	"github.com/rs/zerolog/log"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// Exec replicates a statement that modifies the database and returns once it has been
+// committed and applied to the database of the leader. If the local replica is not the
+// leader, the statement is forwarded to the leader or, if the client requested it, a
+// redirect to the leader is returned.
+func (s *Server) Exec(ctx context.Context, in *api.ExecRequest) (out *api.ExecResult, err error) {
+	if s.db == nil {
+		return nil, status.Error(codes.Unavailable, "no database is configured on this replica")
+	}
+
+	// Validate the statement before it is replicated so that invalid statements fail fast
+	// and statements that would diverge or halt the replicas are never proposed.
+	stmt := in.GetStatement()
+	if err = fsm.Validate(stmt); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	var value []byte
+	if value, err = proto.Marshal(stmt); err != nil {
+		return nil, status.Error(codes.InvalidArgument, "could not marshal statement")
+	}
+
+	var result *fsm.Result
+	if result, err = s.commit(ctx, fsm.Exec, value, in.Redirect); err != nil {
+		return nil, err
+	}
+	return execResult(result), nil
+}
+
+// Transaction replicates an ordered list of statements as a single entry and returns
+// once all of the statements have been applied to the database of the leader in a
+// single transaction. If any statement fails, the transaction is rolled back and the
+// error of the statement is returned. Transactions are forwarded or redirected to the
+// leader in the same way as Exec.
+func (s *Server) Transaction(ctx context.Context, in *api.TransactionRequest) (out *api.TransactionResult, err error) {
+	if s.db == nil {
+		return nil, status.Error(codes.Unavailable, "no database is configured on this replica")
+	}
+
+	if len(in.Statements) == 0 {
+		return nil, status.Error(codes.InvalidArgument, fsm.ErrEmptyTransaction.Error())
+	}
+
+	for i, stmt := range in.Statements {
+		if err = fsm.Validate(stmt); err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "statement %d: %s", i, err)
+		}
+	}
+
+	// Only the statements are replicated, not how the request is routed to the leader.
+	var value []byte
+	if value, err = proto.Marshal(&api.TransactionRequest{Statements: in.Statements}); err != nil {
+		return nil, status.Error(codes.InvalidArgument, "could not marshal transaction")
+	}
+
+	var result *fsm.Result
+	if result, err = s.commit(ctx, fsm.Transaction, value, in.Redirect); err != nil {
+		return nil, err
+	}
+
+	out = &api.TransactionResult{Index: result.Index, Results: make([]*api.ExecResult, 0, len(result.Statements))}
+	for _, stmt := range result.Statements {
+		out.Results = append(out.Results, execResult(stmt))
+	}
+	return out, nil
+}
+
+// Commit the command and return the result of applying it to the database. If the
+// local replica is not the leader the command is forwarded to the leader unless a
+// redirect is requested. Errors are returned as gRPC status errors, including errors
+// executing the command against the database.
+func (s *Server) commit(ctx context.Context, name string, value []byte, redirect bool) (_ *fsm.Result, err error) {
+	var applied *replica.Applied
+	if applied, err = s.replica.Commit(ctx, name, value); err != nil {
+		if !errors.Is(err, replica.ErrNotLeader) {
+			return nil, commitError(err)
+		}
+
+		if redirect {
+			return nil, s.redirect()
+		}
+
+		if applied, err = s.replica.ForwardCommit(ctx, name, value); err != nil {
+			return nil, commitError(err)
+		}
+	}
+
+	result, ok := applied.Result.(*fsm.Result)
+	if !ok {
+		log.Error().Type("result", applied.Result).Msg("unexpected result from state machine")
+		return nil, status.Error(codes.Internal, "could not execute statement")
+	}
+
+	if result.Err != nil {
+		return nil, status.Error(codes.InvalidArgument, result.Err.Error())
+	}
+	return result, nil
+}
+
+func execResult(result *fsm.Result) *api.ExecResult {
+	return &api.ExecResult{
+		LastInsertId: result.LastInsertID,
+		RowsAffected: result.RowsAffected,
+		Index:        result.Index,
+	}
+}
+
+// Query executes a read-only statement against the local database once the requested
+// consistency level has been satisfied. Local reads may be served by any replica, so
+// long as it is not more stale than the maximum staleness. Lease and linearizable reads
+// must be served by the leader.
+func (s *Server) Query(ctx context.Context, in *api.QueryRequest) (out *api.QueryResult, err error) {
+	if s.db == nil {
+		return nil, status.Error(codes.Unavailable, "no database is configured on this replica")
+	}
+
+	if in.Statement == nil {
+		return nil, status.Error(codes.InvalidArgument, fsm.ErrEmptyStatement.Error())
+	}
+
+	switch in.Consistency {
+	case api.QueryRequest_LOCAL:
+		if in.MaxStaleness != nil {
+			if maxStaleness := in.MaxStaleness.AsDuration(); maxStaleness > 0 && s.replica.Staleness() > maxStaleness {
+				return nil, status.Error(codes.FailedPrecondition, "replica is more stale than the maximum staleness")
+			}
+		}
+	case api.QueryRequest_LEASE:
+		if err = s.replica.ConfirmRead(ctx, true); err != nil {
+			return nil, commitError(err)
+		}
+	case api.QueryRequest_LINEARIZABLE:
+		if err = s.replica.ConfirmRead(ctx, false); err != nil {
+			return nil, commitError(err)
+		}
+	default:
+		return nil, status.Errorf(codes.InvalidArgument, "unknown consistency level %s", in.Consistency)
+	}
+
+	if out, err = s.db.Query(ctx, in.Statement); err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, status.FromContextError(ctxErr).Err()
+		}
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	return out, nil
+}
+
+// Returns a status that redirects the client to the database server of the leader.
+func (s *Server) redirect() error {
+	leader, addr, err := s.replica.LeaderAddr()
+	if err != nil {
+		return commitError(err)
+	}
+
+	serr, err := status.New(codes.FailedPrecondition, replica.ErrNotLeader.Error()).WithDetails(&api.Redirect{Leader: leader, Addr: addr})
+	if err != nil {
+		log.Error().Err(err).Msg("could not create redirect status")
+		return status.Error(codes.Internal, "could not redirect to leader")
+	}
+	return serr.Err()
+}
+
+// Convert an error from committing a statement or confirming a read into a gRPC status
+// error.
+func commitError(err error) error {
+	switch {
+	case errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded):
+		return status.FromContextError(err).Err()
+	case errors.Is(err, replica.ErrNotLeader):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, replica.ErrDropped):
+		return status.Error(codes.Aborted, err.Error())
+	case errors.Is(err, replica.ErrNotListening), errors.Is(err, replica.ErrNoStateMachine), errors.Is(err, replica.ErrNoLeader), errors.Is(err, replica.ErrTransferring):
+		return status.Error(codes.Unavailable, err.Error())
+	default:
+		log.Error().Err(err).Msg("could not commit statement")
+		return status.Error(codes.Internal, "could not execute statement")
+	}
+}
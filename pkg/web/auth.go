@@ -0,0 +1,56 @@
+package web
+
+import (
+	"net"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+// Admin authenticates requests to endpoints that manage the cluster with the api keys
+// and bearer tokens of the database server. If roles are configured, the client must
+// have a role with the admin permission. If authentication is not configured, only
+// requests from the loopback interface are allowed so that the cluster cannot be
+// managed by anyone who can reach the web ui.
+func (s *Server) Admin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if s.auth == nil {
+			if !loopback(c.Request.RemoteAddr) {
+				c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"success": false, "error": "admin endpoints are only available from localhost when authentication is not configured"})
+				return
+			}
+			c.Next()
+			return
+		}
+
+		principal, err := s.auth.AuthenticateRequest(c.Request)
+		if err != nil {
+			log.Debug().Err(err).Str("path", c.FullPath()).Msg("refused unauthenticated request")
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"success": false, "error": err.Error()})
+			return
+		}
+
+		if s.roles != nil {
+			if err = s.roles.AuthorizeAdmin(principal); err != nil {
+				log.Debug().Err(err).Str("path", c.FullPath()).Str("principal", principal.Name).Msg("refused unauthorized admin request")
+				c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"success": false, "error": err.Error()})
+				return
+			}
+		}
+
+		c.Next()
+	}
+}
+
+// Returns true if the remote address of the connection is a loopback address; the
+// forwarded headers are ignored since they are set by the client.
+func loopback(remoteAddr string) bool {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
@@ -0,0 +1,18 @@
+package web
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Peers reports the reachability, last contact, and round trip time of each remote peer
+// that the local replica is connected to.
+func (s *Server) Peers(c *gin.Context) {
+	if s.replica == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"success": false, "error": "replication is not enabled"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"name": s.replica.Name(), "peers": s.replica.PeerStatus()})
+}
@@ -61,6 +61,11 @@ func (s *Server) setupRoutes() (err error) {
 	{
 		// Status/Heartbeat endpoint
 		v1.GET("/status", s.Status)
+
+		// Cluster management endpoints; endpoints that change the cluster require an admin
+		// and are not allowed cross-origin.
+		v1.GET("/peers", s.Peers)
+		v1.POST("/leader/transfer", s.Admin(), s.TransferLeadership)
 	}
 
 	return nil
@@ -0,0 +1,71 @@
+package web
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/bbengfort/otterdb/pkg/replica"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/rs/zerolog/log"
+)
+
+// The maximum amount of time to wait for the leader to step down.
+const transferTimeout = 15 * time.Second
+
+// TransferRequest specifies the replica to transfer leadership to; if the target is
+// empty, leadership is transferred to the voting member with the lowest PID.
+type TransferRequest struct {
+	Target string `json:"target"`
+}
+
+// TransferLeadership hands off leadership of the quorum from the local replica, which
+// must be the leader, e.g. before the leader is restarted for planned maintenance. The
+// client must be an admin, see Admin.
+func (s *Server) TransferLeadership(c *gin.Context) {
+	// Requests must be JSON so that a page on another origin cannot submit a form to the
+	// endpoint without a CORS preflight, which does not allow POST.
+	if c.ContentType() != binding.MIMEJSON {
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"success": false, "error": "transfer requests must be application/json"})
+		return
+	}
+
+	// An empty body transfers leadership to the default target.
+	var in TransferRequest
+	if err := c.ShouldBindJSON(&in); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "could not parse transfer request"})
+		return
+	}
+
+	if s.replica == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"success": false, "error": "replication is not enabled"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), transferTimeout)
+	defer cancel()
+
+	if err := s.replica.TransferLeadership(ctx, in.Target); err != nil {
+		switch {
+		case errors.Is(err, replica.ErrNotLeader):
+			c.JSON(http.StatusConflict, gin.H{"success": false, "error": err.Error(), "leader": s.replica.Leader()})
+		case errors.Is(err, replica.ErrTransferring):
+			c.JSON(http.StatusConflict, gin.H{"success": false, "error": err.Error()})
+		case errors.Is(err, replica.ErrNotMember), errors.Is(err, replica.ErrNoTransferTarget):
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+		case errors.Is(err, replica.ErrTransferTimeout), errors.Is(err, context.DeadlineExceeded):
+			c.JSON(http.StatusGatewayTimeout, gin.H{"success": false, "error": err.Error()})
+		case errors.Is(err, replica.ErrNotListening):
+			c.JSON(http.StatusServiceUnavailable, gin.H{"success": false, "error": err.Error()})
+		default:
+			log.Error().Err(err).Msg("could not transfer leadership")
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "could not transfer leadership"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
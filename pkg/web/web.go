@@ -15,6 +15,8 @@ import (
 	"time"
 
 	"github.com/bbengfort/otterdb/pkg/config"
+	"github.com/bbengfort/otterdb/pkg/replica"
+	"github.com/bbengfort/otterdb/pkg/server/auth"
 	"github.com/gin-gonic/gin"
 	"github.com/rs/zerolog/log"
 )
@@ -22,6 +24,9 @@ import (
 type Server struct {
 	sync.RWMutex
 	conf    config.WebConfig
+	replica *replica.Replica
+	auth    *auth.Authenticator
+	roles   *auth.Roles
 	srv     *http.Server
 	router  *gin.Engine
 	url     *url.URL
@@ -30,19 +35,34 @@ type Server struct {
 	ready   bool
 }
 
-func New(conf config.WebConfig) (srv *Server, err error) {
+// New creates the web server; the replica is used to manage the cluster from the web ui.
+// Clients that manage the cluster are authenticated and authorized with the api keys,
+// key set, and roles of the database server.
+func New(conf config.WebConfig, replica *replica.Replica, authConf config.AuthConfig) (srv *Server, err error) {
 	// Must supply a valid configuration.
 	if err = conf.Validate(); err != nil {
 		return nil, err
 	}
 
-	srv = &Server{conf: conf}
+	srv = &Server{conf: conf, replica: replica}
 
 	// If not enabled, return just the server stub
 	if !conf.Enabled {
 		return srv, nil
 	}
 
+	// Load the api keys and key set used to authenticate admins, if configured
+	if srv.auth, err = auth.New(authConf); err != nil {
+		return nil, fmt.Errorf("could not configure authentication: %w", err)
+	}
+
+	// Load the roles used to authorize admins, if configured
+	if authConf.RolesPath != "" {
+		if srv.roles, err = auth.LoadRoles(authConf.RolesPath); err != nil {
+			return nil, fmt.Errorf("could not load roles: %w", err)
+		}
+	}
+
 	// Configure the gin router when enabled
 	srv.router = gin.New()
 	srv.router.RedirectTrailingSlash = true
@@ -162,8 +182,8 @@ func (s *Server) setURL(addr net.Addr) {
 
 // Debug returns a server that uses the specified http server instead of creating one.
 // This function is primarily used to create test servers easily.
-func Debug(conf config.WebConfig, srv *http.Server) (s *Server, err error) {
-	if s, err = New(conf); err != nil {
+func Debug(conf config.WebConfig, replica *replica.Replica, authConf config.AuthConfig, srv *http.Server) (s *Server, err error) {
+	if s, err = New(conf, replica, authConf); err != nil {
 		return nil, err
 	}
 
@@ -0,0 +1,84 @@
+package web_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bbengfort/otterdb/pkg/config"
+	"github.com/bbengfort/otterdb/pkg/logger"
+	"github.com/bbengfort/otterdb/pkg/web"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMain(m *testing.M) {
+	logger.Discard()
+	exitVal := m.Run()
+	logger.ResetLogger()
+	os.Exit(exitVal)
+}
+
+func TestAdmin(t *testing.T) {
+	conf := config.WebConfig{Enabled: true, Mode: "test", BindAddr: "127.0.0.1:0", Origin: "http://localhost:2208"}
+
+	// The transfer reaches the handler, which fails since replication is not enabled.
+	transfer := func(handler http.Handler, remote, contentType string, headers ...string) int {
+		req := httptest.NewRequest(http.MethodPost, "/v1/leader/transfer", strings.NewReader(`{"target": "kira"}`))
+		req.RemoteAddr = remote
+		req.Header.Set("Content-Type", contentType)
+		for i := 0; i+1 < len(headers); i += 2 {
+			req.Header.Set(headers[i], headers[i+1])
+		}
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	t.Run("Localhost", func(t *testing.T) {
+		srv := &http.Server{}
+		_, err := web.Debug(conf, nil, config.AuthConfig{}, srv)
+		require.NoError(t, err)
+
+		// Without authentication only local clients can manage the cluster.
+		require.Equal(t, http.StatusServiceUnavailable, transfer(srv.Handler, "127.0.0.1:52000", "application/json"))
+		require.Equal(t, http.StatusServiceUnavailable, transfer(srv.Handler, "[::1]:52000", "application/json"))
+		require.Equal(t, http.StatusForbidden, transfer(srv.Handler, "10.0.0.8:52000", "application/json"))
+		require.Equal(t, http.StatusForbidden, transfer(srv.Handler, "10.0.0.8:52000", "application/json", "X-Forwarded-For", "127.0.0.1"))
+
+		// Forms can be submitted cross-origin without a preflight.
+		require.Equal(t, http.StatusUnsupportedMediaType, transfer(srv.Handler, "127.0.0.1:52000", "text/plain"))
+		require.Equal(t, http.StatusUnsupportedMediaType, transfer(srv.Handler, "127.0.0.1:52000", "application/x-www-form-urlencoded"))
+	})
+
+	t.Run("Authenticated", func(t *testing.T) {
+		dir := t.TempDir()
+		keysPath := filepath.Join(dir, "keys.json")
+		keys := `[
+			{"name": "operator", "key": "operator-key", "roles": ["operator"]},
+			{"name": "service", "key": "service-key", "roles": ["service"]}
+		]`
+		require.NoError(t, os.WriteFile(keysPath, []byte(keys), 0600))
+
+		rolesPath := filepath.Join(dir, "roles.json")
+		roles := `{
+			"operator": {"permissions": ["admin"]},
+			"service": {"permissions": ["read", "write"], "tables": ["*"]}
+		}`
+		require.NoError(t, os.WriteFile(rolesPath, []byte(roles), 0600))
+
+		srv := &http.Server{}
+		_, err := web.Debug(conf, nil, config.AuthConfig{KeysPath: keysPath, RolesPath: rolesPath}, srv)
+		require.NoError(t, err)
+
+		// With authentication clients must be admins, even from localhost.
+		require.Equal(t, http.StatusUnauthorized, transfer(srv.Handler, "127.0.0.1:52000", "application/json"))
+		require.Equal(t, http.StatusUnauthorized, transfer(srv.Handler, "10.0.0.8:52000", "application/json", "X-API-Key", "wrong"))
+		require.Equal(t, http.StatusForbidden, transfer(srv.Handler, "10.0.0.8:52000", "application/json", "X-API-Key", "service-key"))
+		require.Equal(t, http.StatusServiceUnavailable, transfer(srv.Handler, "10.0.0.8:52000", "application/json", "X-API-Key", "operator-key"))
+	})
+}